@@ -0,0 +1,109 @@
+package gormbulk
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ErrAdvisoryLockNotAcquired is returned when the advisory lock set with
+// WithAdvisoryLock couldn't be acquired.
+var ErrAdvisoryLockNotAcquired = errors.New("advisory lock not acquired")
+
+// WithAdvisoryLock will take an advisory lock with the key for the duration
+// of the bulk operation, i.e. to prevent two sync jobs from upserting the
+// same table at the same time. The lock is taken with `GET_LOCK` for MySQL
+// and `pg_advisory_lock` with a 64 bit FNV-1a hash of the key for Postgres and
+// waits until the lock is released by any other session. The lock is taken
+// before any statements set with WithSetupSQL and released after any
+// statements set with WithTeardownSQL, even if the bulk operation failed.
+// Since the lock is held by the session the connection is pinned, see
+// WithPinnedConnection, and the transactions used by BulkSync and SeedTable
+// run in the session holding the lock.
+func WithAdvisoryLock(key string) Option {
+	return func(o *options) {
+		o.advisoryLock = key
+	}
+}
+
+// advisoryLockID returns the key used for Postgres advisory locks.
+func advisoryLockID(key string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+
+	return int64(h.Sum64())
+}
+
+// lock takes the advisory lock set with WithAdvisoryLock, if any. The
+// returned function must be called to release the lock.
+func (o *options) lock(db *gorm.DB) (func() error, error) {
+	if o.advisoryLock == "" {
+		return func() error { return nil }, nil
+	}
+
+	switch dialect := db.Dialect().GetName(); dialect {
+	case "mysql":
+		acquired, err := o.queryInt(db, "SELECT GET_LOCK(?, -1)", o.advisoryLock)
+		if err != nil {
+			return nil, err
+		}
+
+		if !acquired.Valid || acquired.Int64 != 1 {
+			return nil, ErrAdvisoryLockNotAcquired
+		}
+
+		return func() error {
+			return o.exec(db, "SELECT RELEASE_LOCK(?)", o.advisoryLock)
+		}, nil
+	case "postgres":
+		id := advisoryLockID(o.advisoryLock)
+
+		if err := o.exec(db, "SELECT pg_advisory_lock(?)", id); err != nil {
+			return nil, err
+		}
+
+		return func() error {
+			return o.exec(db, "SELECT pg_advisory_unlock(?)", id)
+		}, nil
+	default:
+		return nil, fmt.Errorf("advisory locks not supported for dialect %s", dialect)
+	}
+}
+
+// queryInt runs the query returning a single integer on the pinned
+// connection, if any. The query is passed to the executor when using
+// WithExecutor, the result is then always 1.
+func (o *options) queryInt(db *gorm.DB, query string, vars ...interface{}) (sql.NullInt64, error) {
+	var result sql.NullInt64
+
+	if o.executor != nil {
+		return sql.NullInt64{Int64: 1, Valid: true}, o.exec(db, query, vars...)
+	}
+
+	if err := o.checkStatement(query, vars); err != nil {
+		return result, err
+	}
+
+	o.logStatement(query, vars, nil)
+
+	var row *sql.Row
+
+	if o.pinned(db) {
+		ctx := o.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		row = o.conn.QueryRowContext(ctx, query, vars...)
+	} else {
+		row = db.Raw(query, vars...).Row()
+	}
+
+	err := row.Scan(&result)
+
+	return result, err
+}
@@ -0,0 +1,139 @@
+package gormbulk
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithAdvisoryLock(t *testing.T) {
+	type item struct {
+		Name string
+	}
+
+	cases := []struct {
+		description      string
+		dialect          string
+		expectedMockFunc func(mock sqlmock.Sqlmock)
+		expectedErr      error
+		errorMsg         string
+	}{
+		{
+			description: "mysql lock around the bulk operation",
+			dialect:     "mysql",
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT GET_LOCK(?, -1)").
+					WithArgs("items_sync").
+					WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+				mock.ExpectExec("INSERT INTO `items` (`name`) VALUES (?)").
+					WillReturnError(errors.New("insert failed"))
+				mock.ExpectExec("SELECT RELEASE_LOCK(?)").
+					WithArgs("items_sync").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedErr: errors.New("insert failed"),
+		},
+		{
+			description: "mysql lock not acquired",
+			dialect:     "mysql",
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT GET_LOCK(?, -1)").
+					WithArgs("items_sync").
+					WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(nil))
+			},
+			expectedErr: ErrAdvisoryLockNotAcquired,
+		},
+		{
+			description: "postgres lock around the bulk operation",
+			dialect:     "postgres",
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("SELECT pg_advisory_lock($1)").
+					WithArgs(advisoryLockID("items_sync")).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`INSERT INTO "items" ("name") VALUES ($1)`).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("SELECT pg_advisory_unlock($1)").
+					WithArgs(advisoryLockID("items_sync")).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			description:      "unsupported dialect",
+			dialect:          "common",
+			expectedMockFunc: func(sqlmock.Sqlmock) {},
+			errorMsg:         "advisory locks not supported for dialect common",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open(tc.dialect, db)
+			require.NoError(t, err)
+
+			tc.expectedMockFunc(mock)
+
+			err = BulkInsert(gdb, []interface{}{item{Name: "a"}}, WithAdvisoryLock("items_sync"))
+
+			switch {
+			case tc.errorMsg != "":
+				require.EqualError(t, err, tc.errorMsg)
+			case tc.expectedErr != nil:
+				assert.Equal(t, tc.expectedErr, err)
+			default:
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWithAdvisoryLockBulkSync(t *testing.T) {
+	type item struct {
+		Name string
+	}
+
+	connector := &sessionConnector{}
+
+	db := sql.OpenDB(connector)
+	db.SetMaxOpenConns(1)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, BulkSync(
+		gdb, []interface{}{item{Name: "a"}},
+		WithContext(ctx),
+		WithAdvisoryLock("items_sync"),
+	))
+
+	require.Len(t, connector.connections, 1)
+	assert.Equal(t, []string{
+		"SELECT GET_LOCK(?, -1)",
+		"BEGIN",
+		"CREATE TEMPORARY TABLE IF NOT EXISTS `items_staging` LIKE `items`",
+		"DELETE FROM `items_staging`",
+		"INSERT INTO `items_staging` (`name`) VALUES (?)",
+		"INSERT INTO `items` (`name`) SELECT `name` FROM `items_staging` ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)",
+		"COMMIT",
+		"SELECT RELEASE_LOCK(?)",
+	}, connector.connections[0])
+}
+
+func Test_advisoryLockID(t *testing.T) {
+	assert.Equal(t, advisoryLockID("items_sync"), advisoryLockID("items_sync"))
+	assert.NotEqual(t, advisoryLockID("items_sync"), advisoryLockID("users_sync"))
+}
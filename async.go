@@ -0,0 +1,154 @@
+package gormbulk
+
+import (
+	"context"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ChunkResult is the result of a single chunk executed with BulkExecAsync.
+// Rows holds the index of the objects in the chunk.
+type ChunkResult struct {
+	Rows         []int
+	RowsAffected int64
+	Err          error
+}
+
+// BulkExecAsync will split the objects into chunks (see WithChunkSize) and
+// execute them one by one in a separate goroutine. The result of each chunk is
+// sent on the returned channel as soon as it completes and the next chunk
+// isn't started until the result is read, letting the caller control the pace.
+// The channel is closed when all chunks are done and must be read until then.
+//
+// The context works like WithContext: when no more chunks can be started, the
+// remaining rows are sent in a single result with a *NotAttemptedError. Results
+// which aren't read once the context is done are dropped so the goroutine
+// stops even if the caller stops reading.
+// The session (see WithSetupSQL) is held until all chunks are done.
+// Validation errors, session errors and errors when deleting missing rows (see
+// SyncDeleteMissing) are sent in a result without rows.
+func BulkExecAsync(ctx context.Context, db *gorm.DB, objects []interface{}, execFunc ExecFunc, opts ...Option) <-chan ChunkResult {
+	var (
+		results = make(chan ChunkResult)
+		o       = newModelOptions(firstObject(objects), append(opts, WithContext(ctx))...)
+	)
+
+	go func() {
+		defer close(results)
+
+		execAsync(db, objects, execFunc.toV2(), o, results)
+	}()
+
+	return results
+}
+
+func execAsync(db *gorm.DB, objects []interface{}, execFunc ExecFuncV2, o *options, results chan<- ChunkResult) {
+	ctx := o.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// send returns false if the result couldn't be sent because the context is
+	// done. A caller still reading gets the result even then.
+	send := func(result ChunkResult) bool {
+		select {
+		case results <- result:
+			return true
+		default:
+		}
+
+		select {
+		case results <- result:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	objects, rows, err := o.validate(objects)
+	if err != nil {
+		send(ChunkResult{Err: err})
+		return
+	}
+
+	// Nothing to do.
+	if len(objects) < 1 {
+		return
+	}
+
+	if err := o.probeHealth(db); err != nil {
+		send(ChunkResult{Err: err})
+		return
+	}
+
+	end, err := o.startSession(db)
+	if err != nil {
+		send(ChunkResult{Err: err})
+		return
+	}
+
+	defer func() {
+		if err := end(); err != nil {
+			send(ChunkResult{Err: err})
+		}
+	}()
+
+	var (
+		allObjects = objects
+		chunkSize  = o.streamChunkSize()
+		elapsed    time.Duration
+		done       int
+		failed     bool
+	)
+
+	for len(objects) > 0 {
+		size := o.chunkLen(objects, chunkSize)
+
+		if err := o.canStartChunk(elapsed, done, size); err != nil {
+			send(ChunkResult{Rows: rows, Err: &NotAttemptedError{Rows: rows, Err: err}})
+			return
+		}
+
+		chunkObjects, chunkRows := objects[:size], rows[:size]
+		objects, rows = objects[size:], rows[size:]
+
+		// Count the rows affected for each chunk separately.
+		chunkOptions := *o
+		chunkOptions.result = &Result{}
+
+		started := time.Now()
+		err := execObjects(db, chunkObjects, chunkRows, execFunc, &chunkOptions)
+
+		elapsed += time.Since(started)
+		done += size
+		o.chunk++
+
+		if err != nil {
+			failed = true
+		}
+
+		if o.result != nil {
+			o.result.RowsAffected += chunkOptions.result.RowsAffected
+			o.result.Unchanged += chunkOptions.result.Unchanged
+			o.result.StatementSizes = append(o.result.StatementSizes, chunkOptions.result.StatementSizes...)
+		}
+
+		sent := send(ChunkResult{
+			Rows:         chunkRows,
+			RowsAffected: chunkOptions.result.RowsAffected,
+			Err:          err,
+		})
+		if !sent {
+			return
+		}
+	}
+
+	if failed || !o.deleteMissing {
+		return
+	}
+
+	if err := deleteMissing(db, allObjects, o); err != nil {
+		send(ChunkResult{Err: err})
+	}
+}
@@ -0,0 +1,267 @@
+package gormbulk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkExecAsync(t *testing.T) {
+	type test struct {
+		Foo string
+	}
+
+	objects := []interface{}{test{"a"}, test{"b"}, test{"c"}}
+
+	cases := []struct {
+		description      string
+		objects          []interface{}
+		opts             []Option
+		ctx              func() (context.Context, context.CancelFunc)
+		expectedMockFunc func(mock sqlmock.Sqlmock)
+		expectedResults  []ChunkResult
+	}{
+		{
+			description: "result for each chunk",
+			objects:     objects,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `tests`").
+					WithArgs("a", "b").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec("INSERT INTO `tests`").
+					WithArgs("c").
+					WillReturnError(errors.New("some error"))
+			},
+			expectedResults: []ChunkResult{
+				{Rows: []int{0, 1}, RowsAffected: 2},
+				{Rows: []int{2}, Err: errors.New("some error")},
+			},
+		},
+		{
+			description: "chunks split by max chunk bytes",
+			objects:     objects,
+			opts:        []Option{WithMaxChunkBytes(1)},
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `tests`").
+					WithArgs("a").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO `tests`").
+					WithArgs("b").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO `tests`").
+					WithArgs("c").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedResults: []ChunkResult{
+				{Rows: []int{0}, RowsAffected: 1},
+				{Rows: []int{1}, RowsAffected: 1},
+				{Rows: []int{2}, RowsAffected: 1},
+			},
+		},
+		{
+			description: "no chunks attempted when context is done",
+			objects:     objects,
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				return ctx, cancel
+			},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {},
+			expectedResults: []ChunkResult{
+				{
+					Rows: []int{0, 1, 2},
+					Err: &NotAttemptedError{
+						Rows: []int{0, 1, 2},
+						Err:  context.Canceled,
+					},
+				},
+			},
+		},
+		{
+			description: "validation error sent without rows",
+			objects:     []interface{}{test{"a"}, nil},
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {},
+			expectedResults: []ChunkResult{
+				{Err: ValidationErrors{{Row: 1, Err: ErrNilObject}}},
+			},
+		},
+		{
+			description: "channel closed without results for empty input",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			tc.expectedMockFunc(mock)
+
+			ctx, cancel := tc.ctx()
+			defer cancel()
+
+			var results []ChunkResult
+			opts := append([]Option{WithChunkSize(2)}, tc.opts...)
+
+			for result := range BulkExecAsync(ctx, gdb, tc.objects, InsertFunc, opts...) {
+				results = append(results, result)
+			}
+
+			assert.Equal(t, tc.expectedResults, results)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestBulkExecAsyncContextDone(t *testing.T) {
+	type test struct {
+		Foo string
+	}
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	mock.ExpectExec("INSERT INTO `tests`").
+		WithArgs("a", "b").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	results := BulkExecAsync(
+		ctx, gdb,
+		[]interface{}{test{"a"}, test{"b"}},
+		InsertFunc,
+		WithChunkSize(2),
+	)
+
+	// Never read the result of the executed chunk. The goroutine must give up
+	// sending it once the context is done and close the channel.
+	for mock.ExpectationsWereMet() != nil {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+
+	_, ok := <-results
+	assert.False(t, ok)
+}
+
+func TestBulkExecAsyncSession(t *testing.T) {
+	type test struct {
+		Foo string
+	}
+
+	cases := []struct {
+		description      string
+		expectedMockFunc func(mock sqlmock.Sqlmock)
+		expectedResults  []ChunkResult
+	}{
+		{
+			description: "session held for all chunks",
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("SET unique_checks = 0").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO `tests`").
+					WithArgs("a", "b").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec("INSERT INTO `tests`").
+					WithArgs("c").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("SET unique_checks = 1").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedResults: []ChunkResult{
+				{Rows: []int{0, 1}, RowsAffected: 2},
+				{Rows: []int{2}, RowsAffected: 1},
+			},
+		},
+		{
+			description: "setup error sent without rows",
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("SET unique_checks = 0").
+					WillReturnError(errors.New("access denied"))
+				mock.ExpectExec("SET unique_checks = 1").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedResults: []ChunkResult{
+				{Err: errors.New("access denied")},
+			},
+		},
+		{
+			description: "teardown error sent without rows",
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("SET unique_checks = 0").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO `tests`").
+					WithArgs("a", "b").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec("INSERT INTO `tests`").
+					WithArgs("c").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("SET unique_checks = 1").
+					WillReturnError(errors.New("connection lost"))
+			},
+			expectedResults: []ChunkResult{
+				{Rows: []int{0, 1}, RowsAffected: 2},
+				{Rows: []int{2}, RowsAffected: 1},
+				{Err: errors.New("connection lost")},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			tc.expectedMockFunc(mock)
+
+			results := BulkExecAsync(
+				context.Background(), gdb,
+				[]interface{}{test{"a"}, test{"b"}, test{"c"}},
+				InsertFunc,
+				WithChunkSize(2),
+				WithSetupSQL("SET unique_checks = 0"),
+				WithTeardownSQL("SET unique_checks = 1"),
+			)
+
+			var received []ChunkResult
+			for result := range results {
+				received = append(received, result)
+			}
+
+			assert.Equal(t, tc.expectedResults, received)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
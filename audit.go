@@ -0,0 +1,71 @@
+package gormbulk
+
+import "github.com/jinzhu/gorm"
+
+const (
+	// AuditOperationColumn is the column in the audit table holding the
+	// operation.
+	AuditOperationColumn = "operation"
+
+	// AuditTimestampColumn is the column in the audit table holding the time
+	// the rows were written.
+	AuditTimestampColumn = "audited_at"
+)
+
+// WithAuditTable will insert all rows to the audit table in the same
+// transaction as the bulk statement. Besides the columns from the objects, the
+// audit table must have the columns AuditOperationColumn and
+// AuditTimestampColumn. The operation defaults to INSERT and may be changed
+// with WithAuditOperation.
+func WithAuditTable(table string) Option {
+	return func(o *options) {
+		o.auditTable = table
+	}
+}
+
+// WithAuditOperation sets the value for the operation column in the audit
+// table, i.e. UPSERT.
+func WithAuditOperation(operation string) Option {
+	return func(o *options) {
+		o.auditOperation = operation
+	}
+}
+
+// auditOptions returns a copy of the options used to build the statement for
+// the audit table.
+func (o *options) auditOptions() *options {
+	var (
+		ao        = *o
+		operation = o.auditOperation
+		auditedAt = o.currentTime()
+	)
+
+	if operation == "" {
+		operation = "INSERT"
+	}
+
+	ao.auditTable = ""
+	ao.versionColumn = ""
+	ao.skipNullUpdates = false
+	ao.statementCache = nil
+	ao.columnValues = map[string]ColumnValueFunc{
+		AuditOperationColumn: func(int, interface{}) interface{} { return operation },
+		AuditTimestampColumn: func(int, interface{}) interface{} { return auditedAt },
+	}
+
+	for k, v := range o.columnValues {
+		ao.columnValues[k] = v
+	}
+
+	return &ao
+}
+
+// execAudit inserts the objects to the audit table.
+func execAudit(tx *gorm.DB, objects []interface{}, rows []int, o *options) error {
+	scope, err := scopeFromObjects(tx.Table(o.auditTable), objects, rows, plainInsertFunc, o.auditOptions())
+	if err != nil {
+		return err
+	}
+
+	return o.exec(tx, scope.SQL, scope.SQLVars...)
+}
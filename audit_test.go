@@ -0,0 +1,95 @@
+package gormbulk
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditTable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	now := time.Date(2019, 11, 1, 0, 0, 0, 0, time.UTC)
+	nowFunc := WithNowFunc(func() time.Time { return now })
+
+	type user struct {
+		Name string
+	}
+
+	cases := []struct {
+		description      string
+		options          []Option
+		expectedMockFunc func(mock sqlmock.Sqlmock)
+		errContains      string
+	}{
+		{
+			description: "rows inserted to audit table in same transaction",
+			options:     []Option{WithAuditTable("users_history")},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `users` \\(`name`\\)").
+					WithArgs("foo", "bar").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec("INSERT INTO `users_history` \\(`audited_at`, `name`, `operation`\\)").
+					WithArgs(now, "foo", "INSERT", now, "bar", "INSERT").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			description: "custom operation",
+			options: []Option{
+				WithAuditTable("users_history"),
+				WithAuditOperation("UPSERT"),
+			},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `users`").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec("INSERT INTO `users_history`").
+					WithArgs(now, "foo", "UPSERT", now, "bar", "UPSERT").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			description: "rolled back if audit fails",
+			options:     []Option{WithAuditTable("users_history")},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `users`").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec("INSERT INTO `users_history`").
+					WillReturnError(errors.New("no such table"))
+				mock.ExpectRollback()
+			},
+			errContains: "no such table",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			tc.expectedMockFunc(mock)
+
+			err := BulkInsert(gdb, []interface{}{user{"foo"}, user{"bar"}}, append(tc.options, nowFunc)...)
+
+			if tc.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errContains)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package gormbulk
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ErrIDBackfillNotSupported is returned in an *IDBackfillError when the IDs
+// can't be backfilled for the dialect or options used, see WithIDBackfill.
+var ErrIDBackfillNotSupported = errors.New("ID backfill not supported for dialect or options")
+
+// WithIDBackfill will set the primary key of each object to the ID generated
+// by the database. The objects must be pointers to structs with a single blank
+// integer primary key. The IDs are derived from the last insert ID and the
+// number of rows in each statement, which is only supported for plain inserts
+// with MySQL (requiring consecutive IDs for multi row inserts, i.e.
+// innodb_autoinc_lock_mode 0 or 1) and SQLite, and not with WithExecutor,
+// WithMultiStatement or PadPartialChunk. The IDs are assigned in the order the objects are bound
+// in each statement, so each object gets its own ID in the original slice
+// order also when executing in chunks, shards or with retries. If not every
+// row was inserted, i.e. with InsertIgnoreFunc, no IDs are set and an
+// *IDBackfillError is returned.
+func WithIDBackfill() Option {
+	return func(o *options) {
+		o.idBackfill = true
+	}
+}
+
+// checkIDBackfill returns an *IDBackfillError if the IDs can't be backfilled
+// for the objects in the statement. It's called before the statement is
+// executed.
+func (o *options) checkIDBackfill(db *gorm.DB, objects []interface{}, rows []int) error {
+	if !o.idBackfill {
+		return nil
+	}
+
+	switch db.Dialect().GetName() {
+	case "mysql", "sqlite3":
+	default:
+		return &IDBackfillError{Rows: rows, Err: ErrIDBackfillNotSupported}
+	}
+
+	if o.executor != nil || o.multiStatement() || o.partialChunkPolicy == PadPartialChunk {
+		return &IDBackfillError{Rows: rows, Err: ErrIDBackfillNotSupported}
+	}
+
+	for i, object := range objects {
+		field, err := backfillField(db, object)
+		if err == nil && !field.IsBlank {
+			err = errors.New("primary key already set")
+		}
+
+		if err != nil {
+			return &IDBackfillError{Rows: rows, Err: &RowError{Row: rows[i], Err: err}}
+		}
+	}
+
+	return nil
+}
+
+// backfillField returns the primary key field of the object.
+func backfillField(db *gorm.DB, object interface{}) (*gorm.Field, error) {
+	rv := reflect.ValueOf(object)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("object must be a pointer to a struct, got %T", object)
+	}
+
+	scope := db.NewScope(object)
+	if len(scope.PrimaryFields()) != 1 {
+		return nil, errors.New("object must have a single primary key")
+	}
+
+	field := scope.PrimaryField()
+
+	switch field.Field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		return nil, fmt.Errorf("primary key %s must be an integer", field.Name)
+	}
+
+	return field, nil
+}
+
+// backfillIDs sets the primary key of the objects to the IDs generated for the
+// statement. MySQL returns the first ID generated and SQLite the last.
+func (o *options) backfillIDs(db *gorm.DB, objects []interface{}, rows []int, res sql.Result) error {
+	if !o.idBackfill {
+		return nil
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return &IDBackfillError{Rows: rows, Err: err}
+	}
+
+	if rowsAffected != int64(len(objects)) {
+		return &IDBackfillError{
+			Rows: rows,
+			Err:  fmt.Errorf("%d of %d rows inserted", rowsAffected, len(objects)),
+		}
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return &IDBackfillError{Rows: rows, Err: err}
+	}
+
+	if db.Dialect().GetName() == "sqlite3" {
+		id -= int64(len(objects) - 1)
+	}
+
+	for i, object := range objects {
+		field, err := backfillField(db, object)
+		if err == nil {
+			err = field.Set(id + int64(i))
+		}
+
+		if err != nil {
+			return &IDBackfillError{Rows: rows, Err: &RowError{Row: rows[i], Err: err}}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package gormbulk
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithIDBackfill(t *testing.T) {
+	type user struct {
+		ID   uint
+		Name string
+	}
+
+	type result struct {
+		lastInsertID int64
+		rowsAffected int64
+		err          error
+	}
+
+	cases := []struct {
+		description string
+		dialect     string
+		objects     []interface{}
+		opts        []Option
+		results     []result
+		expectedIDs []uint
+		expectedErr error
+	}{
+		{
+			description: "ids in input order across chunks",
+			dialect:     "mysql",
+			objects:     []interface{}{&user{Name: "a"}, &user{Name: "b"}, &user{Name: "c"}},
+			results:     []result{{lastInsertID: 10, rowsAffected: 2}, {lastInsertID: 20, rowsAffected: 1}},
+			expectedIDs: []uint{10, 11, 20},
+		},
+		{
+			description: "sqlite returns the last id",
+			dialect:     "sqlite3",
+			objects:     []interface{}{&user{Name: "a"}, &user{Name: "b"}, &user{Name: "c"}},
+			results:     []result{{lastInsertID: 11, rowsAffected: 2}, {lastInsertID: 12, rowsAffected: 1}},
+			expectedIDs: []uint{10, 11, 12},
+		},
+		{
+			description: "ids from the retried statement",
+			dialect:     "mysql",
+			objects:     []interface{}{&user{Name: "a"}, &user{Name: "b"}, &user{Name: "c"}},
+			opts:        []Option{WithRetryBudget(1, time.Millisecond)},
+			results: []result{
+				{err: errors.New("deadlock")},
+				{lastInsertID: 30, rowsAffected: 2},
+				{lastInsertID: 40, rowsAffected: 1},
+			},
+			expectedIDs: []uint{30, 31, 40},
+		},
+		{
+			description: "skipped nil objects",
+			dialect:     "mysql",
+			objects:     []interface{}{&user{Name: "a"}, nil, &user{Name: "b"}},
+			opts:        []Option{WithNilPolicy(SkipNil)},
+			results:     []result{{lastInsertID: 10, rowsAffected: 2}},
+			expectedIDs: []uint{10, 0, 11},
+		},
+		{
+			description: "not all rows inserted",
+			dialect:     "mysql",
+			objects:     []interface{}{&user{Name: "a"}, &user{Name: "b"}},
+			opts:        []Option{WithRetryBudget(1, time.Millisecond)},
+			results:     []result{{lastInsertID: 10, rowsAffected: 1}},
+			expectedIDs: []uint{0, 0},
+			expectedErr: &IDBackfillError{Rows: []int{0, 1}, Err: errors.New("1 of 2 rows inserted")},
+		},
+		{
+			description: "postgres not supported",
+			dialect:     "postgres",
+			objects:     []interface{}{&user{Name: "a"}},
+			expectedIDs: []uint{0},
+			expectedErr: &IDBackfillError{Rows: []int{0}, Err: ErrIDBackfillNotSupported},
+		},
+		{
+			description: "padded partial chunks not supported",
+			dialect:     "mysql",
+			objects:     []interface{}{&user{Name: "a"}},
+			opts:        []Option{WithPartialChunkPolicy(PadPartialChunk)},
+			expectedIDs: []uint{0},
+			expectedErr: &IDBackfillError{Rows: []int{0}, Err: ErrIDBackfillNotSupported},
+		},
+		{
+			description: "primary key already set",
+			dialect:     "mysql",
+			objects:     []interface{}{&user{Name: "a"}, &user{ID: 5, Name: "b"}},
+			expectedIDs: []uint{0, 5},
+			expectedErr: &IDBackfillError{
+				Rows: []int{0, 1},
+				Err:  &RowError{Row: 1, Err: errors.New("primary key already set")},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open(tc.dialect, db)
+			require.NoError(t, err)
+
+			for _, res := range tc.results {
+				expected := mock.ExpectExec(regexp.QuoteMeta("INSERT INTO"))
+
+				if res.err != nil {
+					expected.WillReturnError(res.err)
+					continue
+				}
+
+				expected.WillReturnResult(sqlmock.NewResult(res.lastInsertID, res.rowsAffected))
+			}
+
+			opts := append([]Option{WithIDBackfill()}, tc.opts...)
+			errs := BulkExecChunk(gdb, tc.objects, InsertFunc, 2, opts...)
+
+			if tc.expectedErr != nil {
+				require.Len(t, errs, 1)
+				assert.Equal(t, tc.expectedErr, errs[0])
+			} else {
+				require.Empty(t, errs)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+
+			ids := make([]uint, len(tc.objects))
+			for i, object := range tc.objects {
+				if u, ok := object.(*user); ok {
+					ids[i] = u.ID
+				}
+			}
+
+			assert.Equal(t, tc.expectedIDs, ids)
+		})
+	}
+}
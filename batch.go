@@ -0,0 +1,40 @@
+package gormbulk
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+)
+
+// WithBatchID will stamp every row with the batch ID in the column, i.e.
+// `import_id`, so the whole bulk load can be referenced later. If the ID is
+// empty a random UUID is generated for each bulk call (or BulkWriter). The ID
+// used is set in Result.BatchID when using WithResult.
+func WithBatchID(column, id string) Option {
+	return func(o *options) {
+		batchID := id
+		if batchID == "" {
+			batchID = newUUID()
+		}
+
+		o.batchID = batchID
+
+		WithColumnValue(column, func(_ int, _ interface{}) interface{} {
+			return batchID
+		})(o)
+	}
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+
+	if _, err := io.ReadFull(rand.Reader, b[:]); err != nil {
+		panic(fmt.Sprintf("could not generate UUID: %v", err))
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
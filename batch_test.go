@@ -0,0 +1,66 @@
+package gormbulk
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithBatchID(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	cases := []struct {
+		description string
+		id          string
+	}{
+		{
+			description: "batch ID set by caller",
+			id:          "import-42",
+		},
+		{
+			description: "batch ID generated",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			var batchID interface{} = sqlmock.AnyArg()
+			if tc.id != "" {
+				batchID = tc.id
+			}
+
+			mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`import_id`, `name`) VALUES (?, ?), (?, ?)")).
+				WithArgs(batchID, "a", batchID, "b").
+				WillReturnResult(sqlmock.NewResult(0, 2))
+
+			var (
+				result  = &Result{}
+				objects = []interface{}{user{"a"}, user{"b"}}
+			)
+
+			require.NoError(t, BulkInsert(gdb, objects, WithBatchID("import_id", tc.id), WithResult(result)))
+			require.NoError(t, mock.ExpectationsWereMet())
+
+			if tc.id != "" {
+				assert.Equal(t, tc.id, result.BatchID)
+				return
+			}
+
+			assert.Regexp(t, uuid, result.BatchID)
+		})
+	}
+}
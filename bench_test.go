@@ -0,0 +1,125 @@
+package gormbulk
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+)
+
+// The benchmarks are run for every combination of rows and columns. The
+// baseline is kept in testdata/benchmarks.txt, see the Benchmarks section in
+// the README for how to compare against it.
+var (
+	benchmarkRowCounts    = []int{10, 100, 1000}
+	benchmarkColumnCounts = []int{5, 20, 50}
+	benchmarkChunkSizes   = []int{10, 100, 1000}
+)
+
+// benchmarkType returns a struct type with the number of columns, alternating
+// between string and int64 fields.
+func benchmarkType(columns int) reflect.Type {
+	fields := make([]reflect.StructField, columns)
+
+	for i := range fields {
+		fields[i] = reflect.StructField{
+			Name: fmt.Sprintf("Column%d", i),
+			Type: reflect.TypeOf(""),
+		}
+
+		if i%2 == 1 {
+			fields[i].Type = reflect.TypeOf(int64(0))
+		}
+	}
+
+	return reflect.StructOf(fields)
+}
+
+// benchmarkObjects returns the number of rows with all fields set.
+func benchmarkObjects(rows, columns int) []interface{} {
+	var (
+		t       = benchmarkType(columns)
+		objects = make([]interface{}, rows)
+	)
+
+	for i := range objects {
+		rv := reflect.New(t).Elem()
+
+		for j := 0; j < columns; j++ {
+			if j%2 == 1 {
+				rv.Field(j).SetInt(int64(i * j))
+				continue
+			}
+
+			rv.Field(j).SetString(fmt.Sprintf("row %d column %d", i, j))
+		}
+
+		objects[i] = rv.Interface()
+	}
+
+	return objects
+}
+
+// benchmarkDB returns a DB where no statements are expected to be executed.
+func benchmarkDB(b *testing.B) *gorm.DB {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	gdb, err := gorm.Open("mysql", db)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return gdb.Table("benchmarks")
+}
+
+// noopExecutor discards all statements.
+var noopExecutor = ExecutorFunc(func(*gorm.DB, string, ...interface{}) error {
+	return nil
+})
+
+func BenchmarkBuildSQL(b *testing.B) {
+	db := benchmarkDB(b)
+
+	for _, rows := range benchmarkRowCounts {
+		for _, columns := range benchmarkColumnCounts {
+			objects := benchmarkObjects(rows, columns)
+
+			b.Run(fmt.Sprintf("rows=%d/columns=%d", rows, columns), func(b *testing.B) {
+				b.ReportAllocs()
+
+				for i := 0; i < b.N; i++ {
+					if _, err := scopeFromObjects(db, objects, nil, ExecFunc(InsertFunc).toV2(), newOptions()); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkBulkExecChunk(b *testing.B) {
+	const rows = 1000
+
+	db := benchmarkDB(b)
+
+	for _, columns := range benchmarkColumnCounts {
+		objects := benchmarkObjects(rows, columns)
+
+		for _, chunkSize := range benchmarkChunkSizes {
+			b.Run(fmt.Sprintf("rows=%d/columns=%d/chunk=%d", rows, columns, chunkSize), func(b *testing.B) {
+				b.ReportAllocs()
+
+				for i := 0; i < b.N; i++ {
+					if errs := BulkExecChunk(db, objects, InsertFunc, chunkSize, WithExecutor(noopExecutor)); len(errs) > 0 {
+						b.Fatal(errs)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package gormbulk
+
+// WithMaxChunkBytes will end a chunk early when executing with BulkExecChunk
+// if adding the next object would make the estimated size of the bound values
+// exceed n bytes. Byte slices, such as BLOB columns, are counted by their
+// length, which makes it possible to stay below limits such as MySQL's
+// max_allowed_packet regardless of the size of the blobs. A chunk always holds
+// at least one object.
+func WithMaxChunkBytes(n int) Option {
+	return func(o *options) {
+		o.maxChunkBytes = n
+	}
+}
+
+// WithBlobRowPerStatement will execute each object with a non empty byte
+// slice field in a statement of its own when executing with BulkExecChunk.
+// Objects without blobs are chunked as usual, but a chunk never spans across
+// an object with a blob.
+func WithBlobRowPerStatement() Option {
+	return func(o *options) {
+		o.blobRowPerStatement = true
+	}
+}
+
+// chunkLen returns the number of objects to execute in the next chunk, which
+// is at most chunkSize and at least one.
+func (o *options) chunkLen(objects []interface{}, chunkSize int) int {
+	size := chunkSize
+	if len(objects) < size {
+		size = len(objects)
+	}
+
+	if o.maxChunkBytes < 1 && !o.blobRowPerStatement {
+		return size
+	}
+
+	var bytes int
+
+	for i, object := range objects[:size] {
+		objectBytes, hasBlob := o.objectSize(object)
+
+		if o.blobRowPerStatement && hasBlob {
+			if i == 0 {
+				return 1
+			}
+
+			return i
+		}
+
+		bytes += objectBytes
+
+		if o.maxChunkBytes > 0 && bytes > o.maxChunkBytes && i > 0 {
+			return i
+		}
+	}
+
+	return size
+}
+
+// objectSize returns the approximate size in bytes of the values bound for
+// the object and if any of the fields is a non empty byte slice. Objects that
+// can't be converted are left to fail when building the statement.
+func (o *options) objectSize(object interface{}) (int, bool) {
+	fields, err := o.objectToMap(object)
+	if err != nil {
+		return 0, false
+	}
+
+	var (
+		size    int
+		hasBlob bool
+	)
+
+	for _, field := range fields {
+		if !field.Field.IsValid() {
+			continue
+		}
+
+		value := derefValue(fieldValue(field))
+
+		if b, ok := value.([]byte); ok && len(b) > 0 {
+			hasBlob = true
+		}
+
+		size += encodedSize("", []interface{}{value})
+	}
+
+	return size, hasBlob
+}
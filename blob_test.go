@@ -0,0 +1,87 @@
+package gormbulk
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlobChunking(t *testing.T) {
+	type file struct {
+		Name string
+		Data []byte
+	}
+
+	objects := []interface{}{
+		file{Name: "a"},
+		file{Name: "b"},
+		file{Name: "c", Data: bytes.Repeat([]byte("x"), 100)},
+		file{Name: "d"},
+		file{Name: "e"},
+	}
+
+	cases := []struct {
+		description  string
+		opts         []Option
+		chunkSize    int
+		expectedRows []int
+	}{
+		{
+			description:  "chunked by size only",
+			chunkSize:    10,
+			expectedRows: []int{5},
+		},
+		{
+			description:  "blob row in statement of its own",
+			opts:         []Option{WithBlobRowPerStatement()},
+			chunkSize:    10,
+			expectedRows: []int{2, 1, 2},
+		},
+		{
+			description:  "chunk ended before exceeding max bytes",
+			opts:         []Option{WithMaxChunkBytes(102)},
+			chunkSize:    10,
+			expectedRows: []int{2, 2, 1},
+		},
+		{
+			description:  "object exceeding max bytes is executed alone",
+			opts:         []Option{WithMaxChunkBytes(50)},
+			chunkSize:    10,
+			expectedRows: []int{2, 1, 2},
+		},
+		{
+			description:  "chunk size still applies",
+			opts:         []Option{WithMaxChunkBytes(1000)},
+			chunkSize:    2,
+			expectedRows: []int{2, 2, 1},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, _, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			var rows []int
+
+			executor := ExecutorFunc(func(_ *gorm.DB, _ string, vars ...interface{}) error {
+				rows = append(rows, len(vars)/2)
+				return nil
+			})
+
+			opts := append([]Option{WithExecutor(executor)}, tc.opts...)
+
+			errs := BulkExecChunk(gdb, objects, InsertFunc, tc.chunkSize, opts...)
+			require.Empty(t, errs)
+
+			assert.Equal(t, tc.expectedRows, rows)
+		})
+	}
+}
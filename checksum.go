@@ -0,0 +1,111 @@
+package gormbulk
+
+import (
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// WithChecksumColumn will set the column for every row to a SHA-256 checksum
+// (hex encoded) of the values of all fields in the object. CreatedAt,
+// UpdatedAt and encrypted fields are not part of the checksum and neither are
+// columns set with WithColumnValue. When set, InsertOnDuplicateKeyUpdateFunc
+// will only update existing rows if the checksum differs, which takes
+// precedence over the version column.
+//
+//  ON DUPLICATE KEY UPDATE
+//    col1 = IF(NOT (checksum <=> VALUES(checksum)), VALUES(col1), col1),
+//    checksum = IF(NOT (checksum <=> VALUES(checksum)), VALUES(checksum), checksum)
+func WithChecksumColumn(column string) Option {
+	return func(o *options) {
+		o.checksumColumn = column
+	}
+}
+
+// checksum returns the checksum for the fields of an object.
+func (o *options) checksum(fields map[string]*gorm.Field) (string, error) {
+	return o.checksumFields(fields, fields)
+}
+
+// checksumFields returns the checksum for the fields of an object using the
+// values from the values fields, which may be the fields of another object of
+// the same type. A field missing in values is treated as NULL.
+func (o *options) checksumFields(fields, values map[string]*gorm.Field) (string, error) {
+	var names []string
+
+	for name, field := range fields {
+		if name == o.checksumColumn {
+			continue
+		}
+
+		if _, ok := encryptColumn(field.StructField); ok {
+			continue
+		}
+
+		switch field.Struct.Name {
+		case "CreatedAt", "UpdatedAt":
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	hash := sha256.New()
+
+	for _, name := range names {
+		encoded, err := o.fieldChecksumValue(values[name])
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(hash, "%s=%s\x00", name, encoded)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// fieldChecksumValue returns the stable representation of the value bound for
+// the field.
+func (o *options) fieldChecksumValue(field *gorm.Field) (string, error) {
+	if field == nil {
+		return checksumValue(nil)
+	}
+
+	value, err := o.convertValue(field, fieldValue(field))
+	if err != nil {
+		return "", err
+	}
+
+	return checksumValue(value)
+}
+
+// checksumValue returns a stable representation of the value. Time values are
+// represented in UTC so the checksum doesn't depend on the location.
+func checksumValue(value interface{}) (string, error) {
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+
+		value = v
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return "NULL", nil
+	case time.Time:
+		return fmt.Sprintf("%q", v.UTC().Format(time.RFC3339Nano)), nil
+	case []byte:
+		return fmt.Sprintf("%x", v), nil
+	default:
+		return fmt.Sprintf("%q", fmt.Sprint(v)), nil
+	}
+}
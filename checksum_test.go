@@ -0,0 +1,72 @@
+package gormbulk
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithChecksumColumn(t *testing.T) {
+	type item struct {
+		Name      string
+		Seen      time.Time
+		CreatedAt time.Time
+	}
+
+	var (
+		seen      = time.Date(2019, 11, 1, 12, 0, 0, 0, time.UTC)
+		stockholm = time.FixedZone("Europe/Stockholm", 3600)
+	)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	objects := []interface{}{
+		item{Name: "a", Seen: seen},
+		item{Name: "a", Seen: seen.In(stockholm), CreatedAt: seen},
+		item{Name: "b", Seen: seen},
+	}
+
+	fields, err := ObjectToMap(objects[0])
+	require.NoError(t, err)
+
+	o := newOptions(WithChecksumColumn("checksum"))
+
+	checksumA, err := o.checksum(fields)
+	require.NoError(t, err)
+	assert.Len(t, checksumA, 64)
+
+	fields, err = ObjectToMap(objects[2])
+	require.NoError(t, err)
+
+	checksumB, err := o.checksum(fields)
+	require.NoError(t, err)
+	assert.NotEqual(t, checksumA, checksumB)
+
+	condition := "IF(NOT (`checksum` <=> VALUES(`checksum`))"
+
+	mock.ExpectExec(regexp.QuoteMeta(
+		"INSERT INTO `items` (`checksum`, `created_at`, `name`, `seen`) VALUES (?, ?, ?, ?), (?, ?, ?, ?), (?, ?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE "+
+			"`name` = "+condition+", VALUES(`name`), `name`), "+
+			"`seen` = "+condition+", VALUES(`seen`), `seen`), "+
+			"`checksum` = "+condition+", VALUES(`checksum`), `checksum`)",
+	)).
+		WithArgs(
+			checksumA, sqlmock.AnyArg(), "a", seen,
+			checksumA, seen, "a", seen.In(stockholm),
+			checksumB, sqlmock.AnyArg(), "b", seen,
+		).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	require.NoError(t, BulkInsertOnDuplicateKeyUpdate(gdb, objects, WithChecksumColumn("checksum")))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,78 @@
+package gormbulk
+
+import "io"
+
+// Chunks splits the objects in chunks of at most size objects the same way as
+// BulkExecChunk, i.e. to execute each chunk in a transaction of your own. The
+// options affecting the chunks, such as WithMaxChunkBytes and
+// WithBlobRowPerStatement, are honored. If size is less than one nil is
+// returned.
+func Chunks(objects []interface{}, size int, opts ...Option) [][]interface{} {
+	if size < 1 {
+		return nil
+	}
+
+	var (
+		chunks [][]interface{}
+		o      = newModelOptions(firstObject(objects), opts...)
+	)
+
+	for len(objects) > 0 {
+		n := o.chunkLen(objects, size)
+
+		chunks = append(chunks, objects[:n:n])
+		objects = objects[n:]
+	}
+
+	return chunks
+}
+
+// StreamChunks works like Chunks but reads the objects by calling next until
+// it returns io.EOF and calls fn with each chunk as soon as it's complete. At
+// most size objects are held in memory at the same time. Any error from next
+// or fn is returned as is and stops the stream.
+func StreamChunks(next func() (interface{}, error), size int, fn func(chunk []interface{}) error, opts ...Option) error {
+	if size < 1 {
+		return &ChunkSizeError{Size: size}
+	}
+
+	var (
+		buffered []interface{}
+		o        = newOptions(opts...)
+	)
+
+	// flush calls fn with complete chunks from the buffered objects until
+	// less than min objects are left.
+	flush := func(min int) error {
+		for len(buffered) > 0 && len(buffered) >= min {
+			n := o.chunkLen(buffered, size)
+
+			if err := fn(buffered[:n:n]); err != nil {
+				return err
+			}
+
+			buffered = buffered[n:]
+		}
+
+		return nil
+	}
+
+	for {
+		object, err := next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		buffered = append(buffered, object)
+
+		if err := flush(size); err != nil {
+			return err
+		}
+	}
+
+	return flush(1)
+}
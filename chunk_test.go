@@ -0,0 +1,112 @@
+package gormbulk
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunks(t *testing.T) {
+	type file struct {
+		Name string
+		Data []byte
+	}
+
+	objects := []interface{}{
+		file{Name: "a"},
+		file{Name: "b"},
+		file{Name: "c", Data: []byte("blob")},
+		file{Name: "d"},
+		file{Name: "e"},
+	}
+
+	cases := []struct {
+		description string
+		size        int
+		opts        []Option
+		expected    [][]interface{}
+	}{
+		{
+			description: "invalid size",
+			size:        0,
+		},
+		{
+			description: "last chunk is smaller",
+			size:        2,
+			expected: [][]interface{}{
+				objects[0:2], objects[2:4], objects[4:5],
+			},
+		},
+		{
+			description: "one chunk",
+			size:        10,
+			expected: [][]interface{}{
+				objects,
+			},
+		},
+		{
+			description: "blob row in chunk of its own",
+			size:        10,
+			opts:        []Option{WithBlobRowPerStatement()},
+			expected: [][]interface{}{
+				objects[0:2], objects[2:3], objects[3:5],
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Chunks(objects, tc.size, tc.opts...))
+
+			if tc.size < 1 {
+				return
+			}
+
+			var (
+				i        int
+				streamed [][]interface{}
+			)
+
+			next := func() (interface{}, error) {
+				if i == len(objects) {
+					return nil, io.EOF
+				}
+
+				i++
+
+				return objects[i-1], nil
+			}
+
+			err := StreamChunks(next, tc.size, func(chunk []interface{}) error {
+				streamed = append(streamed, chunk)
+				return nil
+			}, tc.opts...)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, streamed)
+		})
+	}
+}
+
+func TestStreamChunksErrors(t *testing.T) {
+	assert.Equal(t, &ChunkSizeError{Size: 0}, StreamChunks(nil, 0, nil))
+
+	errNext := errors.New("next failed")
+	next := func() (interface{}, error) {
+		return nil, errNext
+	}
+
+	assert.Equal(t, errNext, StreamChunks(next, 1, nil))
+
+	errChunk := errors.New("chunk failed")
+	next = func() (interface{}, error) {
+		return 1, nil
+	}
+
+	assert.Equal(t, errChunk, StreamChunks(next, 1, func([]interface{}) error {
+		return errChunk
+	}))
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const usage = `Usage:
+  bench-compare [flags] <old> <new>
+
+Compare the output of two go test -bench runs, i.e. the baseline in
+testdata/benchmarks.txt and a new run. Results for the same benchmark run
+multiple times (-count) are averaged. Exits with status 1 if time or
+allocations per operation increased more than the threshold for any benchmark.
+
+Flags:
+`
+
+// result holds the averaged metrics for a benchmark.
+type result struct {
+	nsPerOp     float64
+	allocsPerOp float64
+	runs        int
+}
+
+func main() {
+	var (
+		help      bool
+		threshold float64
+	)
+
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+
+	flag.BoolVar(&help, "h", false, "Show this help text")
+	flag.BoolVar(&help, "help", false, "")
+	flag.Float64Var(&threshold, "threshold", 10, "Max increase in percent before reporting a regression")
+	flag.Parse()
+
+	if help {
+		flag.Usage()
+		return
+	}
+
+	regressed, err := run(os.Stdout, threshold, flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bench-compare: %v\n", err)
+		os.Exit(2)
+	}
+
+	if regressed {
+		os.Exit(1)
+	}
+}
+
+func run(w io.Writer, threshold float64, args []string) (bool, error) {
+	if len(args) != 2 {
+		flag.Usage()
+		return false, errors.New("old and new benchmark files are required")
+	}
+
+	oldResults, err := parseFile(args[0])
+	if err != nil {
+		return false, err
+	}
+
+	newResults, err := parseFile(args[1])
+	if err != nil {
+		return false, err
+	}
+
+	var names []string
+
+	for name := range newResults {
+		if _, ok := oldResults[name]; ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	var (
+		regressed bool
+		tw        = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	)
+
+	fmt.Fprintln(tw, "benchmark\told ns/op\tnew ns/op\tdelta\told allocs/op\tnew allocs/op\tdelta\t")
+
+	for _, name := range names {
+		var (
+			o          = oldResults[name]
+			n          = newResults[name]
+			nsDelta    = delta(o.nsPerOp, n.nsPerOp)
+			allocDelta = delta(o.allocsPerOp, n.allocsPerOp)
+			mark       string
+		)
+
+		if nsDelta > threshold || allocDelta > threshold {
+			regressed = true
+			mark = "REGRESSION"
+		}
+
+		fmt.Fprintf(
+			tw, "%s\t%.0f\t%.0f\t%+.1f%%\t%.0f\t%.0f\t%+.1f%%\t%s\n",
+			name, o.nsPerOp, n.nsPerOp, nsDelta, o.allocsPerOp, n.allocsPerOp, allocDelta, mark,
+		)
+	}
+
+	return regressed, tw.Flush()
+}
+
+// delta returns the change from old to new in percent.
+func delta(before, after float64) float64 {
+	if before == 0 {
+		return 0
+	}
+
+	return (after - before) / before * 100
+}
+
+func parseFile(name string) (map[string]*result, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return parse(f)
+}
+
+// parse reads the benchmark lines from go test -bench output. The GOMAXPROCS
+// suffix is removed from the names so runs from different machines can be
+// compared.
+func parse(r io.Reader) (map[string]*result, error) {
+	results := map[string]*result{}
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+
+		name := fields[0]
+		if i := strings.LastIndex(name, "-"); i > 0 {
+			if _, err := strconv.Atoi(name[i+1:]); err == nil {
+				name = name[:i]
+			}
+		}
+
+		res, ok := results[name]
+		if !ok {
+			res = &result{}
+			results[name] = res
+		}
+
+		res.runs++
+
+		// Metrics are pairs of value and unit after the number of iterations.
+		for i := 2; i+1 < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q for %s", fields[i], name)
+			}
+
+			// Keep a running average for repeated runs.
+			avg := func(current float64) float64 {
+				return current + (value-current)/float64(res.runs)
+			}
+
+			switch fields[i+1] {
+			case "ns/op":
+				res.nsPerOp = avg(res.nsPerOp)
+			case "allocs/op":
+				res.allocsPerOp = avg(res.allocsPerOp)
+			}
+		}
+	}
+
+	return results, scanner.Err()
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/jinzhu/gorm"
+	"github.com/jinzhu/inflection"
+)
+
+const templateData = `// Code generated by bulk-gen; DO NOT EDIT.
+// See github.com/bombsimon/gorm-bulk
+package {{.Package}}
+
+import (
+	gormbulk "github.com/bombsimon/gorm-bulk"
+	"github.com/jinzhu/gorm"
+)
+{{ range $t := .Types }}
+// Columns for {{$t.Name}}.
+const (
+{{- range $c := $t.Columns }}
+	{{$t.Name}}Column{{$c.Field}} = "{{$c.Name}}"
+{{- end }}
+)
+
+// {{$t.Name}}PtrSliceToInterfaceSlice returns an interface slice with {{$t.Plural}}.
+func {{$t.Name}}PtrSliceToInterfaceSlice(ts []*{{$t.Name}}) []interface{} {
+	var is = make([]interface{}, len(ts))
+
+	for i := range ts {
+		is[i] = ts[i]
+	}
+
+	return is
+}
+
+// {{$t.Name}}SliceToInterfaceSlice returns an interface slice with {{$t.Plural}}.
+func {{$t.Name}}SliceToInterfaceSlice(ts []{{$t.Name}}) []interface{} {
+	var is = make([]interface{}, len(ts))
+
+	for i := range ts {
+		is[i] = ts[i]
+	}
+
+	return is
+}
+
+// BulkInsert{{$t.Plural}} will call gormbulk.BulkInsert with the {{$t.Plural}}.
+func BulkInsert{{$t.Plural}}(db *gorm.DB, ts []{{$t.Name}}, opts ...gormbulk.Option) error {
+	return gormbulk.BulkInsert(db, {{$t.Name}}SliceToInterfaceSlice(ts), opts...)
+}
+
+// BulkInsertIgnore{{$t.Plural}} will call gormbulk.BulkInsertIgnore with the {{$t.Plural}}.
+func BulkInsertIgnore{{$t.Plural}}(db *gorm.DB, ts []{{$t.Name}}, opts ...gormbulk.Option) error {
+	return gormbulk.BulkInsertIgnore(db, {{$t.Name}}SliceToInterfaceSlice(ts), opts...)
+}
+
+// BulkInsertOnDuplicateKeyUpdate{{$t.Plural}} will call
+// gormbulk.BulkInsertOnDuplicateKeyUpdate with the {{$t.Plural}}.
+func BulkInsertOnDuplicateKeyUpdate{{$t.Plural}}(db *gorm.DB, ts []{{$t.Name}}, opts ...gormbulk.Option) error {
+	return gormbulk.BulkInsertOnDuplicateKeyUpdate(db, {{$t.Name}}SliceToInterfaceSlice(ts), opts...)
+}
+{{ end -}}
+`
+
+// gormModelColumns holds the fields added by embedding gorm.Model.
+var gormModelColumns = []Column{
+	{Field: "ID", Name: "id"},
+	{Field: "CreatedAt", Name: "created_at"},
+	{Field: "UpdatedAt", Name: "updated_at"},
+	{Field: "DeletedAt", Name: "deleted_at"},
+}
+
+func main() {
+	var (
+		help    bool
+		keyword string
+	)
+
+	flag.BoolVar(&help, "h", false, "Show this help text")
+	flag.BoolVar(&help, "help", false, "")
+	flag.StringVar(&keyword, "keyword", "GORM-BULK", "Keyword to match to generate typed wrappers")
+	flag.Parse()
+
+	if help {
+		flag.PrintDefaults()
+		return
+	}
+
+	p := New(os.Getenv("GOFILE"), keyword)
+	if err := p.GetTypesFromFile(); err != nil {
+		panic(err)
+	}
+
+	if err := p.CreateFile(); err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("Generated typed bulk wrappers for %d types\n", len(p.Types))
+}
+
+// Type is a struct type to generate wrappers for.
+type Type struct {
+	Name    string
+	Plural  string
+	Columns []Column
+}
+
+// Column is a column of a type with the name of the struct field and the
+// column name.
+type Column struct {
+	Field string
+	Name  string
+}
+
+// Parser is the parser that will search a file for struct types annotated with
+// the keyword.
+type Parser struct {
+	Types   []Type
+	File    string
+	Keyword string
+	Package string
+}
+
+// New will create a new parser to use for a given file.
+func New(file, kw string) *Parser {
+	return &Parser{
+		Keyword: kw,
+		File:    file,
+	}
+}
+
+// GetTypesFromFile will read a file and pass the content to GetTypes()
+func (p *Parser) GetTypesFromFile() error {
+	fileData, err := ioutil.ReadFile(p.File)
+	if err != nil {
+		return err
+	}
+
+	return p.GetTypes(fileData)
+}
+
+// GetTypes will find all struct types annotated with the keyword.
+func (p *Parser) GetTypes(fileData []byte) error {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "", fileData, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	p.findStructTypes(file)
+
+	if len(p.Types) < 1 {
+		return errors.New("no types found")
+	}
+
+	p.Package = file.Name.Name
+
+	return nil
+}
+
+// CreateFile will create a file on disk with the wrappers for all types
+// found, named after the parsed file, i.e. models_bulk.gen.go.
+func (p *Parser) CreateFile() error {
+	fileBytes, err := p.Generate()
+	if err != nil {
+		return err
+	}
+
+	base := strings.TrimSuffix(filepath.Base(p.File), ".go")
+	outputFile := filepath.Join(
+		filepath.Dir(p.File),
+		fmt.Sprintf("%s_bulk.gen.go", base),
+	)
+
+	return ioutil.WriteFile(outputFile, fileBytes, 0644)
+}
+
+// Generate returns the formatted source with the wrappers for all types found.
+func (p *Parser) Generate() ([]byte, error) {
+	tmpl := template.Must(template.New("").Parse(templateData))
+	buf := bytes.Buffer{}
+
+	if err := tmpl.Execute(&buf, p); err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+func (p *Parser) findStructTypes(f *ast.File) {
+	for _, decl := range f.Decls {
+		// Ensure the declaration is a GenDecl.
+		gdecl, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+
+		if gdecl.Tok != token.TYPE || gdecl.Doc == nil {
+			continue
+		}
+
+		var hasGoGenerate bool
+
+		for _, doc := range gdecl.Doc.List {
+			if strings.Contains(doc.Text, p.Keyword) {
+				hasGoGenerate = true
+				break
+			}
+		}
+
+		if !hasGoGenerate {
+			continue
+		}
+
+		for _, spec := range gdecl.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+
+			p.Types = append(p.Types, Type{
+				Name:    ts.Name.Name,
+				Plural:  inflection.Plural(ts.Name.Name),
+				Columns: structColumns(st),
+			})
+		}
+	}
+}
+
+// structColumns returns the columns for all exported fields in the struct the
+// same way gorm names them. Fields tagged with `gorm:"-"` are left out.
+func structColumns(st *ast.StructType) []Column {
+	var columns []Column
+
+	for _, field := range st.Fields.List {
+		var tag string
+
+		if field.Tag != nil {
+			if value, err := strconv.Unquote(field.Tag.Value); err == nil {
+				tag = reflect.StructTag(value).Get("gorm")
+			}
+		}
+
+		if tag == "-" {
+			continue
+		}
+
+		// Embedded gorm.Model adds it's fields.
+		if len(field.Names) == 0 {
+			if sel, ok := field.Type.(*ast.SelectorExpr); ok && sel.Sel.Name == "Model" {
+				columns = append(columns, gormModelColumns...)
+			}
+
+			continue
+		}
+
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				continue
+			}
+
+			columnName := gorm.ToColumnName(name.Name)
+			if column, ok := tagColumn(tag); ok {
+				columnName = column
+			}
+
+			columns = append(columns, Column{Field: name.Name, Name: columnName})
+		}
+	}
+
+	return columns
+}
+
+// tagColumn returns the column set with the COLUMN setting in the gorm tag.
+func tagColumn(tag string) (string, bool) {
+	for _, setting := range strings.Split(tag, ";") {
+		parts := strings.SplitN(setting, ":", 2)
+		if len(parts) == 2 && strings.EqualFold(strings.TrimSpace(parts[0]), "column") {
+			return strings.TrimSpace(parts[1]), true
+		}
+	}
+
+	return "", false
+}
+
+// vim: set ft=gohtmltmpl:
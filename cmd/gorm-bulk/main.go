@@ -0,0 +1,155 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	gormbulk "github.com/bombsimon/gorm-bulk"
+	"github.com/jinzhu/gorm"
+)
+
+const usage = `Usage:
+  gorm-bulk [flags] <package dir> <struct name>
+  gorm-bulk [flags] -json <sample file> -table <table name>
+
+Print the SQL gorm-bulk would generate for the struct (or JSON sample) with the
+given number of rows for the dialect.
+
+Flags:
+`
+
+var actions = map[string]gormbulk.ExecFunc{
+	"insert":        gormbulk.InsertFunc,
+	"insert-ignore": gormbulk.InsertIgnoreFunc,
+	"upsert":        gormbulk.InsertOnDuplicateKeyUpdateFunc,
+}
+
+func main() {
+	var (
+		help       bool
+		action     string
+		dialect    string
+		jsonSample string
+		rows       int
+		table      string
+	)
+
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+
+	flag.BoolVar(&help, "h", false, "Show this help text")
+	flag.BoolVar(&help, "help", false, "")
+	flag.StringVar(&action, "action", "insert", "Bulk action, one of insert, insert-ignore or upsert")
+	flag.StringVar(&dialect, "dialect", "mysql", "SQL dialect, i.e. mysql or postgres")
+	flag.StringVar(&jsonSample, "json", "", "JSON file with a sample object to use instead of a struct")
+	flag.IntVar(&rows, "rows", 2, "Number of rows")
+	flag.StringVar(&table, "table", "", "Table name, defaults to the gorm table name for the struct")
+	flag.Parse()
+
+	if help {
+		flag.Usage()
+		return
+	}
+
+	if err := run(os.Stdout, action, dialect, jsonSample, rows, table, flag.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "gorm-bulk: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(w io.Writer, action, dialect, jsonSample string, rows int, table string, args []string) error {
+	execFunc, ok := actions[action]
+	if !ok {
+		return fmt.Errorf("unknown action %s", action)
+	}
+
+	if rows < 1 {
+		return errors.New("rows must be at least 1")
+	}
+
+	var (
+		modelType reflect.Type
+		err       error
+	)
+
+	switch {
+	case jsonSample != "":
+		if table == "" {
+			return errors.New("table must be set when using a JSON sample")
+		}
+
+		modelType, err = typeFromJSONFile(jsonSample)
+	case len(args) == 2:
+		if table == "" {
+			table = defaultTableName(args[1])
+		}
+
+		modelType, err = typeFromPackage(args[0], args[1])
+	default:
+		flag.Usage()
+		return errors.New("package dir and struct name or JSON sample is required")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	recorder := &recorder{}
+
+	db, err := gorm.Open(dialect, recorder)
+	if err != nil {
+		return err
+	}
+
+	objects := make([]interface{}, rows)
+	for i := range objects {
+		objects[i] = reflect.New(modelType).Interface()
+	}
+
+	if err := gormbulk.BulkExec(db.Table(table), objects, execFunc); err != nil {
+		return err
+	}
+
+	for _, query := range recorder.queries {
+		fmt.Fprintf(w, "%s;\n", query)
+	}
+
+	return nil
+}
+
+// recorder implements gorm.SQLCommon and records all executed queries instead
+// of executing them.
+type recorder struct {
+	queries []string
+}
+
+func (r *recorder) Exec(query string, _ ...interface{}) (sql.Result, error) {
+	r.queries = append(r.queries, strings.TrimSpace(query))
+
+	return result{}, nil
+}
+
+func (r *recorder) Prepare(string) (*sql.Stmt, error) {
+	return nil, errors.New("prepare is not supported")
+}
+
+func (r *recorder) Query(string, ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("query is not supported")
+}
+
+func (r *recorder) QueryRow(string, ...interface{}) *sql.Row {
+	return nil
+}
+
+type result struct{}
+
+func (result) LastInsertId() (int64, error) { return 0, nil }
+func (result) RowsAffected() (int64, error) { return 0, nil }
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/jinzhu/inflection"
+	"github.com/lib/pq"
+)
+
+// basicTypes maps the name of all predeclared types to their type.
+var basicTypes = map[string]reflect.Type{
+	"bool":    reflect.TypeOf(false),
+	"string":  reflect.TypeOf(""),
+	"int":     reflect.TypeOf(int(0)),
+	"int8":    reflect.TypeOf(int8(0)),
+	"int16":   reflect.TypeOf(int16(0)),
+	"int32":   reflect.TypeOf(int32(0)),
+	"int64":   reflect.TypeOf(int64(0)),
+	"uint":    reflect.TypeOf(uint(0)),
+	"uint8":   reflect.TypeOf(uint8(0)),
+	"uint16":  reflect.TypeOf(uint16(0)),
+	"uint32":  reflect.TypeOf(uint32(0)),
+	"uint64":  reflect.TypeOf(uint64(0)),
+	"float32": reflect.TypeOf(float32(0)),
+	"float64": reflect.TypeOf(float64(0)),
+	"byte":    reflect.TypeOf(byte(0)),
+	"rune":    reflect.TypeOf(rune(0)),
+}
+
+// packageTypes maps qualified type names from other packages commonly used in
+// models to their type.
+var packageTypes = map[string]reflect.Type{
+	"time.Time":       reflect.TypeOf(time.Time{}),
+	"time.Duration":   reflect.TypeOf(time.Duration(0)),
+	"sql.NullBool":    reflect.TypeOf(sql.NullBool{}),
+	"sql.NullFloat64": reflect.TypeOf(sql.NullFloat64{}),
+	"sql.NullInt64":   reflect.TypeOf(sql.NullInt64{}),
+	"sql.NullString":  reflect.TypeOf(sql.NullString{}),
+	"json.RawMessage": reflect.TypeOf(json.RawMessage{}),
+	"gorm.Model":      reflect.TypeOf(gorm.Model{}),
+	"pq.NullTime":     reflect.TypeOf(pq.NullTime{}),
+	"pq.BoolArray":    reflect.TypeOf(pq.BoolArray{}),
+	"pq.Float64Array": reflect.TypeOf(pq.Float64Array{}),
+	"pq.Int64Array":   reflect.TypeOf(pq.Int64Array{}),
+	"pq.StringArray":  reflect.TypeOf(pq.StringArray{}),
+}
+
+// defaultTableName returns the table name gorm uses for a struct name.
+func defaultTableName(structName string) string {
+	return inflection.Plural(gorm.ToTableName(structName))
+}
+
+// typeFromPackage parses all Go files in the package directory and returns a
+// struct type with the same fields and tags as the named struct. Types which
+// can't be resolved are replaced with strings.
+func typeFromPackage(dir, structName string) (reflect.Type, error) {
+	fset := token.NewFileSet()
+
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	specs := map[string]*ast.TypeSpec{}
+
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				gdecl, ok := decl.(*ast.GenDecl)
+				if !ok || gdecl.Tok != token.TYPE {
+					continue
+				}
+
+				for _, spec := range gdecl.Specs {
+					if ts, ok := spec.(*ast.TypeSpec); ok {
+						specs[ts.Name.Name] = ts
+					}
+				}
+			}
+		}
+	}
+
+	spec, ok := specs[structName]
+	if !ok {
+		return nil, fmt.Errorf("type %s not found in %s", structName, dir)
+	}
+
+	if _, ok := spec.Type.(*ast.StructType); !ok {
+		return nil, fmt.Errorf("type %s is not a struct", structName)
+	}
+
+	r := &resolver{specs: specs, resolving: map[string]bool{}}
+
+	return r.resolve(spec.Type), nil
+}
+
+// resolver resolves types parsed from a package to reflect types.
+type resolver struct {
+	specs     map[string]*ast.TypeSpec
+	resolving map[string]bool
+}
+
+func (r *resolver) resolve(expr ast.Expr) reflect.Type {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if basic, ok := basicTypes[t.Name]; ok {
+			return basic
+		}
+
+		spec, ok := r.specs[t.Name]
+		if !ok || r.resolving[t.Name] {
+			return basicTypes["string"]
+		}
+
+		r.resolving[t.Name] = true
+		defer delete(r.resolving, t.Name)
+
+		return r.resolve(spec.Type)
+	case *ast.SelectorExpr:
+		if pkg, ok := t.X.(*ast.Ident); ok {
+			if known, ok := packageTypes[pkg.Name+"."+t.Sel.Name]; ok {
+				return known
+			}
+		}
+	case *ast.StarExpr:
+		return reflect.PtrTo(r.resolve(t.X))
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return reflect.SliceOf(r.resolve(t.Elt))
+		}
+
+		if lit, ok := t.Len.(*ast.BasicLit); ok {
+			if n, err := strconv.Atoi(lit.Value); err == nil {
+				return reflect.ArrayOf(n, r.resolve(t.Elt))
+			}
+		}
+	case *ast.MapType:
+		return reflect.MapOf(r.resolve(t.Key), r.resolve(t.Value))
+	case *ast.StructType:
+		return r.resolveStruct(t)
+	}
+
+	return basicTypes["string"]
+}
+
+func (r *resolver) resolveStruct(st *ast.StructType) reflect.Type {
+	var fields []reflect.StructField
+
+	for _, field := range st.Fields.List {
+		var tag reflect.StructTag
+
+		if field.Tag != nil {
+			if value, err := strconv.Unquote(field.Tag.Value); err == nil {
+				tag = reflect.StructTag(value)
+			}
+		}
+
+		fieldType := r.resolve(field.Type)
+
+		// Embedded fields are named after their type.
+		if len(field.Names) == 0 {
+			name := embeddedName(field.Type)
+			if !ast.IsExported(name) || fieldType.Kind() != reflect.Struct {
+				continue
+			}
+
+			fields = append(fields, reflect.StructField{
+				Name:      name,
+				Type:      fieldType,
+				Tag:       tag,
+				Anonymous: true,
+			})
+
+			continue
+		}
+
+		for _, name := range field.Names {
+			// Unexported fields are ignored by gorm.
+			if !name.IsExported() {
+				continue
+			}
+
+			fields = append(fields, reflect.StructField{
+				Name: name.Name,
+				Type: fieldType,
+				Tag:  tag,
+			})
+		}
+	}
+
+	return reflect.StructOf(fields)
+}
+
+// embeddedName returns the field name of an embedded type.
+func embeddedName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	}
+
+	return ""
+}
+
+// typeFromJSONFile returns a struct type with one field for each key in the
+// JSON object (or first object in a JSON array) in the file. Numbers without
+// decimals are integers, objects and arrays are JSON columns and null values
+// are nullable strings.
+func typeFromJSONFile(path string) (reflect.Type, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var sample interface{}
+	if err := json.Unmarshal(data, &sample); err != nil {
+		return nil, err
+	}
+
+	if list, ok := sample.([]interface{}); ok && len(list) > 0 {
+		sample = list[0]
+	}
+
+	object, ok := sample.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("JSON sample must be an object or an array of objects")
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	fields := make([]reflect.StructField, len(keys))
+
+	for i, key := range keys {
+		var (
+			fieldType reflect.Type
+			tag       = fmt.Sprintf("column:%s", key)
+		)
+
+		switch v := object[key].(type) {
+		case bool:
+			fieldType = basicTypes["bool"]
+		case string:
+			fieldType = basicTypes["string"]
+		case float64:
+			fieldType = basicTypes["float64"]
+			if v == math.Trunc(v) {
+				fieldType = basicTypes["int64"]
+			}
+		case nil:
+			fieldType = reflect.PtrTo(basicTypes["string"])
+		default:
+			fieldType = basicTypes["string"]
+			tag += ";type:json"
+		}
+
+		fields[i] = reflect.StructField{
+			Name: fmt.Sprintf("Field%d", i),
+			Type: fieldType,
+			Tag:  reflect.StructTag(fmt.Sprintf(`gorm:"%s"`, tag)),
+		}
+	}
+
+	return reflect.StructOf(fields), nil
+}
@@ -0,0 +1,292 @@
+package gormbulk
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// DefaultCoercionTimeLayout is the layout used to format time values bound for
+// text columns when using WithTypeCoercion.
+const DefaultCoercionTimeLayout = time.RFC3339Nano
+
+// coercionTimeLayouts are the layouts tried, in order, when parsing strings
+// bound for date and time columns.
+var coercionTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// WithTypeCoercion will convert values to the column type set with the TYPE
+// tag, i.e. `gorm:"type:int"`, before they're bound. This makes it possible to
+// load loosely typed data such as strings read from CSV into typed columns.
+// Strings are parsed for integer, float, decimal, bool, date and time columns,
+// bools are bound as 0 or 1 for integer columns and numbers, bools and times
+// are formatted for text columns. A *CoercionError holding the row and column
+// is returned for the first value that can't be converted.
+func WithTypeCoercion() Option {
+	return func(o *options) {
+		o.typeCoercion = true
+	}
+}
+
+// coerceFunc converts a value to a column type. The value is never nil.
+type coerceFunc func(o *options, value interface{}) (interface{}, error)
+
+// coerceFuncs maps the base column type (lower case without size or
+// modifiers) to the function converting values to the type.
+var coerceFuncs = map[string]coerceFunc{
+	"int":         coerceInt,
+	"integer":     coerceInt,
+	"int2":        coerceInt,
+	"int4":        coerceInt,
+	"int8":        coerceInt,
+	"tinyint":     coerceInt,
+	"smallint":    coerceInt,
+	"mediumint":   coerceInt,
+	"bigint":      coerceInt,
+	"serial":      coerceInt,
+	"smallserial": coerceInt,
+	"bigserial":   coerceInt,
+	"float":       coerceFloat,
+	"float4":      coerceFloat,
+	"float8":      coerceFloat,
+	"double":      coerceFloat,
+	"real":        coerceFloat,
+	"decimal":     coerceDecimal,
+	"numeric":     coerceDecimal,
+	"bool":        coerceBool,
+	"boolean":     coerceBool,
+	"char":        coerceText,
+	"nchar":       coerceText,
+	"varchar":     coerceText,
+	"nvarchar":    coerceText,
+	"character":   coerceText,
+	"text":        coerceText,
+	"tinytext":    coerceText,
+	"mediumtext":  coerceText,
+	"longtext":    coerceText,
+	"date":        coerceDate,
+	"datetime":    coerceTime,
+	"timestamp":   coerceTime,
+	"timestamptz": coerceTime,
+}
+
+// baseColumnType returns the column type in lower case without size, array
+// suffix or modifiers, i.e. `int` for `INT(11) UNSIGNED`.
+func baseColumnType(columnType string) string {
+	columnType = strings.ToLower(strings.TrimSpace(columnType))
+
+	if i := strings.IndexAny(columnType, " (["); i >= 0 {
+		columnType = columnType[:i]
+	}
+
+	return columnType
+}
+
+// coerce converts the value to the type of the column if using
+// WithTypeCoercion. Values implementing driver.Valuer, expressions such as
+// gorm.Expr and types not handled for the column are left to the driver.
+func (o *options) coerce(row int, field *gorm.Field, value interface{}) (interface{}, error) {
+	if !o.typeCoercion || value == nil {
+		return value, nil
+	}
+
+	columnType, ok := field.TagSettingsGet("TYPE")
+	if !ok || strings.HasSuffix(strings.TrimSpace(columnType), "[]") {
+		return value, nil
+	}
+
+	fn, ok := coerceFuncs[baseColumnType(columnType)]
+	if !ok {
+		return value, nil
+	}
+
+	if _, ok := value.(driver.Valuer); ok {
+		return value, nil
+	}
+
+	coerced, err := fn(o, value)
+	if err != nil {
+		return nil, &CoercionError{
+			Row:    row,
+			Column: o.columnName(field.StructField),
+			Type:   columnType,
+			Value:  value,
+			Err:    err,
+		}
+	}
+
+	return coerced, nil
+}
+
+func coerceInt(_ *options, value interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(value)
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return value, nil
+	case reflect.Bool:
+		if rv.Bool() {
+			return int64(1), nil
+		}
+
+		return int64(0), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f != math.Trunc(f) || f > math.MaxInt64 || f < math.MinInt64 {
+			return nil, fmt.Errorf("%v is not an integer", f)
+		}
+
+		return int64(f), nil
+	case reflect.String:
+		return strconv.ParseInt(strings.TrimSpace(rv.String()), 10, 64)
+	}
+
+	return value, nil
+}
+
+func coerceFloat(_ *options, value interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(value)
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		if rv.Bool() {
+			return float64(1), nil
+		}
+
+		return float64(0), nil
+	case reflect.String:
+		return strconv.ParseFloat(strings.TrimSpace(rv.String()), 64)
+	}
+
+	return value, nil
+}
+
+// coerceDecimal validates strings but keeps them as is to not lose precision.
+func coerceDecimal(o *options, value interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.String {
+		return coerceFloat(o, value)
+	}
+
+	str := strings.TrimSpace(rv.String())
+	if _, err := strconv.ParseFloat(str, 64); err != nil {
+		return nil, err
+	}
+
+	return str, nil
+}
+
+func coerceBool(_ *options, value interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(value)
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() != 0, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint() != 0, nil
+	case reflect.String:
+		return strconv.ParseBool(strings.TrimSpace(rv.String()))
+	}
+
+	return value, nil
+}
+
+func coerceText(_ *options, value interface{}) (interface{}, error) {
+	if t, ok := value.(time.Time); ok {
+		return t.Format(DefaultCoercionTimeLayout), nil
+	}
+
+	rv := reflect.ValueOf(value)
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, rv.Type().Bits()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case reflect.String:
+		return rv.String(), nil
+	}
+
+	return value, nil
+}
+
+// coerceDate parses strings and formats them with the date layout. Time values
+// are already formatted by convertValue.
+func coerceDate(o *options, value interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.String {
+		return value, nil
+	}
+
+	t, err := o.parseTime(rv.String(), o.dateLayout())
+	if err != nil {
+		return nil, err
+	}
+
+	return t.Format(o.dateLayout()), nil
+}
+
+// coerceTime parses strings and converts integers as seconds since the Unix
+// epoch.
+func coerceTime(o *options, value interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(value)
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return o.inLocation(time.Unix(rv.Int(), 0).UTC()), nil
+	case reflect.String:
+		t, err := o.parseTime(rv.String())
+		if err != nil {
+			return nil, err
+		}
+
+		return o.inLocation(t), nil
+	}
+
+	return value, nil
+}
+
+// parseTime parses the string with the passed layouts or any of the layouts
+// in coercionTimeLayouts. Times without offset are parsed in the location set
+// with WithLocation, or UTC.
+func (o *options) parseTime(str string, layouts ...string) (time.Time, error) {
+	str = strings.TrimSpace(str)
+	loc := o.location
+
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	for _, layout := range append(layouts, coercionTimeLayouts...) {
+		if t, err := time.ParseInLocation(layout, str, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("could not parse %q as time", str)
+}
+
+// inLocation converts the time to the location set with WithLocation, if any.
+func (o *options) inLocation(t time.Time) time.Time {
+	if o.location == nil {
+		return t
+	}
+
+	return t.In(o.location)
+}
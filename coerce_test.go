@@ -0,0 +1,135 @@
+package gormbulk
+
+import (
+	"database/sql/driver"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTypeCoercion(t *testing.T) {
+	type record struct {
+		Count   string  `gorm:"type:int(11) unsigned"`
+		Active  bool    `gorm:"type:tinyint(1)"`
+		Enabled string  `gorm:"type:boolean"`
+		Price   string  `gorm:"type:decimal(10,2)"`
+		Score   string  `gorm:"type:double"`
+		Code    int     `gorm:"type:varchar(10)"`
+		Ratio   float64 `gorm:"type:text"`
+		Day     string  `gorm:"type:date"`
+		Seen    string  `gorm:"type:datetime"`
+		Note    string
+	}
+
+	cases := []struct {
+		description   string
+		objects       []interface{}
+		opts          []Option
+		expectedArgs  []driver.Value
+		expectedError error
+	}{
+		{
+			description: "values are converted to the column type",
+			objects: []interface{}{
+				record{
+					Count:   " 42 ",
+					Active:  true,
+					Enabled: "false",
+					Price:   "9.90",
+					Score:   "1.5",
+					Code:    7,
+					Ratio:   0.25,
+					Day:     "2019-11-01 10:00:00",
+					Seen:    "2019-11-01T10:00:00+02:00",
+					Note:    "12",
+				},
+			},
+			opts: []Option{WithTypeCoercion(), WithUTC()},
+			expectedArgs: []driver.Value{
+				int64(1), "7", int64(42), "2019-11-01", false, "12",
+				"9.90", "0.25", float64(1.5),
+				time.Date(2019, 11, 1, 8, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			description: "no conversion without the option",
+			objects: []interface{}{
+				record{Count: "42", Active: true, Code: 7},
+			},
+			expectedArgs: []driver.Value{
+				true, 7, "42", "", "", "", "", float64(0), "", "",
+			},
+		},
+		{
+			description: "error for value that can't be converted",
+			objects: []interface{}{
+				record{Count: "1", Enabled: "true", Price: "1", Score: "1", Day: "2019-11-01", Seen: "2019-11-01"},
+				record{Count: "many", Enabled: "true", Price: "1", Score: "1", Day: "2019-11-01", Seen: "2019-11-01"},
+			},
+			opts: []Option{WithTypeCoercion()},
+			expectedError: &CoercionError{
+				Row:    1,
+				Column: "count",
+				Type:   "int(11) unsigned",
+				Value:  "many",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			if tc.expectedError == nil {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `records`")).
+					WithArgs(tc.expectedArgs...).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			err = BulkInsert(gdb, tc.objects, tc.opts...)
+
+			if tc.expectedError != nil {
+				coercionErr, ok := err.(*CoercionError)
+				require.True(t, ok, "expected *CoercionError, got %v", err)
+
+				assert.Equal(t, tc.expectedError.(*CoercionError).Row, coercionErr.Row)
+				assert.Equal(t, tc.expectedError.(*CoercionError).Column, coercionErr.Column)
+				assert.Equal(t, tc.expectedError.(*CoercionError).Type, coercionErr.Type)
+				assert.Equal(t, tc.expectedError.(*CoercionError).Value, coercionErr.Value)
+				assert.Error(t, coercionErr.Err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_baseColumnType(t *testing.T) {
+	cases := map[string]string{
+		"INT(11) UNSIGNED":         "int",
+		"varchar(255)":             "varchar",
+		" text ":                   "text",
+		"timestamp with time zone": "timestamp",
+		"character varying(10)":    "character",
+		"integer[]":                "integer",
+		"decimal(10,2)":            "decimal",
+		"double precision":         "double",
+		"datetime(6)":              "datetime",
+	}
+
+	for columnType, expected := range cases {
+		assert.Equal(t, expected, baseColumnType(columnType), columnType)
+	}
+}
@@ -0,0 +1,59 @@
+package gormbulk
+
+import (
+	"sort"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ColumnOrder decides the order of the columns in the statement.
+type ColumnOrder int
+
+// Available column orders.
+const (
+	// Alphabetical orders the columns alphabetically by name. This is the
+	// default order.
+	Alphabetical ColumnOrder = iota
+
+	// StructOrder orders the columns in the order the fields are declared in
+	// the struct, with fields from embedded structs where they're embedded.
+	// Columns not in the struct, i.e. added with WithColumnValue, are added
+	// last in alphabetical order.
+	StructOrder
+)
+
+// WithColumnOrder sets the order of the columns in the statement.
+func WithColumnOrder(order ColumnOrder) Option {
+	return func(o *options) {
+		o.columnOrder = order
+	}
+}
+
+// sortColumns sorts the column names according to the ColumnOrder. The fields
+// are the fields of the first object, keyed by column name.
+func (o *options) sortColumns(scope *gorm.Scope, columnNames []string, fields map[string]*gorm.Field) {
+	sort.Strings(columnNames)
+
+	if o.columnOrder != StructOrder {
+		return
+	}
+
+	positions := map[*gorm.StructField]int{}
+	for i, field := range scope.GetStructFields() {
+		positions[field] = i
+	}
+
+	position := func(column string) int {
+		if field, ok := fields[column]; ok {
+			if i, ok := positions[field.StructField]; ok {
+				return i
+			}
+		}
+
+		return len(positions)
+	}
+
+	sort.SliceStable(columnNames, func(i, j int) bool {
+		return position(columnNames[i]) < position(columnNames[j])
+	})
+}
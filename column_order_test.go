@@ -0,0 +1,74 @@
+package gormbulk
+
+import (
+	"database/sql/driver"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithColumnOrder(t *testing.T) {
+	type Base struct {
+		Tenant string
+	}
+
+	type account struct {
+		Zone string
+		Base
+		Name  string
+		Alias string
+	}
+
+	cases := []struct {
+		description  string
+		opts         []Option
+		expectedSQL  string
+		expectedArgs []driver.Value
+	}{
+		{
+			description:  "alphabetical by default",
+			expectedSQL:  "INSERT INTO `accounts` (`alias`, `name`, `tenant`, `zone`) VALUES (?, ?, ?, ?)",
+			expectedArgs: []driver.Value{"a", "n", "t", "z"},
+		},
+		{
+			description:  "struct order",
+			opts:         []Option{WithColumnOrder(StructOrder)},
+			expectedSQL:  "INSERT INTO `accounts` (`zone`, `tenant`, `name`, `alias`) VALUES (?, ?, ?, ?)",
+			expectedArgs: []driver.Value{"z", "t", "n", "a"},
+		},
+		{
+			description: "struct order with injected columns last",
+			opts: []Option{
+				WithColumnOrder(StructOrder),
+				WithColumnValue("source", func(int, interface{}) interface{} { return "s" }),
+				WithColumnValue("batch", func(int, interface{}) interface{} { return "b" }),
+			},
+			expectedSQL:  "INSERT INTO `accounts` (`zone`, `tenant`, `name`, `alias`, `batch`, `source`) VALUES (?, ?, ?, ?, ?, ?)",
+			expectedArgs: []driver.Value{"z", "t", "n", "a", "b", "s"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			mock.ExpectExec(regexp.QuoteMeta(tc.expectedSQL)).
+				WithArgs(tc.expectedArgs...).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			objects := []interface{}{
+				account{Zone: "z", Base: Base{Tenant: "t"}, Name: "n", Alias: "a"},
+			}
+
+			require.NoError(t, BulkInsert(gdb, objects, tc.opts...))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package gormbulk
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Compressor compresses values for compressed columns, see
+// WithCompressedColumn. The codec, i.e. gzip or zstd, is written to the codec
+// column if one is set for the compressed column.
+type Compressor interface {
+	Codec() string
+	Compress(data []byte) ([]byte, error)
+}
+
+// GzipCompressor compresses values with gzip using the compression level,
+// i.e. gzip.BestCompression. The zero value uses gzip.NoCompression, use
+// NewGzipCompressor for the default level.
+type GzipCompressor struct {
+	Level int
+}
+
+// NewGzipCompressor returns a GzipCompressor with gzip.DefaultCompression.
+func NewGzipCompressor() *GzipCompressor {
+	return &GzipCompressor{Level: gzip.DefaultCompression}
+}
+
+// Codec implements Compressor.
+func (c *GzipCompressor) Codec() string {
+	return "gzip"
+}
+
+// Compress implements Compressor.
+func (c *GzipCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w, err := gzip.NewWriterLevel(&buf, c.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// WithCompressor sets the Compressor used for compressed columns. The default
+// compressor is gzip with the default level, use this option to change the
+// level or to use another codec such as zstd.
+func WithCompressor(compressor Compressor) Option {
+	return func(o *options) {
+		o.compressor = compressor
+	}
+}
+
+// WithCompressedColumn will compress the value bound for the column, i.e. to
+// store large payloads in archival tables. If codecColumn isn't empty the
+// codec is written to that column for all rows where the value isn't NULL.
+// Fields may also be compressed with the tag `bulk:"compress"` and the codec
+// column set with `bulk:"compress;codec:payload_codec"`. The value is
+// compressed after all other conversions but before it's encrypted.
+func WithCompressedColumn(column, codecColumn string) Option {
+	return func(o *options) {
+		if o.compressedColumns == nil {
+			o.compressedColumns = map[string]string{}
+		}
+
+		o.compressedColumns[column] = codecColumn
+	}
+}
+
+// compressColumn returns true if the column should be compressed and the
+// codec column for it, which may be empty.
+func (o *options) compressColumn(column string, field *gorm.StructField) (string, bool) {
+	if codecColumn, ok := o.compressedColumns[column]; ok {
+		return codecColumn, true
+	}
+
+	settings := bulkTagSettings(field)
+	if _, ok := settings["COMPRESS"]; !ok {
+		return "", false
+	}
+
+	return settings["CODEC"], true
+}
+
+// codecColumns returns the codec column for each compressed column in the
+// fields.
+func (o *options) codecColumns(fields map[string]*gorm.Field) map[string]string {
+	codecColumns := map[string]string{}
+
+	for column, field := range fields {
+		if codecColumn, ok := o.compressColumn(column, field.StructField); ok && codecColumn != "" {
+			codecColumns[codecColumn] = column
+		}
+	}
+
+	return codecColumns
+}
+
+// codec returns the codec to write to the codec column for the compressed
+// field, or nil if the field is NULL.
+func (o *options) codec(field *gorm.Field) interface{} {
+	if field == nil || isNull(fieldValue(field)) {
+		return nil
+	}
+
+	return o.getCompressor().Codec()
+}
+
+// getCompressor returns the compressor set with WithCompressor or gzip if not
+// set.
+func (o *options) getCompressor() Compressor {
+	if o.compressor == nil {
+		return NewGzipCompressor()
+	}
+
+	return o.compressor
+}
+
+// compress compresses the value if the column should be compressed.
+func (o *options) compress(column string, field *gorm.Field, value interface{}) (interface{}, error) {
+	if _, ok := o.compressColumn(column, field.StructField); !ok || isNull(value) {
+		return value, nil
+	}
+
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return nil, err
+		}
+
+		value = v
+	}
+
+	var data []byte
+
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		data = []byte(fmt.Sprint(v))
+	}
+
+	compressed, err := o.getCompressor().Compress(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not compress column %s: %v", column, err)
+	}
+
+	return compressed, nil
+}
@@ -0,0 +1,135 @@
+package gormbulk
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql/driver"
+	"errors"
+	"io/ioutil"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reverseCompressor struct{}
+
+func (reverseCompressor) Codec() string {
+	return "reverse"
+}
+
+func (reverseCompressor) Compress(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, errors.New("nothing to compress")
+	}
+
+	reversed := make([]byte, len(data))
+	for i, b := range data {
+		reversed[len(data)-1-i] = b
+	}
+
+	return reversed, nil
+}
+
+func TestCompression(t *testing.T) {
+	type event struct {
+		Name    string
+		Payload *string `bulk:"compress;codec:payload_codec"`
+		Raw     []byte
+	}
+
+	payload := "abc"
+	empty := ""
+
+	cases := []struct {
+		description   string
+		objects       []interface{}
+		opts          []Option
+		expectedSQL   string
+		expectedArgs  []driver.Value
+		expectedError string
+	}{
+		{
+			description: "tagged field is compressed with codec column",
+			objects: []interface{}{
+				event{Name: "a", Payload: &payload},
+				event{Name: "b"},
+			},
+			opts:        []Option{WithCompressor(reverseCompressor{})},
+			expectedSQL: "INSERT INTO `events` (`name`, `payload`, `payload_codec`, `raw`) VALUES (?, ?, ?, ?), (?, ?, ?, ?)",
+			expectedArgs: []driver.Value{
+				"a", []byte("cba"), "reverse", []byte(nil),
+				"b", nil, nil, []byte(nil),
+			},
+		},
+		{
+			description: "column compressed with option",
+			objects: []interface{}{
+				event{Name: "a", Raw: []byte("xyz")},
+			},
+			opts: []Option{
+				WithCompressor(reverseCompressor{}),
+				WithCompressedColumn("raw", ""),
+			},
+			expectedSQL: "INSERT INTO `events` (`name`, `payload`, `payload_codec`, `raw`) VALUES (?, ?, ?, ?)",
+			expectedArgs: []driver.Value{
+				"a", nil, nil, []byte("zyx"),
+			},
+		},
+		{
+			description: "compression error",
+			objects: []interface{}{
+				event{Name: "a", Payload: &empty},
+			},
+			opts:          []Option{WithCompressor(reverseCompressor{})},
+			expectedError: "could not compress column payload: nothing to compress",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			if tc.expectedError == "" {
+				mock.ExpectExec(regexp.QuoteMeta(tc.expectedSQL)).
+					WithArgs(tc.expectedArgs...).
+					WillReturnResult(sqlmock.NewResult(0, int64(len(tc.objects))))
+			}
+
+			err = BulkInsert(gdb, tc.objects, tc.opts...)
+
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGzipCompressor(t *testing.T) {
+	data := bytes.Repeat([]byte("payload "), 100)
+
+	compressor := NewGzipCompressor()
+	assert.Equal(t, "gzip", compressor.Codec())
+
+	compressed, err := compressor.Compress(data)
+	require.NoError(t, err)
+	assert.True(t, len(compressed) < len(data))
+
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	require.NoError(t, err)
+
+	decompressed, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, data, decompressed)
+}
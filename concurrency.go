@@ -0,0 +1,184 @@
+package gormbulk
+
+import (
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// WithConcurrency will execute up to n chunks at the same time with
+// BulkExecChunk. Chunks may finish in any order so rows in one chunk must not
+// depend on rows in another chunk, i.e. by referencing auto increment IDs; use
+// WithOrderedChunks for such workloads. The function set with OnChunkDone may
+// be called concurrently. Chunks are always executed one by one when using
+// WithPinnedConnection, WithMultiStatement or any session statements since
+// they require a single connection.
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		o.concurrencyLimit = n
+	}
+}
+
+// WithOrderedChunks will execute the chunks one by one in input order, each
+// chunk starting after the previous chunk is done, even when using
+// WithConcurrency.
+func WithOrderedChunks() Option {
+	return func(o *options) {
+		o.orderedChunks = true
+	}
+}
+
+// concurrency returns the number of chunks to execute at the same time.
+func (o *options) concurrency() int {
+	if o.orderedChunks || o.pinConnection || o.hasSession() || o.statementsPerTrip > 1 {
+		return 1
+	}
+
+	if o.concurrencyLimit < 1 {
+		return 1
+	}
+
+	return o.concurrencyLimit
+}
+
+// WithPoolReserve will cap the number of statements in flight when using
+// WithConcurrency so at least n connections in the pool are left for other
+// queries, i.e. the application's regular queries. The cap is the max number
+// of open connections (see sql.DB.SetMaxOpenConns) minus n, but never less
+// than one. Nothing is capped if the pool has no max number of open
+// connections.
+func WithPoolReserve(n int) Option {
+	return func(o *options) {
+		o.poolReserve = n
+	}
+}
+
+// inFlight returns the max number of chunks to execute at the same time for
+// the db, which is the concurrency capped by the pool reserve.
+func (o *options) inFlight(db *gorm.DB) int {
+	n := o.concurrency()
+
+	if o.poolReserve < 1 {
+		return n
+	}
+
+	sqlDB, ok := db.CommonDB().(*sql.DB)
+	if !ok {
+		return n
+	}
+
+	maxOpen := sqlDB.Stats().MaxOpenConnections
+	if maxOpen < 1 {
+		return n
+	}
+
+	if available := maxOpen - o.poolReserve; available < n {
+		n = available
+	}
+
+	if n < 1 {
+		return 1
+	}
+
+	return n
+}
+
+// chunkError is an error from a chunk executed concurrently.
+type chunkError struct {
+	chunk int
+	err   error
+}
+
+// execChunksConcurrently executes the objects in chunks with up to
+// concurrency chunks at the same time. The errors are returned in chunk order.
+func (o *options) execChunksConcurrently(db *gorm.DB, objects []interface{}, rows []int, execFunc ExecFuncV2, chunkSize int) []error {
+	var (
+		mu           sync.Mutex
+		wg           sync.WaitGroup
+		slots        = make(chan struct{}, o.inFlight(db))
+		chunkErrors  []chunkError
+		notAttempted error
+		elapsed      time.Duration
+		done         int
+	)
+
+	for len(objects) > 0 {
+		size := o.chunkLen(objects, chunkSize)
+
+		slots <- struct{}{}
+
+		mu.Lock()
+		err := o.canStartChunk(elapsed, done, size)
+		mu.Unlock()
+
+		if err != nil {
+			<-slots
+			notAttempted = &NotAttemptedError{Rows: rows, Err: err}
+
+			break
+		}
+
+		chunkObjects, chunkRows := objects[:size], rows[:size]
+		objects, rows = objects[size:], rows[size:]
+
+		// Each chunk gets its own options to count the chunk and the result
+		// separately.
+		chunkOptions := *o
+		chunkOptions.result = &Result{}
+
+		if len(objects) == 0 {
+			chunkOptions.padSize = chunkSize
+		}
+
+		wg.Add(1)
+
+		go func(chunkOptions *options, chunkObjects []interface{}, chunkRows []int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			started := time.Now()
+			err := execObjects(db, chunkObjects, chunkRows, execFunc, chunkOptions)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			elapsed += time.Since(started)
+			done += len(chunkObjects)
+
+			if o.result != nil {
+				o.result.RowsAffected += chunkOptions.result.RowsAffected
+				o.result.Unchanged += chunkOptions.result.Unchanged
+				o.result.StatementSizes = append(o.result.StatementSizes, chunkOptions.result.StatementSizes...)
+			}
+
+			if err != nil {
+				chunkErrors = append(chunkErrors, chunkError{chunk: chunkOptions.chunk, err: err})
+			}
+		}(&chunkOptions, chunkObjects, chunkRows)
+
+		o.chunk++
+	}
+
+	wg.Wait()
+
+	sort.Slice(chunkErrors, func(i, j int) bool {
+		return chunkErrors[i].chunk < chunkErrors[j].chunk
+	})
+
+	var allErrors []error
+
+	for _, e := range chunkErrors {
+		allErrors = append(allErrors, e.err)
+	}
+
+	if notAttempted != nil {
+		allErrors = append(allErrors, notAttempted)
+	}
+
+	return allErrors
+}
@@ -0,0 +1,184 @@
+package gormbulk
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithConcurrency(t *testing.T) {
+	type item struct {
+		Name string
+	}
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	mock.MatchExpectationsInOrder(false)
+
+	for _, name := range []string{"a", "b", "c", "d"} {
+		exec := mock.ExpectExec("INSERT INTO `items`").WithArgs(name)
+
+		switch name {
+		case "a", "c":
+			exec.WillReturnError(errors.New(name))
+		default:
+			exec.WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+	}
+
+	var (
+		result  = &Result{}
+		objects = []interface{}{
+			item{Name: "a"}, item{Name: "b"}, item{Name: "c"}, item{Name: "d"},
+		}
+	)
+
+	errs := BulkExecChunk(gdb, objects, InsertFunc, 1, WithConcurrency(2), WithResult(result))
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, []error{errors.New("a"), errors.New("c")}, errs)
+	assert.Equal(t, int64(2), result.RowsAffected)
+}
+
+func TestWithOrderedChunks(t *testing.T) {
+	type item struct {
+		Name string
+	}
+
+	cases := []struct {
+		description string
+		opts        []Option
+		concurrent  bool
+	}{
+		{
+			description: "chunks executed at the same time",
+			opts:        []Option{WithConcurrency(2)},
+			concurrent:  true,
+		},
+		{
+			description: "chunks executed in order",
+			opts:        []Option{WithConcurrency(2), WithOrderedChunks()},
+		},
+		{
+			description: "chunks executed in order by default",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			var (
+				mu      sync.Mutex
+				started sync.WaitGroup
+				order   []interface{}
+				objects = []interface{}{item{Name: "a"}, item{Name: "b"}}
+			)
+
+			// Each statement waits for the other statement to start, which
+			// only happens if they're executed at the same time.
+			started.Add(len(objects))
+
+			executor := ExecutorFunc(func(_ *gorm.DB, _ string, vars ...interface{}) error {
+				mu.Lock()
+				order = append(order, vars...)
+				mu.Unlock()
+
+				started.Done()
+
+				wait := make(chan struct{})
+				go func() {
+					started.Wait()
+					close(wait)
+				}()
+
+				select {
+				case <-wait:
+					return nil
+				case <-time.After(50 * time.Millisecond):
+					return errors.New("timeout")
+				}
+			})
+
+			db, _, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			errs := BulkExecChunk(gdb, objects, InsertFunc, 1, append(tc.opts, WithExecutor(executor))...)
+
+			if tc.concurrent {
+				assert.Empty(t, errs)
+				assert.ElementsMatch(t, []interface{}{"a", "b"}, order)
+
+				return
+			}
+
+			// The first statement times out waiting for the second one.
+			assert.Equal(t, []error{errors.New("timeout")}, errs)
+			assert.Equal(t, []interface{}{"a", "b"}, order)
+		})
+	}
+}
+
+func TestWithPoolReserve(t *testing.T) {
+	cases := []struct {
+		description string
+		maxOpen     int
+		opts        []Option
+		expected    int
+	}{
+		{
+			description: "no reserve",
+			maxOpen:     4,
+			opts:        []Option{WithConcurrency(8)},
+			expected:    8,
+		},
+		{
+			description: "capped by reserve",
+			maxOpen:     4,
+			opts:        []Option{WithConcurrency(8), WithPoolReserve(1)},
+			expected:    3,
+		},
+		{
+			description: "concurrency below cap",
+			maxOpen:     10,
+			opts:        []Option{WithConcurrency(2), WithPoolReserve(1)},
+			expected:    2,
+		},
+		{
+			description: "at least one",
+			maxOpen:     2,
+			opts:        []Option{WithConcurrency(8), WithPoolReserve(5)},
+			expected:    1,
+		},
+		{
+			description: "unlimited pool",
+			opts:        []Option{WithConcurrency(8), WithPoolReserve(1)},
+			expected:    8,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, _, err := sqlmock.New()
+			require.NoError(t, err)
+
+			db.SetMaxOpenConns(tc.maxOpen)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expected, newOptions(tc.opts...).inFlight(gdb))
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package gormbulk
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jinzhu/gorm"
+)
+
+// WithPinnedConnection will execute all statements for the bulk operation on
+// one single connection from the pool instead of any free connection, i.e. to
+// make session settings such as `SET foreign_key_checks = 0`, temporary
+// tables and `LAST_INSERT_ID()` consistent for all chunks. The transactions
+// started for WithAuditTable, BulkSync and SeedTable are started on the pinned
+// connection, a transaction passed as db always uses its own connection.
+// Statements executed on the pinned connection are not logged by gorm. The
+// connection is pinned by BulkExec, BulkExecChunk, BulkExecAsync and the
+// functions reading objects from a reader, and by a BulkWriter for each call
+// executing any chunks. The connection is always pinned when using
+// WithSetupSQL or WithTeardownSQL.
+func WithPinnedConnection() Option {
+	return func(o *options) {
+		o.pinConnection = true
+	}
+}
+
+// pin gets the connection used for all statements when using
+// WithPinnedConnection. The returned function must be called to release the
+// connection when the bulk operation is done.
+func (o *options) pin(db *gorm.DB) (func(), error) {
+	sqlDB, ok := db.CommonDB().(*sql.DB)
+	if !(o.pinConnection || o.hasSession()) || o.conn != nil || !ok {
+		return func() {}, nil
+	}
+
+	ctx := o.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	o.conn = conn
+
+	return func() {
+		conn.Close()
+		o.conn = nil
+	}, nil
+}
+
+// pinned returns true if statements for the db should be executed on the
+// pinned connection. Transactions use their own connection.
+func (o *options) pinned(db *gorm.DB) bool {
+	if o.conn == nil {
+		return false
+	}
+
+	_, ok := db.CommonDB().(*sql.DB)
+
+	return ok
+}
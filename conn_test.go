@@ -0,0 +1,256 @@
+package gormbulk
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sessionConnector is a driver opening a new connection for each call to
+// Connect and recording the statements executed on each connection, including
+// the start and end of transactions. All statements succeed and all queries
+// return a single row with the value 1.
+type sessionConnector struct {
+	mu          sync.Mutex
+	connections [][]string
+}
+
+type sessionConn struct {
+	connector *sessionConnector
+	id        int
+}
+
+type sessionRows struct {
+	done bool
+}
+
+func (c *sessionConnector) Connect(context.Context) (driver.Conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.connections = append(c.connections, nil)
+
+	return &sessionConn{connector: c, id: len(c.connections) - 1}, nil
+}
+
+func (c *sessionConnector) Driver() driver.Driver { return nil }
+
+func (c *sessionConn) record(statement string) {
+	c.connector.mu.Lock()
+	defer c.connector.mu.Unlock()
+
+	c.connector.connections[c.id] = append(c.connector.connections[c.id], strings.TrimSpace(statement))
+}
+
+func (c *sessionConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *sessionConn) Close() error                        { return nil }
+
+func (c *sessionConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *sessionConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.record("BEGIN")
+	return c, nil
+}
+
+func (c *sessionConn) Commit() error {
+	c.record("COMMIT")
+	return nil
+}
+
+func (c *sessionConn) Rollback() error {
+	c.record("ROLLBACK")
+	return nil
+}
+
+func (c *sessionConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.record(query)
+	return driver.RowsAffected(1), nil
+}
+
+func (c *sessionConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.record(query)
+	return &sessionRows{}, nil
+}
+
+func (r *sessionRows) Columns() []string { return []string{"value"} }
+func (r *sessionRows) Close() error      { return nil }
+
+func (r *sessionRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+
+	r.done = true
+	dest[0] = int64(1)
+
+	return nil
+}
+
+func TestWithPinnedConnection(t *testing.T) {
+	type item struct {
+		Name string
+	}
+
+	cases := []struct {
+		description   string
+		opts          []Option
+		expectedInUse []int
+	}{
+		{
+			description:   "connection returned after each chunk",
+			expectedInUse: []int{0, 0},
+		},
+		{
+			description:   "connection held for all chunks",
+			opts:          []Option{WithPinnedConnection()},
+			expectedInUse: []int{1, 1},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			mock.ExpectExec("INSERT INTO `items`").
+				WithArgs("a").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			mock.ExpectExec("INSERT INTO `items`").
+				WithArgs("b").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			var (
+				inUse   []int
+				result  = &Result{}
+				objects = []interface{}{item{Name: "a"}, item{Name: "b"}}
+			)
+
+			opts := append([]Option{
+				WithResult(result),
+				OnChunkDone(func(_ int, _ sql.Result, err error) {
+					require.NoError(t, err)
+					inUse = append(inUse, db.Stats().InUse)
+				}),
+			}, tc.opts...)
+
+			require.Empty(t, BulkExecChunk(gdb, objects, InsertFunc, 1, opts...))
+			require.NoError(t, mock.ExpectationsWereMet())
+
+			assert.Equal(t, tc.expectedInUse, inUse)
+			assert.Equal(t, int64(2), result.RowsAffected)
+			assert.Equal(t, 0, db.Stats().InUse)
+		})
+	}
+}
+
+func TestPinnedConnectionContext(t *testing.T) {
+	type item struct {
+		Name string
+	}
+
+	gdb, err := gorm.Open("mysql", sql.OpenDB(&txOptionsConnector{}))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Cancel the context after the statement is built but before it's
+	// executed on the pinned connection.
+	check := func(string, []interface{}) error {
+		cancel()
+		return nil
+	}
+
+	err = BulkInsert(
+		gdb,
+		[]interface{}{item{Name: "a"}},
+		WithPinnedConnection(),
+		WithContext(ctx),
+		WithStatementCheck(check),
+	)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestPinnedConnectionTransaction(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	cases := []struct {
+		description string
+		run         func(db *gorm.DB, opts ...Option) error
+		expected    []string
+	}{
+		{
+			description: "audited chunk",
+			run: func(db *gorm.DB, opts ...Option) error {
+				opts = append(opts, WithAuditTable("users_audit"))
+				return BulkInsert(db, []interface{}{user{Name: "a"}}, opts...)
+			},
+			expected: []string{
+				"SET unique_checks = 0",
+				"BEGIN",
+				"INSERT INTO `users` (`name`) VALUES (?)",
+				"INSERT INTO `users_audit` (`audited_at`, `name`, `operation`) VALUES (?, ?, ?)",
+				"COMMIT",
+				"SET unique_checks = 1",
+			},
+		},
+		{
+			description: "seeded table",
+			run: func(db *gorm.DB, opts ...Option) error {
+				_, err := SeedTable(db.Table("staff"), []interface{}{user{Name: "a"}}, append(opts, WithSyncKeys("name"))...)
+				return err
+			},
+			expected: []string{
+				"SET unique_checks = 0",
+				"BEGIN",
+				"SELECT COUNT(*) FROM `staff` WHERE (`name`) IN ((?))",
+				"INSERT INTO `staff` (`name`) VALUES (?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)",
+				"COMMIT",
+				"SET unique_checks = 1",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			connector := &sessionConnector{}
+
+			db := sql.OpenDB(connector)
+			db.SetMaxOpenConns(1)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			require.NoError(t, tc.run(
+				gdb,
+				WithContext(ctx),
+				WithSetupSQL("SET unique_checks = 0"),
+				WithTeardownSQL("SET unique_checks = 1"),
+			))
+
+			require.Len(t, connector.connections, 1)
+			assert.Equal(t, tc.expected, connector.connections[0])
+		})
+	}
+}
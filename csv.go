@@ -0,0 +1,179 @@
+package gormbulk
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// csvTimeLayouts holds the layouts tried, in order, when parsing a CSV value
+// to a time.Time.
+var csvTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// BulkInsertCSV will read all records from the CSV and insert them as objects
+// of the same type as model, in chunks of DefaultChunkSize (see
+// WithChunkSize). The first record must be a header with the column names or
+// field names of the model. Each value will be converted to the type of the
+// field, empty values will leave the field blank (nil for pointers) and
+// slices, maps and structs are parsed as JSON. The row in any error returned
+// is the index of the record, not counting the header.
+func BulkInsertCSV(db *gorm.DB, model interface{}, r io.Reader, opts ...Option) error {
+	modelType, err := structType(model)
+	if err != nil {
+		return err
+	}
+
+	reader := csv.NewReader(r)
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not read CSV header: %v", err)
+	}
+
+	columns := make([]string, len(header))
+	modelScope := &gorm.Scope{Value: reflect.New(modelType).Interface()}
+
+	for i, name := range header {
+		field, ok := modelScope.FieldByName(strings.TrimSpace(name))
+		if !ok {
+			return fmt.Errorf("CSV column %s not found in model", name)
+		}
+
+		columns[i] = field.Name
+	}
+
+	row := 0
+
+	next := func() (interface{}, error) {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil, err
+		}
+
+		if err != nil {
+			return nil, &RowError{Row: row, Err: err}
+		}
+
+		object := reflect.New(modelType)
+		scope := &gorm.Scope{Value: object.Interface()}
+
+		for i, value := range record {
+			field, _ := scope.FieldByName(columns[i])
+
+			if err := setFieldString(field.Field, value); err != nil {
+				return nil, &RowError{
+					Row: row,
+					Err: fmt.Errorf("could not parse column %s: %v", field.DBName, err),
+				}
+			}
+		}
+
+		row++
+
+		return object.Interface(), nil
+	}
+
+	return streamObjects(db, next, ExecFunc(InsertFunc).toV2(), newModelOptions(model, opts...))
+}
+
+// setFieldString converts the string to the type of the field and sets it.
+// Empty strings will leave the field with its zero value, except for string
+// fields and sql.Scanner implementations which are set to (or scan) the
+// empty value.
+func setFieldString(fv reflect.Value, value string) error {
+	if fv.Kind() == reflect.Ptr {
+		if value == "" {
+			return nil
+		}
+
+		fv.Set(reflect.New(fv.Type().Elem()))
+
+		return setFieldString(fv.Elem(), value)
+	}
+
+	if scanner, ok := fv.Addr().Interface().(sql.Scanner); ok {
+		if value == "" {
+			return scanner.Scan(nil)
+		}
+
+		return scanner.Scan(value)
+	}
+
+	if fv.Kind() == reflect.String {
+		fv.SetString(value)
+		return nil
+	}
+
+	if value == "" {
+		return nil
+	}
+
+	if _, ok := fv.Interface().(time.Time); ok {
+		for _, layout := range csvTimeLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				fv.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+
+		return fmt.Errorf("invalid time %q", value)
+	}
+
+	switch fv.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		fv.SetFloat(n)
+	case reflect.Slice, reflect.Map, reflect.Struct:
+		if fv.Type() == reflect.TypeOf([]byte(nil)) {
+			fv.SetBytes([]byte(value))
+			return nil
+		}
+
+		return json.Unmarshal([]byte(value), fv.Addr().Interface())
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+
+	return nil
+}
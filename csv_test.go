@@ -0,0 +1,162 @@
+package gormbulk
+
+import (
+	"database/sql"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type csvUser struct {
+	Name   string
+	Age    int
+	Active bool
+	Score  *float64
+	Tags   []string `gorm:"type:json"`
+}
+
+func TestBulkInsertCSV(t *testing.T) {
+	cases := []struct {
+		description      string
+		csv              string
+		options          []Option
+		expectedMockFunc func(mock sqlmock.Sqlmock)
+		expectedErr      string
+	}{
+		{
+			description: "header with column and field names",
+			csv:         "name,Age,active,score,tags\nfoo,1,true,1.5,\"[\"\"a\"\"]\"\nbar,2,false,,\n",
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `csv_users` (`active`, `age`, `name`, `score`, `tags`) VALUES (?, ?, ?, ?, ?), (?, ?, ?, ?, ?)")).
+					WithArgs(true, 1, "foo", 1.5, `["a"]`, false, 2, "bar", nil, nil).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+		},
+		{
+			description: "chunked inserts",
+			csv:         "name,age\nfoo,1\nbar,2\nbaz,3\n",
+			options:     []Option{WithChunkSize(2)},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `csv_users` (`active`, `age`, `name`, `score`, `tags`) VALUES (?, ?, ?, ?, ?), (?, ?, ?, ?, ?)")).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `csv_users` (`active`, `age`, `name`, `score`, `tags`) VALUES (?, ?, ?, ?, ?)")).
+					WithArgs(false, 3, "baz", nil, nil).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			description:      "only header",
+			csv:              "name,age\n",
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {},
+		},
+		{
+			description:      "unknown column",
+			csv:              "name,unknown\nfoo,1\n",
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {},
+			expectedErr:      "CSV column unknown not found in model",
+		},
+		{
+			description: "invalid value reports row",
+			csv:         "name,age\nfoo,1\nbar,2\nbaz,x\n",
+			options:     []Option{WithChunkSize(2)},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `csv_users`")).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+			expectedErr: "row 2: could not parse column age",
+		},
+		{
+			description: "validator gets row in whole CSV",
+			csv:         "name,age\nfoo,1\nbar,2\nbaz,3\n",
+			options: []Option{
+				WithChunkSize(2),
+				WithValidator(func(i int, object interface{}) error {
+					if object.(*csvUser).Name == "baz" {
+						return assert.AnError
+					}
+
+					return nil
+				}),
+			},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `csv_users`")).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+			expectedErr: "row 2: " + assert.AnError.Error(),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			tc.expectedMockFunc(mock)
+
+			err = BulkInsertCSV(gdb, csvUser{}, strings.NewReader(tc.csv), tc.options...)
+
+			if tc.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_setFieldString(t *testing.T) {
+	type test struct {
+		String     string
+		Uint       uint8
+		Time       time.Time
+		Date       time.Time
+		NullString sql.NullString
+		PtrInt     *int
+		Bytes      []byte
+	}
+
+	var (
+		object   test
+		ten      = 10
+		expected = test{
+			Uint:       200,
+			Time:       time.Date(2019, 11, 1, 12, 0, 0, 0, time.UTC),
+			Date:       time.Date(2019, 11, 1, 0, 0, 0, 0, time.UTC),
+			NullString: sql.NullString{},
+			PtrInt:     &ten,
+			Bytes:      []byte("foo"),
+		}
+	)
+
+	rv := reflect.ValueOf(&object).Elem()
+
+	for name, value := range map[string]string{
+		"String":     "",
+		"Uint":       "200",
+		"Time":       "2019-11-01T12:00:00Z",
+		"Date":       "2019-11-01",
+		"NullString": "",
+		"PtrInt":     "10",
+		"Bytes":      "foo",
+	} {
+		require.NoError(t, setFieldString(rv.FieldByName(name), value), name)
+	}
+
+	assert.Equal(t, expected, object)
+
+	assert.Error(t, setFieldString(rv.FieldByName("Uint"), "256"))
+	assert.Error(t, setFieldString(rv.FieldByName("Time"), "yesterday"))
+}
@@ -0,0 +1,136 @@
+package gormbulk
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// DeadLetterRowColumn is the column in the dead letter table holding the
+	// index of the object in the slice passed to the bulk function.
+	DeadLetterRowColumn = "row_index"
+
+	// DeadLetterObjectColumn is the column in the dead letter table holding
+	// the object serialized as JSON.
+	DeadLetterObjectColumn = "object"
+
+	// DeadLetterErrorColumn is the column in the dead letter table holding
+	// the error text.
+	DeadLetterErrorColumn = "error"
+
+	// DeadLetterTimestampColumn is the column in the dead letter table
+	// holding the time the object failed.
+	DeadLetterTimestampColumn = "failed_at"
+)
+
+// deadLetter is a row in the dead letter table. The column names must match
+// the DeadLetter*Column constants.
+type deadLetter struct {
+	Row      int       `gorm:"column:row_index"`
+	Object   string    `gorm:"column:object"`
+	Error    string    `gorm:"column:error"`
+	FailedAt time.Time `gorm:"column:failed_at"`
+}
+
+// WithDeadLetterTable will insert the objects failing to be written into the
+// table, i.e. `users_errors`, giving a queryable record of rejected objects.
+// The table must have the columns DeadLetterRowColumn, DeadLetterObjectColumn
+// (text holding the object as JSON), DeadLetterErrorColumn (text) and
+// DeadLetterTimestampColumn. If the error is for a single row (*RowError) only
+// that object is written, otherwise all objects in the failed statement,
+// chunk or round trip (see WithMultiStatement). The objects are
+// inserted with the same db as the bulk statement, so nothing can be written
+// if the db is a transaction aborted by the failure. If the objects can't be
+// written a *DeadLetterError is returned.
+func WithDeadLetterTable(table string) Option {
+	return func(o *options) {
+		o.deadLetterTable = table
+	}
+}
+
+// deadLetterOptions returns the options used to build and execute the
+// statement for the dead letter table. Only the options deciding how and
+// where statements are executed are kept.
+func (o *options) deadLetterOptions() *options {
+	return &options{
+		executor:        o.executor,
+		statementChecks: o.statementChecks,
+		statementLog:    o.statementLog,
+		redactor:        o.redactor,
+		conn:            o.conn,
+	}
+}
+
+// failedRows returns the rows the error is for, nil if it's for all objects.
+func failedRows(err error) map[int]struct{} {
+	var rows []int
+
+	switch e := err.(type) {
+	case *RowError:
+		rows = []int{e.Row}
+	case *MultiStatementError:
+		rows = e.Rows
+	default:
+		return nil
+	}
+
+	failed := make(map[int]struct{}, len(rows))
+	for _, row := range rows {
+		failed[row] = struct{}{}
+	}
+
+	return failed
+}
+
+// writeDeadLetters inserts the objects failing with the error into the dead
+// letter table set with WithDeadLetterTable, if any. The rows holds the index
+// of each object.
+func (o *options) writeDeadLetters(db *gorm.DB, objects []interface{}, rows []int, err error) error {
+	if o.deadLetterTable == "" {
+		return nil
+	}
+
+	var (
+		failed   = failedRows(err)
+		letters  []interface{}
+		failedAt = o.currentTime()
+	)
+
+	for i, object := range objects {
+		row := i
+		if rows != nil {
+			row = rows[i]
+		}
+
+		if _, ok := failed[row]; failed != nil && !ok {
+			continue
+		}
+
+		data, jsonErr := json.Marshal(object)
+		if jsonErr != nil {
+			return jsonErr
+		}
+
+		letters = append(letters, deadLetter{
+			Row:      row,
+			Object:   string(data),
+			Error:    err.Error(),
+			FailedAt: failedAt,
+		})
+	}
+
+	if len(letters) == 0 {
+		return nil
+	}
+
+	do := o.deadLetterOptions()
+
+	scope, scopeErr := scopeFromObjects(db.Table(o.deadLetterTable), letters, nil, plainInsertFunc, do)
+	if scopeErr != nil {
+		return scopeErr
+	}
+
+	return do.exec(db, scope.SQL, scope.SQLVars...)
+}
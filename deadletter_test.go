@@ -0,0 +1,195 @@
+package gormbulk
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDeadLetterTable(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	now := time.Date(2019, 11, 1, 0, 0, 0, 0, time.UTC)
+	objects := []interface{}{user{Name: "a"}, user{Name: "b"}, user{Name: "c"}}
+
+	cases := []struct {
+		description      string
+		expectedMockFunc func(mock sqlmock.Sqlmock)
+		expectedErrs     []error
+	}{
+		{
+			description: "failed chunk written to dead letter table",
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?), (?)")).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?)")).
+					WillReturnError(errors.New("duplicate entry"))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users_errors` (`error`, `failed_at`, `object`, `row_index`) VALUES (?, ?, ?, ?)")).
+					WithArgs("duplicate entry", now, `{"Name":"c"}`, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedErrs: []error{errors.New("duplicate entry")},
+		},
+		{
+			description: "failing to write dead letters",
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?), (?)")).
+					WillReturnError(errors.New("duplicate entry"))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users_errors` (`error`, `failed_at`, `object`, `row_index`) VALUES (?, ?, ?, ?), (?, ?, ?, ?)")).
+					WithArgs(
+						"duplicate entry", now, `{"Name":"a"}`, 0,
+						"duplicate entry", now, `{"Name":"b"}`, 1,
+					).
+					WillReturnError(errors.New("no such table"))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?)")).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedErrs: []error{
+				&DeadLetterError{
+					Err:           errors.New("duplicate entry"),
+					DeadLetterErr: errors.New("no such table"),
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			tc.expectedMockFunc(mock)
+
+			errs := BulkExecChunk(
+				gdb, objects, InsertFunc, 2,
+				WithDeadLetterTable("users_errors"),
+				WithNowFunc(func() time.Time { return now }),
+			)
+
+			assert.Equal(t, tc.expectedErrs, errs)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWithDeadLetterTableMultiStatement(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	const (
+		one        = "INSERT INTO `users` (`name`) VALUES (?)"
+		two        = one + ";\n" + one
+		deadLetter = "INSERT INTO `users_errors` (`error`, `failed_at`, `object`, `row_index`) VALUES (?, ?, ?, ?)"
+	)
+
+	now := time.Date(2019, 11, 1, 0, 0, 0, 0, time.UTC)
+	objects := []interface{}{user{Name: "a"}, user{Name: "b"}, user{Name: "c"}}
+
+	cases := []struct {
+		description      string
+		expectedMockFunc func(mock sqlmock.Sqlmock)
+		expectedErrs     []error
+	}{
+		{
+			description: "all rows in a failed trip written to dead letter table",
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(two).
+					WithArgs("a", "b").
+					WillReturnError(errors.New("deadlock"))
+				mock.ExpectExec(deadLetter+", (?, ?, ?, ?)").
+					WithArgs(
+						"deadlock", now, `{"Name":"a"}`, 0,
+						"deadlock", now, `{"Name":"b"}`, 1,
+					).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(one).
+					WithArgs("c").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedErrs: []error{
+				&MultiStatementError{Statements: 2, Rows: []int{0, 1}, Err: errors.New("deadlock")},
+			},
+		},
+		{
+			description: "failed last trip written to dead letter table",
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(two).
+					WithArgs("a", "b").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(one).
+					WithArgs("c").
+					WillReturnError(errors.New("deadlock"))
+				mock.ExpectExec(deadLetter).
+					WithArgs("deadlock", now, `{"Name":"c"}`, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedErrs: []error{
+				&MultiStatementError{Statements: 1, Rows: []int{2}, Err: errors.New("deadlock")},
+			},
+		},
+		{
+			description: "failing to write dead letters for the last trip",
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(two).
+					WithArgs("a", "b").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(one).
+					WithArgs("c").
+					WillReturnError(errors.New("deadlock"))
+				mock.ExpectExec(deadLetter).
+					WithArgs("deadlock", now, `{"Name":"c"}`, 2).
+					WillReturnError(errors.New("no such table"))
+			},
+			expectedErrs: []error{
+				&DeadLetterError{
+					Err:           &MultiStatementError{Statements: 1, Rows: []int{2}, Err: errors.New("deadlock")},
+					DeadLetterErr: errors.New("no such table"),
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			tc.expectedMockFunc(mock)
+
+			errs := BulkExecChunk(
+				gdb, objects, InsertFunc, 1,
+				WithMultiStatement(2),
+				WithDeadLetterTable("users_errors"),
+				WithNowFunc(func() time.Time { return now }),
+			)
+
+			assert.Equal(t, tc.expectedErrs, errs)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_failedRows(t *testing.T) {
+	assert.Nil(t, failedRows(errors.New("failed")))
+	assert.Equal(t, map[int]struct{}{3: {}}, failedRows(&RowError{Row: 3, Err: errors.New("failed")}))
+	assert.Equal(
+		t,
+		map[int]struct{}{1: {}, 2: {}},
+		failedRows(&MultiStatementError{Statements: 2, Rows: []int{1, 2}, Err: errors.New("failed")}),
+	)
+}
@@ -0,0 +1,52 @@
+package gormbulk
+
+import (
+	"context"
+	"time"
+)
+
+// WithContext sets the context used when executing objects in chunks with
+// BulkExecChunk. No chunk is started after the context is done and if the
+// context has a deadline, no chunk is started unless it's estimated to finish
+// before the deadline. The estimate is based on the time per object for the
+// chunks already executed. The rows not attempted are returned in a
+// *NotAttemptedError. Statements already started are not cancelled.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+// canStartChunk returns an error if a chunk with size objects should not be
+// started according to the guard (see WithGuard), the circuit breaker (see
+// WithCircuitBreaker) or the context. The elapsed time is the total time spent
+// executing the done objects.
+func (o *options) canStartChunk(elapsed time.Duration, done, size int) error {
+	if err := o.checkGuard(); err != nil {
+		return err
+	}
+
+	if err := o.retryState.checkBreaker(); err != nil {
+		return err
+	}
+
+	if o.ctx == nil {
+		return nil
+	}
+
+	if err := o.ctx.Err(); err != nil {
+		return err
+	}
+
+	deadline, ok := o.ctx.Deadline()
+	if !ok || done == 0 {
+		return nil
+	}
+
+	estimate := time.Duration(int64(elapsed) * int64(size) / int64(done))
+	if time.Until(deadline) < estimate {
+		return context.DeadlineExceeded
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package gormbulk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithContext(t *testing.T) {
+	type test struct {
+		Foo string
+	}
+
+	objects := []interface{}{
+		test{"a"}, test{"b"}, test{"c"}, test{"d"}, test{"e"},
+	}
+
+	cases := []struct {
+		description      string
+		ctx              func() (context.Context, context.CancelFunc)
+		expectedMockFunc func(mock sqlmock.Sqlmock)
+		expectedErr      *NotAttemptedError
+	}{
+		{
+			description: "all chunks executed without deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				for i := 0; i < 3; i++ {
+					mock.ExpectExec("INSERT INTO `tests`").
+						WillReturnResult(sqlmock.NewResult(0, 2))
+				}
+			},
+		},
+		{
+			description: "nothing attempted when context is done",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				return ctx, cancel
+			},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {},
+			expectedErr: &NotAttemptedError{
+				Rows: []int{0, 1, 2, 3, 4},
+				Err:  context.Canceled,
+			},
+		},
+		{
+			description: "stop before chunk estimated to exceed deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 150*time.Millisecond)
+			},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `tests`").
+					WillDelayFor(100 * time.Millisecond).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+			expectedErr: &NotAttemptedError{
+				Rows: []int{2, 3, 4},
+				Err:  context.DeadlineExceeded,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			tc.expectedMockFunc(mock)
+
+			ctx, cancel := tc.ctx()
+			defer cancel()
+
+			errs := BulkExecChunk(gdb, objects, InsertFunc, 2, WithContext(ctx))
+
+			if tc.expectedErr != nil {
+				assert.Equal(t, []error{tc.expectedErr}, errs)
+			} else {
+				assert.Empty(t, errs)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
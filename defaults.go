@@ -0,0 +1,30 @@
+package gormbulk
+
+import "sync"
+
+var (
+	defaultsMu sync.RWMutex
+	defaults   []Option
+)
+
+// SetDefaults will use the options for all bulk calls in the process, i.e. to
+// set a statement log, WithNowFunc or WithChunkSize once at startup instead of
+// passing the same options to every call. The defaults are applied first,
+// followed by the options registered with Configure for the model and the
+// options passed to the call which take precedence. Options appending to a
+// list, such as WithUpdateExcludedColumns, are merged. Calling SetDefaults
+// again replaces the defaults and calling it without options removes them.
+func SetDefaults(opts ...Option) {
+	defaultsMu.Lock()
+	defer defaultsMu.Unlock()
+
+	defaults = append([]Option{}, opts...)
+}
+
+// defaultOptions returns the options set with SetDefaults.
+func defaultOptions() []Option {
+	defaultsMu.RLock()
+	defer defaultsMu.RUnlock()
+
+	return defaults
+}
@@ -0,0 +1,80 @@
+package gormbulk
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetDefaults(t *testing.T) {
+	type user struct {
+		Name      string
+		UpdatedAt time.Time
+	}
+
+	var (
+		now     = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		nowFunc = func() time.Time { return now }
+		columns = " (`name`, `updated_at`) VALUES (?, ?)"
+	)
+
+	cases := []struct {
+		description string
+		defaults    []Option
+		profile     []Option
+		opts        []Option
+		expectedSQL string
+	}{
+		{
+			description: "defaults used",
+			defaults:    []Option{WithNowFunc(nowFunc), WithTablePrefix("app_")},
+			expectedSQL: "INSERT INTO `app_users`" + columns,
+		},
+		{
+			description: "profile takes precedence",
+			defaults:    []Option{WithNowFunc(nowFunc), WithTablePrefix("app_")},
+			profile:     []Option{WithTablePrefix("model_")},
+			expectedSQL: "INSERT INTO `model_users`" + columns,
+		},
+		{
+			description: "call options take precedence",
+			defaults:    []Option{WithNowFunc(nowFunc), WithTablePrefix("app_")},
+			profile:     []Option{WithTablePrefix("model_")},
+			opts:        []Option{WithTablePrefix("tmp_")},
+			expectedSQL: "INSERT INTO `tmp_users`" + columns,
+		},
+		{
+			description: "no defaults",
+			opts:        []Option{WithNowFunc(nowFunc)},
+			expectedSQL: "INSERT INTO `users`" + columns,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			SetDefaults(tc.defaults...)
+			defer SetDefaults()
+
+			Configure(user{}, tc.profile...)
+			defer Configure(user{})
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			mock.ExpectExec(regexp.QuoteMeta(tc.expectedSQL)+"$").
+				WithArgs("a", now).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			err = BulkInsert(gdb, []interface{}{user{Name: "a"}}, tc.opts...)
+			require.NoError(t, err)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
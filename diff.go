@@ -0,0 +1,180 @@
+package gormbulk
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// WithSkipUnchanged will select the existing rows with the same keys as the
+// objects before each statement and leave out all objects where no field
+// differs from the existing row. Fields are compared the same way as for
+// WithChecksumColumn. If no key columns are passed the same columns as
+// SeedTable are used. Use WithResult to get the number of unchanged objects.
+//
+//  SELECT * FROM `tbl` WHERE (`key`) IN ((?), (?))
+func WithSkipUnchanged(keyColumns ...string) Option {
+	return func(o *options) {
+		o.skipUnchanged = true
+		o.diffKeys = keyColumns
+	}
+}
+
+// changedObjects returns the objects, and their rows, which differ from the
+// existing rows in the database.
+func (o *options) changedObjects(db *gorm.DB, objects []interface{}, rows []int) ([]interface{}, []int, error) {
+	if !o.skipUnchanged || len(objects) < 1 {
+		return objects, rows, nil
+	}
+
+	objectFields := make([]map[string]*gorm.Field, len(objects))
+
+	for i := range objects {
+		fields, err := o.objectToMap(objects[i])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		objectFields[i] = fields
+	}
+
+	keys := o.diffKeys
+	if len(keys) == 0 {
+		if keys = seedKeys(objectFields[0]); len(keys) == 0 {
+			return nil, nil, ErrMissingSyncKeys
+		}
+	}
+
+	existing, err := o.existingRows(db, objects, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		changedObjects = make([]interface{}, 0, len(objects))
+		changedRows    = make([]int, 0, len(rows))
+		unchanged      int
+	)
+
+	for i, object := range objects {
+		changed, err := o.isChanged(objectFields[i], keys, existing)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !changed {
+			unchanged++
+			continue
+		}
+
+		changedObjects = append(changedObjects, object)
+		changedRows = append(changedRows, rows[i])
+	}
+
+	if o.result != nil {
+		o.result.Unchanged += unchanged
+	}
+
+	return changedObjects, changedRows, nil
+}
+
+// existingRows selects the rows with the same keys as the objects and returns
+// the fields of each row by their key.
+func (o *options) existingRows(db *gorm.DB, objects []interface{}, keys []string) (map[string]map[string]*gorm.Field, error) {
+	scope := db.NewScope(objects[0])
+
+	in, vars, err := o.keysCondition(scope, objects, keys, "IN")
+	if err != nil {
+		return nil, err
+	}
+
+	modelType, err := structType(objects[0])
+	if err != nil {
+		return nil, err
+	}
+
+	sqlRows, err := o.queryRows(
+		db,
+		fmt.Sprintf("SELECT * FROM %s WHERE %s", scope.Quote(o.scopeTableName(scope)), in),
+		vars...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string]map[string]*gorm.Field{}
+
+	// No rows are returned when using WithExecutor so all objects are
+	// considered changed.
+	if sqlRows == nil {
+		return existing, nil
+	}
+
+	defer sqlRows.Close()
+
+	for sqlRows.Next() {
+		row := reflect.New(modelType).Interface()
+
+		if err := db.ScanRows(sqlRows, row); err != nil {
+			return nil, err
+		}
+
+		fields := map[string]*gorm.Field{}
+		for _, field := range db.NewScope(row).Fields() {
+			fields[o.columnName(field.StructField)] = field
+		}
+
+		key, err := o.rowKey(fields, keys)
+		if err != nil {
+			return nil, err
+		}
+
+		existing[key] = fields
+	}
+
+	return existing, sqlRows.Err()
+}
+
+// isChanged returns true if there's no existing row with the same key as the
+// object or if any field differs.
+func (o *options) isChanged(fields map[string]*gorm.Field, keys []string, existing map[string]map[string]*gorm.Field) (bool, error) {
+	key, err := o.rowKey(fields, keys)
+	if err != nil {
+		return false, err
+	}
+
+	existingFields, ok := existing[key]
+	if !ok {
+		return true, nil
+	}
+
+	checksum, err := o.checksumFields(fields, fields)
+	if err != nil {
+		return false, err
+	}
+
+	existingChecksum, err := o.checksumFields(fields, existingFields)
+	if err != nil {
+		return false, err
+	}
+
+	return checksum != existingChecksum, nil
+}
+
+// rowKey returns a string representation of the key columns of a row.
+func (o *options) rowKey(fields map[string]*gorm.Field, keys []string) (string, error) {
+	values := make([]string, len(keys))
+
+	for i, key := range keys {
+		value, err := o.fieldChecksumValue(fields[key])
+		if err != nil {
+			return "", err
+		}
+
+		values[i] = value
+	}
+
+	return strings.Join(values, "\x00"), nil
+}
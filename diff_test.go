@@ -0,0 +1,170 @@
+package gormbulk
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithSkipUnchanged(t *testing.T) {
+	type user struct {
+		Email string `gorm:"unique"`
+		Name  string
+		Age   int
+	}
+
+	objects := []interface{}{
+		user{Email: "a@example.com", Name: "a", Age: 1},
+		user{Email: "b@example.com", Name: "b", Age: 2},
+		user{Email: "c@example.com", Name: "c", Age: 3},
+	}
+
+	cases := []struct {
+		description       string
+		options           []Option
+		existing          *sqlmock.Rows
+		expectedMockFunc  func(mock sqlmock.Sqlmock)
+		expectedUnchanged int
+	}{
+		{
+			description: "unchanged objects left out",
+			existing: sqlmock.NewRows([]string{"email", "name", "age"}).
+				AddRow("a@example.com", "a", 1).
+				AddRow("b@example.com", "b", 20),
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `users`").
+					WithArgs(2, "b@example.com", "b", 3, "c@example.com", "c").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+			expectedUnchanged: 1,
+		},
+		{
+			description: "nothing executed when all unchanged",
+			options:     []Option{WithSkipUnchanged("email", "name")},
+			existing: sqlmock.NewRows([]string{"email", "name", "age"}).
+				AddRow("a@example.com", "a", 1).
+				AddRow("b@example.com", "b", 2).
+				AddRow("c@example.com", "c", 3),
+			expectedMockFunc:  func(mock sqlmock.Sqlmock) {},
+			expectedUnchanged: 3,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			options := tc.options
+			if options == nil {
+				options = []Option{WithSkipUnchanged()}
+			}
+
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE (")).
+				WillReturnRows(tc.existing)
+
+			tc.expectedMockFunc(mock)
+
+			result := &Result{}
+
+			require.NoError(t, BulkInsertOnDuplicateKeyUpdate(gdb, objects, append(options, WithResult(result))...))
+			require.NoError(t, mock.ExpectationsWereMet())
+
+			assert.Equal(t, tc.expectedUnchanged, result.Unchanged)
+		})
+	}
+}
+
+func TestWithSkipUnchangedShards(t *testing.T) {
+	type user struct {
+		Email string `gorm:"unique"`
+		Name  string
+	}
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	objects := []interface{}{
+		user{Email: "a@example.com", Name: "a"},
+		user{Email: "b@example.com", Name: "b"},
+	}
+
+	shardFunc := func(object interface{}) string {
+		return "users_" + object.(user).Name
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users_a` WHERE (")).
+		WillReturnRows(sqlmock.NewRows([]string{"email", "name"}).AddRow("a@example.com", "a"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users_b` WHERE (")).
+		WillReturnRows(sqlmock.NewRows([]string{"email", "name"}))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users_b`")).
+		WithArgs("b@example.com", "b").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result := &Result{}
+
+	require.NoError(t, BulkInsertOnDuplicateKeyUpdate(
+		gdb,
+		objects,
+		WithSkipUnchanged(),
+		WithShardFunc(shardFunc),
+		WithResult(result),
+	))
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, 1, result.Unchanged)
+}
+
+func TestWithSkipUnchangedAsync(t *testing.T) {
+	type user struct {
+		Email string `gorm:"unique"`
+		Name  string
+	}
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	objects := []interface{}{
+		user{Email: "a@example.com", Name: "a"},
+		user{Email: "b@example.com", Name: "b"},
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE (")).
+		WillReturnRows(sqlmock.NewRows([]string{"email", "name"}).AddRow("a@example.com", "a"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE (")).
+		WillReturnRows(sqlmock.NewRows([]string{"email", "name"}).AddRow("b@example.com", "b"))
+
+	result := &Result{}
+
+	results := BulkExecAsync(
+		context.Background(),
+		gdb,
+		objects,
+		InsertOnDuplicateKeyUpdateFunc,
+		WithChunkSize(1),
+		WithSkipUnchanged(),
+		WithResult(result),
+	)
+
+	for res := range results {
+		require.NoError(t, res.Err)
+	}
+
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, 2, result.Unchanged)
+}
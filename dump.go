@@ -0,0 +1,226 @@
+package gormbulk
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// BulkDump will build the same SQL as BulkExec but instead of executing it,
+// the statement is written to w with all values inlined as escaped literals
+// and terminated with a semicolon. This makes it possible to save bulk
+// statements as migration or seed files or to review them offline. Only the
+// bulk statement itself is written, options executing additional statements
+// such as WithAuditTable and SyncDeleteMissing are ignored. With WithShardFunc
+// one statement is written per table.
+func BulkDump(db *gorm.DB, w io.Writer, objects []interface{}, execFunc ExecFunc, opts ...Option) error {
+	o := newModelOptions(firstObject(objects), opts...)
+
+	objects, rows, err := o.validate(objects)
+	if err != nil {
+		return err
+	}
+
+	shards := []*shard{{objects: objects, rows: rows}}
+	if o.shardFunc != nil {
+		shards = o.shards(objects, rows)
+	}
+
+	for _, s := range shards {
+		shardDB := db
+		if s.table != "" {
+			shardDB = db.Table(s.table)
+		}
+
+		scope, err := scopeFromObjects(shardDB, s.objects, s.rows, execFunc.toV2(), o)
+		if err != nil {
+			return err
+		}
+
+		if scope == nil {
+			continue
+		}
+
+		sql, err := literalSQL(o.dialect, scope.SQL, scope.SQLVars)
+		if err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(w, "%s;\n", sql); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// literalSQL replaces every placeholder in the SQL with the corresponding var
+// formatted as a literal. Question marks inside quoted strings, identifiers
+// and comments are not considered placeholders.
+func literalSQL(dialect, sql string, vars []interface{}) (string, error) {
+	var (
+		b     strings.Builder
+		quote byte
+		i     int
+	)
+
+	for n := 0; n < len(sql); n++ {
+		c := sql[n]
+
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case commentLen(sql[n:]) > 0:
+			end := n + commentLen(sql[n:])
+
+			b.WriteString(sql[n:end])
+			n = end - 1
+
+			continue
+		case c == '?':
+			if i >= len(vars) {
+				return "", errors.New("more placeholders than values")
+			}
+
+			literal, err := sqlLiteral(dialect, vars[i])
+			if err != nil {
+				return "", err
+			}
+
+			b.WriteString(literal)
+			i++
+
+			continue
+		}
+
+		b.WriteByte(c)
+	}
+
+	if i != len(vars) {
+		return "", errors.New("more values than placeholders")
+	}
+
+	return b.String(), nil
+}
+
+// commentLen returns the length of the comment at the start of the SQL, if
+// any. Both block comments and comments running to the end of the line are
+// supported. An unterminated comment runs to the end of the SQL.
+func commentLen(sql string) int {
+	var terminator string
+
+	switch {
+	case strings.HasPrefix(sql, "/*"):
+		terminator = "*/"
+	case strings.HasPrefix(sql, "--"):
+		terminator = "\n"
+	default:
+		return 0
+	}
+
+	end := strings.Index(sql[2:], terminator)
+	if end < 0 {
+		return len(sql)
+	}
+
+	return 2 + end + len(terminator)
+}
+
+// sqlLiteral formats the value as a SQL literal for the dialect. Slices other
+// than []byte are formatted as a comma separated list, the same way as gorm
+// expands them when binding.
+func sqlLiteral(dialect string, value interface{}) (string, error) {
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+
+		return sqlLiteral(dialect, v)
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return quoteLiteral(dialect, v), nil
+	case []byte:
+		if dialect == "postgres" {
+			return fmt.Sprintf(`'\x%s'`, hex.EncodeToString(v)), nil
+		}
+
+		return fmt.Sprintf("X'%s'", hex.EncodeToString(v)), nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+
+		return "FALSE", nil
+	case time.Time:
+		layout := "2006-01-02 15:04:05.999999"
+		if dialect == "postgres" {
+			layout = "2006-01-02 15:04:05.999999-07:00"
+		}
+
+		return quoteLiteral(dialect, v.Format(layout)), nil
+	}
+
+	rv := reflect.ValueOf(value)
+
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return "NULL", nil
+		}
+
+		return sqlLiteral(dialect, rv.Elem().Interface())
+	case reflect.String:
+		return quoteLiteral(dialect, rv.String()), nil
+	case reflect.Bool:
+		return sqlLiteral(dialect, rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, rv.Type().Bits()), nil
+	case reflect.Slice, reflect.Array:
+		literals := make([]string, rv.Len())
+
+		for i := range literals {
+			literal, err := sqlLiteral(dialect, rv.Index(i).Interface())
+			if err != nil {
+				return "", err
+			}
+
+			literals[i] = literal
+		}
+
+		return strings.Join(literals, ", "), nil
+	}
+
+	return "", fmt.Errorf("could not format value of type %T as SQL literal", value)
+}
+
+// quoteLiteral quotes the string as a SQL string literal. Single quotes are
+// doubled and for MySQL, where backslash is an escape character by default,
+// backslashes are escaped as well.
+func quoteLiteral(dialect, s string) string {
+	if dialect == "mysql" {
+		s = strings.Replace(s, `\`, `\\`, -1)
+	}
+
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
@@ -0,0 +1,145 @@
+package gormbulk
+
+import (
+	"bytes"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkDump(t *testing.T) {
+	type seed struct {
+		Name      string
+		Count     int
+		Enabled   bool
+		Note      sql.NullString
+		CreatedAt time.Time
+	}
+
+	createdAt := time.Date(2019, 11, 1, 12, 0, 0, 0, time.UTC)
+
+	objects := []interface{}{
+		seed{Name: "it's", Count: 1, Enabled: true, CreatedAt: createdAt},
+		seed{Name: `back\slash?`, Count: 2, Note: sql.NullString{String: "note", Valid: true}, CreatedAt: createdAt},
+	}
+
+	cases := []struct {
+		description string
+		dialect     string
+		execFunc    ExecFunc
+		options     []Option
+		expectedSQL string
+	}{
+		{
+			description: "mysql insert",
+			dialect:     "mysql",
+			execFunc:    InsertFunc,
+			expectedSQL: "INSERT INTO `seeds` (`count`, `created_at`, `enabled`, `name`, `note`) VALUES " +
+				"(1, '2019-11-01 12:00:00', TRUE, 'it''s', NULL), " +
+				`(2, '2019-11-01 12:00:00', FALSE, 'back\\slash?', 'note');` + "\n",
+		},
+		{
+			description: "postgres insert",
+			dialect:     "postgres",
+			execFunc:    InsertFunc,
+			expectedSQL: `INSERT INTO "seeds" ("count", "created_at", "enabled", "name", "note") VALUES ` +
+				`(1, '2019-11-01 12:00:00+00:00', TRUE, 'it''s', NULL), ` +
+				`(2, '2019-11-01 12:00:00+00:00', FALSE, 'back\slash?', 'note');` + "\n",
+		},
+		{
+			description: "one statement per shard",
+			dialect:     "mysql",
+			execFunc:    InsertFunc,
+			options: []Option{
+				WithShardFunc(func(object interface{}) string {
+					return "seeds_" + object.(seed).Name[:1]
+				}),
+			},
+			expectedSQL: "INSERT INTO `seeds_i` (`count`, `created_at`, `enabled`, `name`, `note`) VALUES " +
+				"(1, '2019-11-01 12:00:00', TRUE, 'it''s', NULL);\n" +
+				"INSERT INTO `seeds_b` (`count`, `created_at`, `enabled`, `name`, `note`) VALUES " +
+				`(2, '2019-11-01 12:00:00', FALSE, 'back\\slash?', 'note');` + "\n",
+		},
+		{
+			description: "comment with apostrophe",
+			dialect:     "mysql",
+			execFunc:    InsertFunc,
+			options:     []Option{WithComment("team's import")},
+			expectedSQL: "/* team's import */ INSERT INTO `seeds` (`count`, `created_at`, `enabled`, `name`, `note`) VALUES " +
+				"(1, '2019-11-01 12:00:00', TRUE, 'it''s', NULL), " +
+				`(2, '2019-11-01 12:00:00', FALSE, 'back\\slash?', 'note');` + "\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open(tc.dialect, db)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+
+			require.NoError(t, BulkDump(gdb, &buf, objects, tc.execFunc, tc.options...))
+			assert.Equal(t, tc.expectedSQL, buf.String())
+
+			// Nothing should be executed.
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_literalSQL(t *testing.T) {
+	cases := []struct {
+		sql      string
+		vars     []interface{}
+		expected string
+	}{
+		{sql: "SELECT ?", vars: []interface{}{1}, expected: "SELECT 1"},
+		{sql: "/* it's? */ SELECT ?", vars: []interface{}{1}, expected: "/* it's? */ SELECT 1"},
+		{sql: "-- it's?\nSELECT ?", vars: []interface{}{1}, expected: "-- it's?\nSELECT 1"},
+		{sql: "SELECT ? -- it's?", vars: []interface{}{1}, expected: "SELECT 1 -- it's?"},
+		{sql: "SELECT '/* ?', ?", vars: []interface{}{1}, expected: "SELECT '/* ?', 1"},
+		{sql: "SELECT ? /* ? */", vars: []interface{}{"a"}, expected: "SELECT 'a' /* ? */"},
+	}
+
+	for _, tc := range cases {
+		sql, err := literalSQL("mysql", tc.sql, tc.vars)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, sql)
+	}
+}
+
+func Test_sqlLiteral(t *testing.T) {
+	var nilString *string
+
+	cases := []struct {
+		value    interface{}
+		dialect  string
+		expected string
+	}{
+		{value: nil, expected: "NULL"},
+		{value: nilString, expected: "NULL"},
+		{value: uint8(3), expected: "3"},
+		{value: 1.5, expected: "1.5"},
+		{value: []int{1, 2}, expected: "1, 2"},
+		{value: []byte("ab"), dialect: "mysql", expected: "X'6162'"},
+		{value: []byte("ab"), dialect: "postgres", expected: `'\x6162'`},
+		{value: `a\b`, dialect: "postgres", expected: `'a\b'`},
+	}
+
+	for _, tc := range cases {
+		literal, err := sqlLiteral(tc.dialect, tc.value)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, literal)
+	}
+
+	_, err := sqlLiteral("mysql", struct{}{})
+	assert.Error(t, err)
+}
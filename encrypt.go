@@ -0,0 +1,88 @@
+package gormbulk
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Encrypter encrypts values for fields tagged with `bulk:"encrypt"`. The
+// column is the name of the column the ciphertext is written to.
+type Encrypter interface {
+	Encrypt(column string, plaintext []byte) ([]byte, error)
+}
+
+// EncrypterFunc is a function implementing Encrypter.
+type EncrypterFunc func(column string, plaintext []byte) ([]byte, error)
+
+// Encrypt implements Encrypter.
+func (fn EncrypterFunc) Encrypt(column string, plaintext []byte) ([]byte, error) {
+	return fn(column, plaintext)
+}
+
+// WithEncrypter sets the Encrypter used for fields tagged with
+// `bulk:"encrypt"`. The value is encrypted after all other conversions and
+// bound as the ciphertext. To write the ciphertext to another column than the
+// field, name the column in the tag, i.e. `bulk:"encrypt:ssn_encrypted"`. NULL
+// values are not encrypted. Executing objects with encrypted fields without an
+// Encrypter returns an error.
+func WithEncrypter(encrypter Encrypter) Option {
+	return func(o *options) {
+		o.encrypter = encrypter
+	}
+}
+
+// encryptColumn returns true if the field is tagged with `bulk:"encrypt"` and
+// the column the ciphertext is written to, which is the column of the field
+// unless set in the tag.
+func encryptColumn(field *gorm.StructField) (string, bool) {
+	column, ok := bulkTagSettings(field)["ENCRYPT"]
+	if !ok {
+		return "", false
+	}
+
+	if column == "ENCRYPT" {
+		return field.DBName, true
+	}
+
+	return column, true
+}
+
+// encrypt encrypts the value if the field is tagged to be encrypted.
+func (o *options) encrypt(column string, field *gorm.Field, value interface{}) (interface{}, error) {
+	if _, ok := encryptColumn(field.StructField); !ok || isNull(value) {
+		return value, nil
+	}
+
+	if o.encrypter == nil {
+		return nil, fmt.Errorf("column %s must be encrypted but no encrypter is set", column)
+	}
+
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return nil, err
+		}
+
+		value = v
+	}
+
+	var plaintext []byte
+
+	switch v := value.(type) {
+	case []byte:
+		plaintext = v
+	case string:
+		plaintext = []byte(v)
+	default:
+		plaintext = []byte(fmt.Sprint(v))
+	}
+
+	ciphertext, err := o.encrypter.Encrypt(column, plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("could not encrypt column %s: %v", column, err)
+	}
+
+	return ciphertext, nil
+}
@@ -0,0 +1,83 @@
+package gormbulk
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithEncrypter(t *testing.T) {
+	type user struct {
+		Name  string
+		Email *string `bulk:"encrypt"`
+		SSN   string  `bulk:"encrypt:ssn_encrypted"`
+	}
+
+	email := "a@example.com"
+
+	reverse := EncrypterFunc(func(column string, plaintext []byte) ([]byte, error) {
+		ciphertext := []byte(column + ":")
+		for i := len(plaintext) - 1; i >= 0; i-- {
+			ciphertext = append(ciphertext, plaintext[i])
+		}
+
+		return ciphertext, nil
+	})
+
+	cases := []struct {
+		description      string
+		objects          []interface{}
+		options          []Option
+		expectedMockFunc func(mock sqlmock.Sqlmock)
+		expectedErr      error
+	}{
+		{
+			description: "tagged fields encrypted",
+			objects:     []interface{}{user{Name: "a", Email: &email, SSN: "123"}, user{Name: "b", SSN: "456"}},
+			options:     []Option{WithEncrypter(reverse)},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `users` \\(`email`, `name`, `ssn_encrypted`\\)").
+					WithArgs(
+						[]byte("email:moc.elpmaxe@a"), "a", []byte("ssn_encrypted:321"),
+						nil, "b", []byte("ssn_encrypted:654"),
+					).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+		},
+		{
+			description:      "error without encrypter",
+			objects:          []interface{}{user{Name: "a", SSN: "123"}},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {},
+			expectedErr:      errors.New("column ssn_encrypted must be encrypted but no encrypter is set"),
+		},
+		{
+			description: "encrypter errors returned",
+			objects:     []interface{}{user{Name: "a", SSN: "123"}},
+			options: []Option{WithEncrypter(EncrypterFunc(func(_ string, _ []byte) ([]byte, error) {
+				return nil, errors.New("no key")
+			}))},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {},
+			expectedErr:      errors.New("could not encrypt column ssn_encrypted: no key"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			tc.expectedMockFunc(mock)
+
+			err = BulkInsert(gdb, tc.objects, tc.options...)
+			assert.Equal(t, tc.expectedErr, err)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
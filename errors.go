@@ -0,0 +1,217 @@
+package gormbulk
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ColumnSizeError is returned (or passed to the truncate callback) when a
+// string value exceeds the size of the column.
+type ColumnSizeError struct {
+	Row    int
+	Column string
+	Size   int
+	Length int
+}
+
+// Error implements the error interface.
+func (e *ColumnSizeError) Error() string {
+	return fmt.Sprintf(
+		"value for column %s in row %d is %d characters, column size is %d",
+		e.Column, e.Row, e.Length, e.Size,
+	)
+}
+
+// CoercionError is returned when a value can't be converted to the column
+// type, see WithTypeCoercion.
+type CoercionError struct {
+	Row    int
+	Column string
+	Type   string
+	Value  interface{}
+	Err    error
+}
+
+// Error implements the error interface.
+func (e *CoercionError) Error() string {
+	return fmt.Sprintf(
+		"value %v for column %s in row %d can't be converted to %s: %s",
+		e.Value, e.Column, e.Row, e.Type, e.Err.Error(),
+	)
+}
+
+// ReservedWordError is returned (or passed to the callback) when a table or
+// column name is a reserved word in the dialect, see WithReservedWordCheck.
+type ReservedWordError struct {
+	Dialect    string
+	Identifier string
+}
+
+// Error implements the error interface.
+func (e *ReservedWordError) Error() string {
+	return fmt.Sprintf("%s is a reserved word in %s", e.Identifier, e.Dialect)
+}
+
+// ChunkSizeError is returned when the chunk size passed to BulkExecChunk is
+// not positive.
+type ChunkSizeError struct {
+	Size int
+}
+
+// Error implements the error interface.
+func (e *ChunkSizeError) Error() string {
+	return fmt.Sprintf("chunk size must be greater than zero, got %d", e.Size)
+}
+
+// NotAttemptedError is returned when chunks weren't started because the
+// context was done or the deadline would be exceeded (see WithContext). Rows
+// holds the index of all objects not attempted.
+type NotAttemptedError struct {
+	Rows []int
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *NotAttemptedError) Error() string {
+	return fmt.Sprintf("%d rows not attempted: %s", len(e.Rows), e.Err.Error())
+}
+
+// MultiStatementError is returned when a round trip with multiple statements
+// fails, see WithMultiStatement. Rows holds the index of all objects in the
+// trip. Depending on the driver, statements before the failing one may already
+// be executed.
+type MultiStatementError struct {
+	Statements int
+	Rows       []int
+	Err        error
+}
+
+// Error implements the error interface.
+func (e *MultiStatementError) Error() string {
+	return fmt.Sprintf(
+		"%d statements with %d rows failed: %s",
+		e.Statements, len(e.Rows), e.Err.Error(),
+	)
+}
+
+// ExecError is returned when executing a bulk statement fails and using
+// ErrorsWithContext or ErrorsWithSQL, see WithErrorVerbosity. Rows holds the
+// index of all objects in the statement. SQL is a truncated excerpt of the
+// statement and Vars the number of vars bound, only set with ErrorsWithSQL.
+type ExecError struct {
+	Table string
+	Chunk int
+	Rows  []int
+	SQL   string
+	Vars  int
+	Err   error
+}
+
+// Error implements the error interface.
+func (e *ExecError) Error() string {
+	msg := fmt.Sprintf("table %s chunk %d", e.Table, e.Chunk)
+
+	if len(e.Rows) > 0 {
+		msg = fmt.Sprintf("%s rows %d-%d", msg, e.Rows[0], e.Rows[len(e.Rows)-1])
+	}
+
+	msg = fmt.Sprintf("%s: %s", msg, e.Err.Error())
+
+	if e.SQL != "" {
+		msg = fmt.Sprintf("%s (SQL: %s, %d vars)", msg, e.SQL, e.Vars)
+	}
+
+	return msg
+}
+
+// DeadLetterError is returned when the objects failing with Err couldn't be
+// written to the dead letter table, see WithDeadLetterTable.
+type DeadLetterError struct {
+	Err           error
+	DeadLetterErr error
+}
+
+// Error implements the error interface.
+func (e *DeadLetterError) Error() string {
+	return fmt.Sprintf("%s (writing dead letters failed: %s)", e.Err.Error(), e.DeadLetterErr.Error())
+}
+
+// IDBackfillError is returned when the IDs generated for a statement can't be
+// backfilled to the objects, see WithIDBackfill. Rows holds the index of each
+// object in the statement. The statement is never retried.
+type IDBackfillError struct {
+	Rows []int
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *IDBackfillError) Error() string {
+	return fmt.Sprintf("could not backfill IDs: %s", e.Err.Error())
+}
+
+// HealthCheckError is returned when the health probe fails before starting a
+// bulk operation, see WithHealthProbe. Err is the error from pinging the
+// database or ErrPoolSaturated. Free holds the number of free connections in
+// the pool, -1 if the ping failed.
+type HealthCheckError struct {
+	Free int
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *HealthCheckError) Error() string {
+	if e.Free < 0 {
+		return fmt.Sprintf("health check failed: %s", e.Err.Error())
+	}
+
+	return fmt.Sprintf("health check failed with %d free connections: %s", e.Free, e.Err.Error())
+}
+
+// UnflushedError is returned when closing a BulkWriter before all buffered
+// objects were flushed. Objects holds the objects never executed.
+type UnflushedError struct {
+	Objects []interface{}
+	Err     error
+}
+
+// Error implements the error interface.
+func (e *UnflushedError) Error() string {
+	return fmt.Sprintf("%d objects not flushed: %s", len(e.Objects), e.Err.Error())
+}
+
+// RowError is an error for a single object (row) in the slice passed to the
+// bulk function.
+type RowError struct {
+	Row int
+	Err error
+}
+
+// Error implements the error interface.
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %s", e.Row, e.Err.Error())
+}
+
+// LineError is an error for a single line read from a reader, i.e. with
+// BulkInsertJSONLines. The line is 1-indexed.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err.Error())
+}
+
+// ValidationErrors is returned when one or more objects failed validation.
+type ValidationErrors []*RowError
+
+// Error implements the error interface.
+func (e ValidationErrors) Error() string {
+	errs := make([]string, len(e))
+	for i := range e {
+		errs[i] = e[i].Error()
+	}
+
+	return fmt.Sprintf("%d objects failed validation: %s", len(e), strings.Join(errs, ", "))
+}
@@ -57,10 +57,13 @@ func UpdateCustomFunc(db *gorm.DB, myTypes []MyType) error {
 			newPlaceholders = append(newPlaceholders, fmt.Sprintf("(?)"))
 		}
 
+		// Quote identifiers with the scope to support all dialects.
 		// This is not SQL string formatting
 		// nolint: gosec
 		scope.Raw(fmt.Sprintf(
-			"INSERT INTO `mytype` (field1) VALUES %s",
+			"INSERT INTO %s (%s) VALUES %s",
+			scope.QuotedTableName(),
+			scope.Quote("field1"),
 			strings.Join(newPlaceholders, ", "),
 		))
 
@@ -0,0 +1,30 @@
+package examples
+
+//go:generate bulk-gen --keyword GORM-BULK
+
+import (
+	"time"
+
+	gormbulk "github.com/bombsimon/gorm-bulk"
+	"github.com/jinzhu/gorm"
+)
+
+// Event represents some kind of database model. This struct has a tag set to
+// support automatic code generation of typed bulk wrappers and column
+// constants.
+// GORM-BULK
+type Event struct {
+	Name       string `gorm:"unique"`
+	Payload    string `gorm:"column:data;type:json"`
+	OccurredAt time.Time
+}
+
+// SaveEvents will upsert all the events with the generated typed wrapper. The
+// column constants may be used wherever a column name is expected.
+func SaveEvents(db *gorm.DB, events []Event) error {
+	return BulkInsertOnDuplicateKeyUpdateEvents(
+		db,
+		events,
+		gormbulk.WithEmptyStringAsNull(EventColumnPayload),
+	)
+}
@@ -2,13 +2,159 @@ package gormbulk
 
 import (
 	"fmt"
+	"net/url"
+	"sort"
 	"strings"
 
 	"github.com/jinzhu/gorm"
 )
 
+// nullColumnsKey is the scope setting holding the quoted column names where
+// every row is NULL, set when using WithSkipNullUpdates.
+const nullColumnsKey = "gormbulk:null_columns"
+
+// versionColumnKey is the scope setting holding the quoted name of the version
+// column used for optimistic locking.
+const versionColumnKey = "gormbulk:version_column"
+
+// checksumColumnKey is the scope setting holding the quoted name of the
+// checksum column set with WithChecksumColumn.
+const checksumColumnKey = "gormbulk:checksum_column"
+
+// excludedColumnsKey is the scope setting holding the quoted names of the
+// columns never updated for existing rows, which is the CreatedAt field (see
+// WithCreatedAtPolicy) and the columns set with WithUpdateExcludedColumns.
+const excludedColumnsKey = "gormbulk:excluded_columns"
+
+// insertOptionVarsKey is the scope setting holding the args for the
+// placeholders in the insert option set with WithInsertOption.
+const insertOptionVarsKey = "gormbulk:insert_option_vars"
+
+// ExecFunc is used to create the final SQL. The scope holds all the vars in
+// scope.SQLVars and the SQL should be set with scope.Raw.
 type ExecFunc func(scope *gorm.Scope, columnNames, groups []string)
 
+// ExecFuncV2 is used to create the final SQL. Unlike ExecFunc it gets an
+// ExecContext exposing the objects, per row vars and column metadata.
+type ExecFuncV2 func(ctx *ExecContext)
+
+// ExecContext holds everything used to build the bulk statement.
+type ExecContext struct {
+	// Scope holds all the vars in Scope.SQLVars. The final SQL should be set
+	// with Scope.Raw.
+	Scope *gorm.Scope
+
+	// Objects are the objects (rows) in the statement.
+	Objects []interface{}
+
+	// Columns holds metadata for each column, in the same order as
+	// QuotedColumnNames and the vars for each row.
+	Columns []Column
+
+	// QuotedColumnNames are the quoted names of all columns.
+	QuotedColumnNames []string
+
+	// Groups holds the placeholder group for each row, i.e. `(?, ?)`.
+	Groups []string
+
+	// RowVars holds the vars for each row. All RowVars together are the same
+	// as the vars in Scope.SQLVars. The vars for a row are in column order
+	// but a column may bind no vars (i.e. DEFAULT) or several vars (i.e. a
+	// gorm.Expr), use VarIndexes to find the vars for a column.
+	RowVars [][]interface{}
+
+	// Placeholders holds the placeholder for each row and column, i.e. `?`
+	// or `ST_GeomFromText(?)`. The placeholders for a row joined together are
+	// the same as the group for the row.
+	Placeholders [][]string
+
+	// VarIndexes holds the indexes in RowVars for each row and column, i.e.
+	// VarIndexes[row][column] are the indexes of the vars bound by the
+	// placeholder in Placeholders[row][column].
+	VarIndexes [][][]int
+
+	// Dialect is the dialect of the database.
+	Dialect gorm.Dialect
+
+	varsBefore []interface{}
+	varsAfter  []interface{}
+}
+
+// AddVarsBefore adds statement level vars bound before the vars for all rows,
+// i.e. for placeholders in a CTE before the VALUES. Vars from multiple calls
+// are bound in the order they're added.
+func (ctx *ExecContext) AddVarsBefore(vars ...interface{}) {
+	ctx.varsBefore = append(ctx.varsBefore, vars...)
+}
+
+// AddVarsAfter adds statement level vars bound after the vars for all rows,
+// i.e. for placeholders in a SET expression or WHERE clause after the VALUES.
+// Vars from multiple calls are bound in the order they're added.
+func (ctx *ExecContext) AddVarsAfter(vars ...interface{}) {
+	ctx.varsAfter = append(ctx.varsAfter, vars...)
+}
+
+// bindVars adds the statement level vars to the scope vars set by the
+// ExecFuncV2.
+func (ctx *ExecContext) bindVars() {
+	if len(ctx.varsBefore) == 0 && len(ctx.varsAfter) == 0 {
+		return
+	}
+
+	vars := make([]interface{}, 0, len(ctx.varsBefore)+len(ctx.Scope.SQLVars)+len(ctx.varsAfter))
+	vars = append(vars, ctx.varsBefore...)
+	vars = append(vars, ctx.Scope.SQLVars...)
+	vars = append(vars, ctx.varsAfter...)
+
+	ctx.Scope.SQLVars = vars
+}
+
+// Column holds metadata about a column in the bulk statement.
+type Column struct {
+	Name       string
+	QuotedName string
+	Field      *gorm.StructField
+}
+
+// SelectColumns returns the quoted column names, groups and vars for the
+// columns at the passed indexes, in the order passed. This makes it possible
+// to reorder or leave out columns in an ExecFuncV2 without breaking the order
+// of the vars. The vars are not added to the scope.
+func (ctx *ExecContext) SelectColumns(indexes ...int) ([]string, []string, []interface{}) {
+	var (
+		quotedColumnNames = make([]string, len(indexes))
+		groups            = make([]string, len(ctx.Placeholders))
+		vars              []interface{}
+	)
+
+	for i, column := range indexes {
+		quotedColumnNames[i] = ctx.QuotedColumnNames[column]
+	}
+
+	for row := range ctx.Placeholders {
+		placeholders := make([]string, len(indexes))
+
+		for i, column := range indexes {
+			placeholders[i] = ctx.Placeholders[row][column]
+
+			for _, varIndex := range ctx.VarIndexes[row][column] {
+				vars = append(vars, ctx.RowVars[row][varIndex])
+			}
+		}
+
+		groups[row] = fmt.Sprintf("(%s)", strings.Join(placeholders, ", "))
+	}
+
+	return quotedColumnNames, groups, vars
+}
+
+// toV2 returns the ExecFunc wrapped as an ExecFuncV2.
+func (fn ExecFunc) toV2() ExecFuncV2 {
+	return func(ctx *ExecContext) {
+		fn(ctx.Scope, ctx.QuotedColumnNames, ctx.Groups)
+	}
+}
+
 // InsertFunc is the default insert func. It will pass a gorm.Scope pointer
 // which holds all the vars in scope.SQLVars. The value set to scope.SQL
 // will be used as SQL and the variables in scope.SQLVars will be used as
@@ -43,21 +189,120 @@ func InsertIgnoreFunc(scope *gorm.Scope, columnNames, groups []string) {
 //  ON DUPLICATE KEY UPDATE
 //    col1 = VALUES(col1),
 //    col2 = VALUES(col2)
+//
+// If the model has a version column (see WithVersionColumn) the update will
+// only be applied if the inserted version is greater than the existing one.
+//
+//  ON DUPLICATE KEY UPDATE
+//    col1 = IF(VALUES(version) > version, VALUES(col1), col1),
+//    version = IF(VALUES(version) > version, VALUES(version), version)
+//
+// If a checksum column is set (see WithChecksumColumn) the update will only be
+// applied if the checksum differs.
 func InsertOnDuplicateKeyUpdateFunc(scope *gorm.Scope, columnNames, groups []string) {
-	var duplicateUpdates []string
+	// This is not SQL string formatting, prepare statements is in use.
+	// nolint: gosec
+	scope.Raw(fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES %s ON DUPLICATE KEY UPDATE %s",
+		scope.QuotedTableName(),
+		strings.Join(columnNames, ", "),
+		strings.Join(groups, ", "),
+		strings.Join(duplicateKeyUpdates(scope, columnNames), ", "),
+	))
+}
+
+// duplicateKeyUpdates returns the assignments for the ON DUPLICATE KEY UPDATE
+// clause for the columns, honoring the NULL and version column settings on the
+// scope. A no-op assignment is returned if no column should be updated.
+func duplicateKeyUpdates(scope *gorm.Scope, columnNames []string) []string {
+	var (
+		duplicateUpdates []string
+		nullColumns      = map[string]struct{}{}
+		compareColumn    string
+		condition        string
+	)
+
+	if columns, ok := scope.Get(nullColumnsKey); ok {
+		for _, column := range columns.([]string) {
+			nullColumns[column] = struct{}{}
+		}
+	}
+
+	// Only update rows with a changed checksum or, if not set, a greater
+	// version.
+	if column, ok := scope.Get(checksumColumnKey); ok {
+		compareColumn = column.(string)
+		condition = fmt.Sprintf("NOT (%s <=> VALUES(%s))", compareColumn, compareColumn)
+	} else if column, ok := scope.Get(versionColumnKey); ok {
+		compareColumn = column.(string)
+		condition = fmt.Sprintf("VALUES(%s) > %s", compareColumn, compareColumn)
+	}
+
+	updateValue := func(column string) string {
+		if condition == "" {
+			return fmt.Sprintf("%s = VALUES(%s)", column, column)
+		}
+
+		return fmt.Sprintf(
+			"%s = IF(%s, VALUES(%s), %s)",
+			column, condition, column, column,
+		)
+	}
+
+	excluded := excludedColumns(scope)
 
 	for i := range columnNames {
-		// Don't update created at on duplicate.
-		if columnNames[i] == "`created_at`" {
+		// Don't update created at or excluded columns on duplicate.
+		if _, ok := excluded[columnNames[i]]; ok {
 			continue
 		}
 
-		duplicateUpdates = append(
-			duplicateUpdates,
-			fmt.Sprintf("%s = VALUES(%s)", columnNames[i], columnNames[i]),
-		)
+		// Don't overwrite existing values with a column without values.
+		if _, ok := nullColumns[columnNames[i]]; ok {
+			continue
+		}
+
+		// The compared column must be updated last since the other columns
+		// compares against the existing value.
+		if columnNames[i] == compareColumn {
+			continue
+		}
+
+		duplicateUpdates = append(duplicateUpdates, updateValue(columnNames[i]))
+	}
+
+	if compareColumn != "" {
+		duplicateUpdates = append(duplicateUpdates, updateValue(compareColumn))
+	}
+
+	// An empty clause isn't valid SQL so assign the primary key to itself if
+	// there's nothing to update, leaving existing rows unchanged.
+	if len(duplicateUpdates) == 0 {
+		primaryKey := scope.PrimaryKey()
+		if primaryKey == "" {
+			primaryKey = "id"
+		}
+
+		column := scope.Quote(primaryKey)
+		duplicateUpdates = append(duplicateUpdates, fmt.Sprintf("%s = %s", column, column))
 	}
 
+	return duplicateUpdates
+}
+
+// InsertOnDuplicateKeyUpdateCoalesceFunc works like
+// InsertOnDuplicateKeyUpdateFunc but keeps the existing value when the
+// inserted value is NULL. This ensures partial records won't wipe existing
+// data.
+//
+//  INSERT INTO `tbl`
+//    (col1, col2)
+//  VALUES
+//    (?, ?), (?, ?)
+//  ON DUPLICATE KEY UPDATE
+//    col1 = COALESCE(VALUES(col1), col1),
+//    col2 = COALESCE(VALUES(col2), col2)
+func InsertOnDuplicateKeyUpdateCoalesceFunc(scope *gorm.Scope, columnNames, groups []string) {
 	// This is not SQL string formatting, prepare statements is in use.
 	// nolint: gosec
 	scope.Raw(fmt.Sprintf(
@@ -65,7 +310,164 @@ func InsertOnDuplicateKeyUpdateFunc(scope *gorm.Scope, columnNames, groups []str
 		scope.QuotedTableName(),
 		strings.Join(columnNames, ", "),
 		strings.Join(groups, ", "),
-		strings.Join(duplicateUpdates, ", "),
+		strings.Join(coalesceUpdates(scope, columnNames, "%s = COALESCE(VALUES(%s), %s)", ""), ", "),
+	))
+}
+
+// InsertOnConflictCoalesceFunc returns an ExecFunc performing a postgres
+// upsert on the conflict columns which keeps the existing value when the
+// inserted value is NULL.
+//
+//  INSERT INTO "tbl"
+//    (col1, col2)
+//  VALUES
+//    (?, ?), (?, ?)
+//  ON CONFLICT (col1) DO UPDATE SET
+//    col1 = COALESCE(EXCLUDED.col1, "tbl".col1),
+//    col2 = COALESCE(EXCLUDED.col2, "tbl".col2)
+func InsertOnConflictCoalesceFunc(conflictColumns ...string) ExecFunc {
+	return func(scope *gorm.Scope, columnNames, groups []string) {
+		table := scope.QuotedTableName()
+
+		// This is not SQL string formatting, prepare statements is in use.
+		// nolint: gosec
+		scope.Raw(fmt.Sprintf(
+			"INSERT INTO %s (%s) VALUES %s ON CONFLICT (%s) DO UPDATE SET %s",
+			table,
+			strings.Join(columnNames, ", "),
+			strings.Join(groups, ", "),
+			strings.Join(quoteColumns(scope, conflictColumns), ", "),
+			strings.Join(coalesceUpdates(scope, columnNames, "%s = COALESCE(EXCLUDED.%s, %s)", table+"."), ", "),
+		))
+	}
+}
+
+// coalesceUpdates returns the assignments for each column except created at
+// and excluded columns formatted with the column name three times, the last
+// one prefixed with the qualifier.
+func coalesceUpdates(scope *gorm.Scope, columnNames []string, format, qualifier string) []string {
+	var (
+		updates  []string
+		excluded = excludedColumns(scope)
+	)
+
+	for _, column := range columnNames {
+		// Don't update created at or excluded columns on duplicate.
+		if _, ok := excluded[column]; ok {
+			continue
+		}
+
+		updates = append(updates, fmt.Sprintf(format, column, column, qualifier+column))
+	}
+
+	return updates
+}
+
+// excludedColumns returns the quoted names of the columns that are never
+// updated on duplicate key. If not set on the scope by the bulk functions,
+// i.e. when calling the ExecFunc directly, only created_at is excluded.
+func excludedColumns(scope *gorm.Scope) map[string]struct{} {
+	columns, ok := scope.Get(excludedColumnsKey)
+	if !ok {
+		return map[string]struct{}{
+			scope.Quote("created_at"): {},
+		}
+	}
+
+	excluded := map[string]struct{}{}
+
+	for _, column := range columns.([]string) {
+		excluded[column] = struct{}{}
+	}
+
+	return excluded
+}
+
+// Compose returns an ExecFunc calling all the passed ExecFuncs in order with
+// the same scope. This is used to add behavior to the bundled ExecFuncs.
+//
+//  Compose(InsertFunc, WithSuffix("RETURNING id"), WithPrefixComment("import"))
+func Compose(execFuncs ...ExecFunc) ExecFunc {
+	return func(scope *gorm.Scope, columnNames, groups []string) {
+		for _, fn := range execFuncs {
+			fn(scope, columnNames, groups)
+		}
+	}
+}
+
+// WithSuffix returns an ExecFunc appending the suffix to the SQL already set on
+// the scope. It should be used with Compose after the ExecFunc creating the
+// SQL.
+func WithSuffix(suffix string) ExecFunc {
+	return func(scope *gorm.Scope, _, _ []string) {
+		scope.Raw(fmt.Sprintf("%s %s", scope.SQL, suffix))
+	}
+}
+
+// WithPrefixComment returns an ExecFunc prepending the SQL already set on the
+// scope with a comment. It should be used with Compose after the ExecFunc
+// creating the SQL.
+//
+//  /* comment */ INSERT INTO ...
+func WithPrefixComment(comment string) ExecFunc {
+	return func(scope *gorm.Scope, _, _ []string) {
+		scope.Raw(fmt.Sprintf("%s %s", sqlComment(comment), scope.SQL))
+	}
+}
+
+// sqlCommentReplacer ensures a comment can't be ended prematurely and doesn't
+// contain any question marks which would be treated as placeholders.
+var sqlCommentReplacer = strings.NewReplacer("*/", "* /", "?", "")
+
+// sqlComment returns the text as a SQL comment.
+func sqlComment(text string) string {
+	return fmt.Sprintf("/* %s */", sqlCommentReplacer.Replace(text))
+}
+
+// optimizerHint returns the hints as an optimizer hint comment.
+func optimizerHint(hints []string) string {
+	return fmt.Sprintf("/*+ %s */", sqlCommentReplacer.Replace(strings.Join(hints, " ")))
+}
+
+// sqlCommenter returns the tags as a comment in the sqlcommenter format where
+// keys are sorted and both keys and values are URL encoded.
+func sqlCommenter(tags map[string]string) string {
+	var (
+		keys  []string
+		pairs []string
+	)
+
+	for k := range tags {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf(
+			"%s='%s'",
+			sqlCommenterEscape(k),
+			sqlCommenterEscape(tags[k]),
+		))
+	}
+
+	return fmt.Sprintf("/*%s*/", strings.Join(pairs, ","))
+}
+
+// sqlCommenterEscape URL encodes the value. Since the value is URL encoded it
+// can't contain any quotes, question marks or end the comment.
+func sqlCommenterEscape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
+
+// plainInsertFunc is a plain insert, not using any insert options set on the
+// scope since they're meant for the bulk statement.
+func plainInsertFunc(ctx *ExecContext) {
+	ctx.Scope.Raw(fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES %s",
+		ctx.Scope.QuotedTableName(),
+		strings.Join(ctx.QuotedColumnNames, ", "),
+		strings.Join(ctx.Groups, ", "),
 	))
 }
 
@@ -78,6 +480,11 @@ func defaultWithFormat(scope *gorm.Scope, columnNames, groups []string, format s
 	if insertOption, ok := scope.Get("gorm:insert_option"); ok {
 		// Add the extra insert option
 		extraOptions = fmt.Sprintf(" %s", insertOption)
+
+		// The vars for the insert option comes after the vars for all rows.
+		if vars, ok := scope.Get(insertOptionVarsKey); ok {
+			scope.SQLVars = append(scope.SQLVars, vars.([]interface{})...)
+		}
 	}
 
 	scope.Raw(fmt.Sprintf(
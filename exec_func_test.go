@@ -1,6 +1,9 @@
 package gormbulk
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -58,6 +61,20 @@ func Test_Exec(t *testing.T) {
 			placeholders: []string{"(?, ?)", "(?, ?)"},
 			expectedSQL:  "INSERT INTO `tests` (`created_at`, `foo`) VALUES (?, ?), (?, ?) ON DUPLICATE KEY UPDATE `foo` = VALUES(`foo`)",
 		},
+		{
+			description:  "on duplicate key keeps existing values for NULL",
+			execFunc:     InsertOnDuplicateKeyUpdateCoalesceFunc,
+			columns:      []string{"`created_at`", "`foo`", "`bar`"},
+			placeholders: []string{"(?, ?, ?)"},
+			expectedSQL:  "INSERT INTO `tests` (`created_at`, `foo`, `bar`) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE `foo` = COALESCE(VALUES(`foo`), `foo`), `bar` = COALESCE(VALUES(`bar`), `bar`)",
+		},
+		{
+			description:  "on conflict keeps existing values for NULL",
+			execFunc:     InsertOnConflictCoalesceFunc("foo"),
+			columns:      []string{"`foo`", "`bar`"},
+			placeholders: []string{"(?, ?)", "(?, ?)"},
+			expectedSQL:  "INSERT INTO `tests` (`foo`, `bar`) VALUES (?, ?), (?, ?) ON CONFLICT (`foo`) DO UPDATE SET `foo` = COALESCE(EXCLUDED.`foo`, `tests`.`foo`), `bar` = COALESCE(EXCLUDED.`bar`, `tests`.`bar`)",
+		},
 		{
 			description:  "correct insert ignore",
 			execFunc:     InsertIgnoreFunc,
@@ -65,6 +82,20 @@ func Test_Exec(t *testing.T) {
 			placeholders: []string{"(?, ?)", "(?, ?)"},
 			expectedSQL:  "INSERT IGNORE INTO `tests` (foo, bar) VALUES (?, ?), (?, ?)",
 		},
+		{
+			description:  "composed with suffix and comment",
+			execFunc:     Compose(InsertFunc, WithSuffix("RETURNING id"), WithPrefixComment("job:nightly")),
+			columns:      []string{"foo", "bar"},
+			placeholders: []string{"(?, ?)", "(?, ?)"},
+			expectedSQL:  "/* job:nightly */ INSERT INTO `tests` (foo, bar) VALUES (?, ?), (?, ?) RETURNING id",
+		},
+		{
+			description:  "comment can not be ended early",
+			execFunc:     Compose(InsertIgnoreFunc, WithPrefixComment("*/ DROP TABLE x; /* why?")),
+			columns:      []string{"foo"},
+			placeholders: []string{"(?)"},
+			expectedSQL:  "/* * / DROP TABLE x; /* why */ INSERT IGNORE INTO `tests` (foo) VALUES (?)",
+		},
 	}
 
 	for _, tc := range cases {
@@ -81,3 +112,174 @@ func Test_Exec(t *testing.T) {
 		})
 	}
 }
+
+func Test_ExecFuncV2(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	type test struct {
+		Foo string
+		Bar string `gorm:"column:baz"`
+	}
+
+	objects := []interface{}{
+		test{Foo: "foo1", Bar: "bar1"},
+		&test{Foo: "foo2", Bar: "bar2"},
+	}
+
+	execFunc := func(ctx *ExecContext) {
+		assert.Equal(t, objects, ctx.Objects)
+		assert.Equal(t, "mysql", ctx.Dialect.GetName())
+
+		require.Len(t, ctx.Columns, 2)
+		assert.Equal(t, "baz", ctx.Columns[0].Name)
+		assert.Equal(t, "`baz`", ctx.Columns[0].QuotedName)
+		assert.Equal(t, "Bar", ctx.Columns[0].Field.Name)
+
+		assert.Equal(t, [][]interface{}{{"bar1", "foo1"}, {"bar2", "foo2"}}, ctx.RowVars)
+
+		// Only insert the first column
+		var (
+			vars   []interface{}
+			groups []string
+		)
+
+		for _, row := range ctx.RowVars {
+			vars = append(vars, row[0])
+			groups = append(groups, "(?)")
+		}
+
+		ctx.Scope.Raw(fmt.Sprintf(
+			"INSERT INTO %s (%s) VALUES %s",
+			ctx.Scope.QuotedTableName(),
+			ctx.QuotedColumnNames[0],
+			strings.Join(groups, ", "),
+		))
+
+		ctx.Scope.SQLVars = vars
+	}
+
+	mock.ExpectExec("INSERT INTO `tests` \\(`baz`\\) VALUES \\(\\?\\), \\(\\?\\)").
+		WithArgs("bar1", "bar2").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	require.NoError(t, BulkExecV2(gdb, objects, execFunc))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_ExecContextSelectColumns(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	type point struct {
+		Name     string
+		Location string
+		Zone     *string
+	}
+
+	zone := "z"
+
+	objects := []interface{}{
+		point{Name: "a", Location: "POINT(1 2)"},
+		point{Name: "b", Location: "POINT(3 4)", Zone: &zone},
+	}
+
+	execFunc := func(ctx *ExecContext) {
+		assert.Equal(t, [][]string{
+			{"ST_GeomFromText(?)", "?", "DEFAULT"},
+			{"ST_GeomFromText(?)", "?", "?"},
+		}, ctx.Placeholders)
+		assert.Equal(t, [][][]int{
+			{{0}, {1}, {}},
+			{{0}, {1}, {2}},
+		}, ctx.VarIndexes)
+
+		// Reorder the columns and leave out the zone.
+		columnNames, groups, vars := ctx.SelectColumns(1, 0)
+
+		ctx.Scope.Raw(fmt.Sprintf(
+			"INSERT INTO %s (%s) VALUES %s",
+			ctx.Scope.QuotedTableName(),
+			strings.Join(columnNames, ", "),
+			strings.Join(groups, ", "),
+		))
+
+		ctx.Scope.SQLVars = vars
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(
+		"INSERT INTO `points` (`name`, `location`) VALUES (?, ST_GeomFromText(?)), (?, ST_GeomFromText(?))",
+	)).
+		WithArgs("a", "POINT(1 2)", "b", "POINT(3 4)").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	require.NoError(t, BulkExecV2(
+		gdb, objects, execFunc,
+		WithColumnExpr("location", "ST_GeomFromText(?)"),
+		WithNilPointerPolicy(NilPointerAsDefault),
+	))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_ExecContextAddVars(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("postgres", db)
+	require.NoError(t, err)
+
+	type item struct {
+		Name string
+	}
+
+	objects := []interface{}{item{Name: "a"}, item{Name: "b"}}
+
+	execFunc := func(ctx *ExecContext) {
+		// Add the vars in another order than they're bound.
+		ctx.AddVarsAfter("active")
+		ctx.AddVarsBefore(1)
+		ctx.AddVarsAfter(10)
+
+		ctx.Scope.Raw(fmt.Sprintf(
+			`WITH "tenant" AS (SELECT ? AS "id") INSERT INTO %s (%s) VALUES %s ON CONFLICT ("name") DO UPDATE SET "status" = ?, "rank" = ?`,
+			ctx.Scope.QuotedTableName(),
+			strings.Join(ctx.QuotedColumnNames, ", "),
+			strings.Join(ctx.Groups, ", "),
+		))
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(
+		`WITH "tenant" AS (SELECT $1 AS "id") INSERT INTO "items" ("name") VALUES ($2), ($3) ON CONFLICT ("name") DO UPDATE SET "status" = $4, "rank" = $5`,
+	)).
+		WithArgs(1, "a", "b", "active", 10).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	require.NoError(t, BulkExecV2(gdb, objects, execFunc))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_duplicateKeyUpdatesQuoting(t *testing.T) {
+	for _, dialect := range []string{"mysql", "postgres"} {
+		t.Run(dialect, func(t *testing.T) {
+			db, _, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open(dialect, db)
+			require.NoError(t, err)
+
+			scope := gdb.NewScope(nil)
+			columns := quoteColumns(scope, []string{"created_at", "foo"})
+
+			assert.Equal(t,
+				[]string{fmt.Sprintf("%s = VALUES(%s)", columns[1], columns[1])},
+				duplicateKeyUpdates(scope, columns),
+			)
+		})
+	}
+}
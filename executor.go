@@ -0,0 +1,351 @@
+package gormbulk
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Executor executes the statements built by the bulk functions. The db is
+// the *gorm.DB passed to the bulk function, or the transaction if the
+// statement is executed in one.
+type Executor interface {
+	Exec(db *gorm.DB, sql string, vars ...interface{}) error
+}
+
+// ExecutorFunc is a function implementing Executor.
+type ExecutorFunc func(db *gorm.DB, sql string, vars ...interface{}) error
+
+// Exec implements Executor.
+func (fn ExecutorFunc) Exec(db *gorm.DB, sql string, vars ...interface{}) error {
+	return fn(db, sql, vars...)
+}
+
+// WithExecutor will execute all statements with the executor instead of
+// db.Exec, i.e. to record the statements in unit tests. Queries, such as
+// selecting existing rows for WithSkipUnchanged, are also passed to the
+// executor and then return no rows.
+func WithExecutor(executor Executor) Option {
+	return func(o *options) {
+		o.executor = executor
+	}
+}
+
+// contextKey is the db setting holding the context set with WithContext when
+// passing the db to the executor, see SQLExecutor.
+const contextKey = "gormbulk:context"
+
+// executorExec executes the statement with the executor set in the options.
+// The context set with WithContext, if any, is set on the db.
+func (o *options) executorExec(db *gorm.DB, sql string, vars ...interface{}) error {
+	if o.ctx != nil {
+		db = db.Set(contextKey, o.ctx)
+	}
+
+	return o.executor.Exec(db, sql, vars...)
+}
+
+// exec executes the statement with the executor set in the options, on the
+// pinned connection or with db.Exec if neither is set.
+func (o *options) exec(db *gorm.DB, sql string, vars ...interface{}) error {
+	if err := o.checkStatement(sql, vars); err != nil {
+		return err
+	}
+
+	o.logStatement(sql, vars, nil)
+
+	if o.executor != nil {
+		return o.executorExec(db, sql, vars...)
+	}
+
+	if o.pinned(db) {
+		_, err := o.execResult(db, sql, vars...)
+		return err
+	}
+
+	return db.Exec(sql, vars...).Error
+}
+
+// execRowsAffected executes the statement the same way as exec and returns
+// the number of rows affected, which is always 0 when using WithExecutor.
+func (o *options) execRowsAffected(db *gorm.DB, sql string, vars ...interface{}) (int64, error) {
+	if err := o.checkStatement(sql, vars); err != nil {
+		return 0, err
+	}
+
+	o.logStatement(sql, vars, nil)
+
+	if o.executor != nil {
+		return 0, o.executorExec(db, sql, vars...)
+	}
+
+	if o.pinned(db) {
+		res, err := o.execResult(db, sql, vars...)
+		if err != nil {
+			return 0, err
+		}
+
+		return res.RowsAffected()
+	}
+
+	res := db.Exec(sql, vars...)
+
+	return res.RowsAffected, res.Error
+}
+
+// ChunkDoneFunc is called after each bulk statement. The chunk is the index
+// of the chunk (always 0 unless executing in chunks) and res is the
+// sql.Result from the driver, which is nil if the statement failed or was
+// executed with WithExecutor.
+type ChunkDoneFunc func(chunk int, res sql.Result, err error)
+
+// OnChunkDone will call fn after each bulk statement, i.e. to record the last
+// insert ID or rows affected for each chunk. Sharded objects (see
+// WithShardFunc) call fn once for each statement in the chunk. Statements
+// executed with the callback set are not logged by gorm since the driver is
+// called directly to get the sql.Result.
+func OnChunkDone(fn ChunkDoneFunc) Option {
+	return func(o *options) {
+		o.onChunkDone = fn
+	}
+}
+
+// chunkDone calls the function set with OnChunkDone, if any.
+func (o *options) chunkDone(res sql.Result, err error) {
+	if o.onChunkDone != nil {
+		o.onChunkDone(o.chunk, res, err)
+	}
+}
+
+// statementSettings are the settings set on the scope for each bulk statement
+// which are passed on to the db executing it.
+var statementSettings = append([]string{sensitiveVarsKey}, shapeSettings...)
+
+// statementDB returns the db with the statement settings set on the scope
+// added. The settings set with db.Set on the db passed to the bulk function
+// are kept, so callbacks, loggers and executors keying off instance settings
+// see the same settings as when building the SQL. The db is only cloned if
+// any setting is added.
+func statementDB(db *gorm.DB, scope *gorm.Scope) *gorm.DB {
+	stmtDB := db
+
+	for _, setting := range statementSettings {
+		value, ok := scope.Get(setting)
+		if !ok {
+			continue
+		}
+
+		if stmtDB == db {
+			stmtDB = db.Set(setting, value)
+			continue
+		}
+
+		stmtDB.InstantSet(setting, value)
+	}
+
+	return stmtDB
+}
+
+// execStatementSQL executes the bulk statement built in the scope and adds the
+// number of rows affected to the result, if any. The rows affected are only
+// known when not using WithExecutor. The sql.Result is only returned when
+// OnChunkDone or WithIDBackfill is set. The statement is executed on the db
+// with the settings from the scope added, see statementDB.
+func (o *options) execStatementSQL(db *gorm.DB, scope *gorm.Scope) (sql.Result, error) {
+	db = statementDB(db, scope)
+
+	if err := o.checkStatement(scope.SQL, scope.SQLVars); err != nil {
+		return nil, err
+	}
+
+	o.logStatement(scope.SQL, scope.SQLVars, sensitiveVars(scope))
+
+	if o.result != nil {
+		o.result.StatementSizes = append(o.result.StatementSizes, StatementSize{
+			Chunk: o.chunk,
+			Bytes: encodedSize(scope.SQL, scope.SQLVars),
+		})
+	}
+
+	if o.executor != nil {
+		return nil, o.executorExec(db, scope.SQL, scope.SQLVars...)
+	}
+
+	if o.onChunkDone == nil && !o.idBackfill && !o.pinned(db) {
+		res := db.Exec(scope.SQL, scope.SQLVars...)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+
+		o.addRowsAffected(res.RowsAffected)
+
+		return nil, nil
+	}
+
+	res, err := o.execResult(db, scope.SQL, scope.SQLVars...)
+	if err != nil {
+		return nil, err
+	}
+
+	if rowsAffected, err := res.RowsAffected(); err == nil {
+		o.addRowsAffected(rowsAffected)
+	}
+
+	if o.onChunkDone == nil && !o.idBackfill {
+		return nil, nil
+	}
+
+	return res, nil
+}
+
+// encodedSize returns the approximate size in bytes of the SQL and vars when
+// sent to the server.
+func encodedSize(sql string, vars []interface{}) int {
+	size := len(sql)
+
+	for _, v := range vars {
+		if valuer, ok := v.(driver.Valuer); ok {
+			if value, err := valuer.Value(); err == nil {
+				v = value
+			}
+		}
+
+		switch value := v.(type) {
+		case nil:
+		case string:
+			size += len(value)
+		case []byte:
+			size += len(value)
+		case time.Time:
+			size += len(value.Format(time.RFC3339Nano))
+		default:
+			size += len(fmt.Sprint(value))
+		}
+	}
+
+	return size
+}
+
+// addRowsAffected adds the rows affected to the result, if any.
+func (o *options) addRowsAffected(rowsAffected int64) {
+	if o.result != nil {
+		o.result.RowsAffected += rowsAffected
+	}
+}
+
+// execResult executes the query the same way as db.Exec but returns the
+// sql.Result from the driver. The query is executed on the pinned connection,
+// if any, with the context set with WithContext.
+func (o *options) execResult(db *gorm.DB, query string, vars ...interface{}) (sql.Result, error) {
+	query, vars, err := dialectSQL(db, query, vars...)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := o.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if o.pinned(db) {
+		return o.conn.ExecContext(ctx, query, vars...)
+	}
+
+	if execer, ok := db.CommonDB().(SQLExecer); ok {
+		return execer.ExecContext(ctx, query, vars...)
+	}
+
+	return db.CommonDB().Exec(query, vars...)
+}
+
+// queryRows runs the query returning rows, i.e. to select existing rows, the
+// same way as exec: the statement is checked and logged and executed on the
+// pinned connection, if any. With WithExecutor the query is passed to the
+// executor and nil rows are returned since the executor returns no rows.
+func (o *options) queryRows(db *gorm.DB, query string, vars ...interface{}) (*sql.Rows, error) {
+	if err := o.checkStatement(query, vars); err != nil {
+		return nil, err
+	}
+
+	o.logStatement(query, vars, nil)
+
+	if o.executor != nil {
+		return nil, o.executorExec(db, query, vars...)
+	}
+
+	if !o.pinned(db) {
+		return db.Raw(query, vars...).Rows()
+	}
+
+	query, vars, err := dialectSQL(db, query, vars...)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := o.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return o.conn.QueryContext(ctx, query, vars...)
+}
+
+// dialectSQL passes the query through gorm to convert placeholders for the
+// dialect and expand slices, the same way as db.Exec.
+func dialectSQL(db *gorm.DB, query string, vars ...interface{}) (string, []interface{}, error) {
+	scope := db.New().Raw(query, vars...).NewScope(nil)
+
+	// Raw replaces the placeholders used internally by gorm with the ones
+	// used by the dialect.
+	scope.Raw(scope.CombinedConditionSql())
+
+	if scope.HasError() {
+		return "", nil, scope.DB().Error
+	}
+
+	return scope.SQL, scope.SQLVars, nil
+}
+
+// SQLExecer executes a query without returning any rows. It's implemented by
+// *sql.DB, *sql.Tx and *sql.Conn as well as *sqlx.DB and
+// *sqlx.Tx.
+type SQLExecer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// SQLExecutor returns an Executor executing the statements with execer
+// instead of gorm, i.e. in code bases using database/sql or sqlx where gorm
+// is only used for the model metadata. The *gorm.DB passed to the bulk
+// function is still used to build the SQL and must use the same dialect, i.e.
+// opened with gorm.Open("postgres", sqlDB) for the *sql.DB already in use.
+// The placeholders are converted for the dialect before the statement is
+// executed. Statements in a transaction, i.e. when using WithAuditTable,
+// BulkSync or SeedTable, are executed in the transaction of the db instead
+// of with execer so they are committed or rolled back with it. The context
+// set with WithContext is used for all statements.
+func SQLExecutor(execer SQLExecer) Executor {
+	return ExecutorFunc(func(db *gorm.DB, query string, vars ...interface{}) error {
+		query, vars, err := dialectSQL(db, query, vars...)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		if value, ok := db.Get(contextKey); ok {
+			ctx = value.(context.Context)
+		}
+
+		stmtExecer := execer
+		if tx, ok := db.CommonDB().(*sql.Tx); ok {
+			stmtExecer = tx
+		}
+
+		_, err = stmtExecer.ExecContext(ctx, query, vars...)
+
+		return err
+	})
+}
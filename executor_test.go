@@ -0,0 +1,359 @@
+package gormbulk
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithExecutor(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	var (
+		executedSQL  []string
+		executedVars [][]interface{}
+	)
+
+	executor := ExecutorFunc(func(_ *gorm.DB, sql string, vars ...interface{}) error {
+		executedSQL = append(executedSQL, sql)
+		executedVars = append(executedVars, vars)
+
+		return nil
+	})
+
+	objects := []interface{}{user{Name: "a"}, user{Name: "b"}}
+
+	require.NoError(t, BulkInsert(gdb, objects, WithExecutor(executor), SyncDeleteMissing("name")))
+
+	assert.Equal(t, []string{
+		"INSERT INTO `users` (`name`) VALUES (?), (?)",
+		"DELETE FROM `users` WHERE (`name`) NOT IN ((?), (?))",
+	}, executedSQL)
+	assert.Equal(t, [][]interface{}{{"a", "b"}, {"a", "b"}}, executedVars)
+
+	// Nothing should be executed on the database.
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLExecutor(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	cases := []struct {
+		description string
+		dialect     string
+		expectedSQL string
+	}{
+		{
+			description: "mysql placeholders",
+			dialect:     "mysql",
+			expectedSQL: "INSERT INTO `users` (`name`) VALUES (?), (?)",
+		},
+		{
+			description: "postgres placeholders",
+			dialect:     "postgres",
+			expectedSQL: `INSERT INTO "users" ("name") VALUES ($1), ($2)`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open(tc.dialect, db)
+			require.NoError(t, err)
+
+			execDB, execMock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			execMock.ExpectExec(regexp.QuoteMeta(tc.expectedSQL)+"$").
+				WithArgs("a", "b").
+				WillReturnResult(sqlmock.NewResult(0, 2))
+
+			objects := []interface{}{user{Name: "a"}, user{Name: "b"}}
+
+			require.NoError(t, BulkInsert(gdb, objects, WithExecutor(SQLExecutor(execDB))))
+			require.NoError(t, execMock.ExpectationsWereMet())
+
+			// Nothing should be executed on the gorm database.
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSQLExecutorTransaction(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	execDB, execMock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	// The statements are executed in the transaction and rolled back when the
+	// audit fails, nothing is executed with the execer.
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?)")).
+		WithArgs("a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users_audit`")).
+		WillReturnError(errors.New("no such table"))
+	mock.ExpectRollback()
+
+	err = BulkInsert(
+		gdb, []interface{}{user{Name: "a"}},
+		WithExecutor(SQLExecutor(execDB)),
+		WithAuditTable("users_audit"),
+	)
+	require.EqualError(t, err, "no such table")
+	require.NoError(t, mock.ExpectationsWereMet())
+	require.NoError(t, execMock.ExpectationsWereMet())
+}
+
+func TestSQLExecutorContext(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	gdb, err := gorm.Open("mysql", sql.OpenDB(&txOptionsConnector{}))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Cancel the context after the statement is built but before it's
+	// executed with the execer.
+	check := func(string, []interface{}) error {
+		cancel()
+		return nil
+	}
+
+	err = BulkInsert(
+		gdb,
+		[]interface{}{user{Name: "a"}},
+		WithExecutor(SQLExecutor(gdb.CommonDB().(*sql.DB))),
+		WithContext(ctx),
+		WithStatementCheck(check),
+	)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestOnChunkDoneContext(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	gdb, err := gorm.Open("mysql", sql.OpenDB(&txOptionsConnector{}))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Cancel the context after the statement is built but before it's
+	// executed to get the sql.Result.
+	check := func(string, []interface{}) error {
+		cancel()
+		return nil
+	}
+
+	err = BulkInsert(
+		gdb,
+		[]interface{}{user{Name: "a"}},
+		OnChunkDone(func(int, sql.Result, error) {}),
+		WithContext(ctx),
+		WithStatementCheck(check),
+	)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestOnChunkDone(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("postgres", db)
+	require.NoError(t, err)
+
+	type chunkDone struct {
+		chunk        int
+		lastInsertID int64
+		rowsAffected int64
+		err          error
+	}
+
+	var done []chunkDone
+
+	onChunkDone := func(chunk int, res sql.Result, err error) {
+		d := chunkDone{chunk: chunk, err: err}
+
+		if res != nil {
+			d.lastInsertID, _ = res.LastInsertId()
+			d.rowsAffected, _ = res.RowsAffected()
+		}
+
+		done = append(done, d)
+	}
+
+	mock.ExpectExec(`INSERT INTO "users" \("name"\) VALUES \(\$1\), \(\$2\)`).
+		WithArgs("a", "b").
+		WillReturnResult(sqlmock.NewResult(2, 2))
+	mock.ExpectExec(`INSERT INTO "users" \("name"\) VALUES \(\$1\)`).
+		WithArgs("c").
+		WillReturnError(errors.New("some error"))
+
+	result := &Result{}
+	objects := []interface{}{user{Name: "a"}, user{Name: "b"}, user{Name: "c"}}
+
+	errs := BulkExecChunk(gdb, objects, InsertFunc, 2, OnChunkDone(onChunkDone), WithResult(result))
+	assert.Equal(t, []error{errors.New("some error")}, errs)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, []chunkDone{
+		{chunk: 0, lastInsertID: 2, rowsAffected: 2},
+		{chunk: 1, err: errors.New("some error")},
+	}, done)
+	assert.Equal(t, int64(2), result.RowsAffected)
+}
+
+func Test_encodedSize(t *testing.T) {
+	cases := []struct {
+		description string
+		vars        []interface{}
+		expected    int
+	}{
+		{
+			description: "only sql",
+			expected:    6,
+		},
+		{
+			description: "strings and bytes",
+			vars:        []interface{}{"abc", []byte("de"), nil},
+			expected:    11,
+		},
+		{
+			description: "valuers and other types",
+			vars:        []interface{}{sql.NullString{String: "abc", Valid: true}, sql.NullInt64{}, 1234, true},
+			expected:    17,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			assert.Equal(t, tc.expected, encodedSize("SELECT", tc.vars))
+		})
+	}
+}
+
+func TestStatementSizes(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	mock.ExpectExec("INSERT INTO `users`").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT INTO `users`").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var (
+		result  = &Result{}
+		objects = []interface{}{user{Name: "a"}, user{Name: "bb"}, user{Name: "ccc"}}
+		sql     = len("INSERT INTO `users` (`name`) VALUES (?), (?)")
+	)
+
+	require.Empty(t, BulkExecChunk(gdb, objects, InsertFunc, 2, WithResult(result)))
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, []StatementSize{
+		{Chunk: 0, Bytes: sql + 3},
+		{Chunk: 1, Bytes: sql - len(", (?)") + 3},
+	}, result.StatementSizes)
+}
+
+func TestInstanceSettings(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	cases := []struct {
+		description      string
+		opts             []Option
+		expectedSettings map[string]interface{}
+	}{
+		{
+			description: "settings on the db are kept",
+			expectedSettings: map[string]interface{}{
+				"tenant": "acme",
+			},
+		},
+		{
+			description: "settings on the scope are added",
+			opts:        []Option{WithInsertOption("IGNORE")},
+			expectedSettings: map[string]interface{}{
+				"tenant":             "acme",
+				"gorm:insert_option": "IGNORE",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, _, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			settings := map[string]interface{}{}
+
+			executor := ExecutorFunc(func(db *gorm.DB, _ string, _ ...interface{}) error {
+				for setting := range tc.expectedSettings {
+					if value, ok := db.Get(setting); ok {
+						settings[setting] = value
+					}
+				}
+
+				return nil
+			})
+
+			opts := append([]Option{WithExecutor(executor)}, tc.opts...)
+
+			require.NoError(t, BulkInsert(gdb.Set("tenant", "acme"), []interface{}{user{Name: "a"}}, opts...))
+			assert.Equal(t, tc.expectedSettings, settings)
+
+			// The settings are not added to the db passed.
+			_, ok := gdb.Get("gorm:insert_option")
+			assert.False(t, ok)
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package gormbulk
+
+import "time"
+
+// WithExpiry will set the column for every row to the current time (see
+// WithBatchTime and WithRowTimestamps) plus the TTL, i.e. to give rows in a
+// cache like table the same expiration. If the objects doesn't have the column
+// it will be added to the statement. Objects with the field set keep their
+// expiry.
+func WithExpiry(column string, ttl time.Duration) Option {
+	return func(o *options) {
+		o.expiryColumn = column
+		o.expiryTTL = ttl
+	}
+}
+
+// expiry returns the expiry for a row where now is the time used for blank
+// CreatedAt and UpdatedAt fields.
+func (o *options) expiry(now time.Time) time.Time {
+	return o.inLocation(now.Add(o.expiryTTL))
+}
@@ -0,0 +1,77 @@
+package gormbulk
+
+import (
+	"database/sql/driver"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithExpiry(t *testing.T) {
+	type entry struct {
+		Key string
+	}
+
+	type entryWithExpiry struct {
+		Key       string
+		ExpiresAt *time.Time
+	}
+
+	var (
+		now     = time.Date(2019, 11, 1, 10, 0, 0, 0, time.UTC)
+		expires = now.Add(time.Hour)
+		custom  = now.Add(24 * time.Hour)
+	)
+
+	cases := []struct {
+		description  string
+		objects      []interface{}
+		expectedSQL  string
+		expectedArgs []driver.Value
+	}{
+		{
+			description: "column is added to the statement",
+			objects: []interface{}{
+				entry{Key: "a"},
+				entry{Key: "b"},
+			},
+			expectedSQL:  "INSERT INTO `entries` (`expires_at`, `key`) VALUES (?, ?), (?, ?)",
+			expectedArgs: []driver.Value{expires, "a", expires, "b"},
+		},
+		{
+			description: "only blank fields are set",
+			objects: []interface{}{
+				entryWithExpiry{Key: "a"},
+				entryWithExpiry{Key: "b", ExpiresAt: &custom},
+			},
+			expectedSQL:  "INSERT INTO `entry_with_expiries` (`expires_at`, `key`) VALUES (?, ?), (?, ?)",
+			expectedArgs: []driver.Value{expires, "a", custom, "b"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			mock.ExpectExec(regexp.QuoteMeta(tc.expectedSQL)).
+				WithArgs(tc.expectedArgs...).
+				WillReturnResult(sqlmock.NewResult(0, 2))
+
+			require.NoError(t, BulkInsert(
+				gdb, tc.objects,
+				WithBatchTime(now),
+				WithExpiry("expires_at", time.Hour),
+				WithNilPointerPolicy(NilPointerAsDefault),
+			))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
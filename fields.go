@@ -0,0 +1,141 @@
+package gormbulk
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/jinzhu/gorm"
+)
+
+// fieldPath is the path to a field which may be bound. The index holds the
+// reflect index for each of the names in StructField.Names.
+type fieldPath struct {
+	field *gorm.StructField
+	index [][]int
+}
+
+// fieldPathCache holds the paths for each struct type, keyed by reflect.Type.
+var fieldPathCache sync.Map
+
+// fieldPaths returns the paths for all fields of the struct type which may be
+// bound. Fields which are never bound regardless of their value, such as
+// relationships, are left out. The paths are resolved once per type so rows
+// can be read with FieldByIndex instead of the field names.
+func fieldPaths(t reflect.Type) []fieldPath {
+	if paths, ok := fieldPathCache.Load(t); ok {
+		return paths.([]fieldPath)
+	}
+
+	var paths []fieldPath
+
+	for _, field := range (&gorm.Scope{Value: reflect.New(t).Interface()}).GetStructFields() {
+		if skipField(field) {
+			continue
+		}
+
+		var (
+			index   [][]int
+			current = t
+		)
+
+		for _, name := range field.Names {
+			if current.Kind() == reflect.Ptr {
+				current = current.Elem()
+			}
+
+			structField, ok := current.FieldByName(name)
+			if !ok {
+				break
+			}
+
+			index = append(index, structField.Index)
+			current = structField.Type
+		}
+
+		if len(index) != len(field.Names) {
+			continue
+		}
+
+		paths = append(paths, fieldPath{field: field, index: index})
+	}
+
+	fieldPathCache.Store(t, paths)
+
+	return paths
+}
+
+// skipField returns true if the field is never bound regardless of its value.
+func skipField(field *gorm.StructField) bool {
+	// Exclude relational record because it's not directly contained in database columns
+	if _, hasForeignKey := field.TagSettingsGet("FOREIGNKEY"); hasForeignKey {
+		return true
+	}
+
+	if field.Relationship != nil || field.IsIgnored {
+		return true
+	}
+
+	if isGeneratedField(field) {
+		return true
+	}
+
+	// Check if auto increment is set (but not set to false). If so skip the
+	// field and let the DBM auto increment the value.
+	if value, ok := field.TagSettingsGet("AUTO_INCREMENT"); ok {
+		if !strings.EqualFold(value, "false") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// objectFields returns the fields which may be bound for the addressable
+// struct value. Like gorm.Scope.Fields, nil pointers to embedded structs are
+// set to a new value to reach the fields. All fields are allocated at once.
+func objectFields(rv reflect.Value) []*gorm.Field {
+	var (
+		paths  = fieldPaths(rv.Type())
+		values = make([]gorm.Field, len(paths))
+		fields = make([]*gorm.Field, len(paths))
+	)
+
+	for i, path := range paths {
+		fv := rv
+
+		for _, index := range path.index {
+			if fv.Kind() == reflect.Ptr && fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+
+			fv = reflect.Indirect(fv).FieldByIndex(index)
+		}
+
+		values[i] = gorm.Field{StructField: path.field, Field: fv, IsBlank: isBlank(fv)}
+		fields[i] = &values[i]
+	}
+
+	return fields
+}
+
+// isBlank returns true if the value is the zero value for its type, the same
+// way as gorm.
+func isBlank(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String:
+		return value.Len() == 0
+	case reflect.Bool:
+		return !value.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return value.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return value.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return value.IsNil()
+	}
+
+	return reflect.DeepEqual(value.Interface(), reflect.Zero(value.Type()).Interface())
+}
@@ -0,0 +1,109 @@
+package gormbulk
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type benchmarkRow struct {
+	gorm.Model
+	Name     string
+	Email    string
+	Age      int
+	Score    float64
+	Active   bool
+	Nickname sql.NullString
+	Born     *time.Time
+}
+
+func benchmarkRows(n int) []interface{} {
+	objects := make([]interface{}, n)
+
+	for i := range objects {
+		objects[i] = benchmarkRow{
+			Name:   fmt.Sprintf("name-%d", i),
+			Email:  fmt.Sprintf("user-%d@example.com", i),
+			Age:    i % 100,
+			Score:  float64(i) / 3,
+			Active: i%2 == 0,
+		}
+	}
+
+	return objects
+}
+
+func Test_objectFields(t *testing.T) {
+	type Audit struct {
+		CreatedBy string
+	}
+
+	type owner struct {
+		ID   uint
+		Name string
+	}
+
+	type test struct {
+		*Audit
+		gorm.Model
+		Serial   int `gorm:"auto_increment"`
+		Name     string
+		Ignored  string `gorm:"-"`
+		Computed string `bulk:"generated"`
+		OwnerID  uint
+		Owner    owner
+		Nickname sql.NullString
+	}
+
+	now := time.Now()
+
+	for _, object := range []*test{
+		{},
+		{Name: "foo", Audit: &Audit{CreatedBy: "bar"}, Model: gorm.Model{ID: 1, CreatedAt: now}},
+	} {
+		var (
+			expected []*gorm.Field
+			actual   = objectFields(reflect.ValueOf(object).Elem())
+		)
+
+		// Copy the object since gorm sets the nil embedded struct.
+		copied := *object
+
+		for _, field := range (&gorm.Scope{Value: &copied}).Fields() {
+			if !skipField(field.StructField) {
+				expected = append(expected, field)
+			}
+		}
+
+		require.Len(t, actual, len(expected))
+
+		for i := range expected {
+			assert.True(t, expected[i].StructField == actual[i].StructField, expected[i].Name)
+			assert.Equal(t, expected[i].Field.Interface(), actual[i].Field.Interface())
+			assert.Equal(t, expected[i].IsBlank, actual[i].IsBlank, expected[i].Name)
+		}
+
+		assert.NotNil(t, object.Audit)
+	}
+}
+
+func BenchmarkObjectToMap(b *testing.B) {
+	objects := benchmarkRows(1000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, object := range objects {
+			if _, err := ObjectToMap(object); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
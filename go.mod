@@ -5,5 +5,7 @@ go 1.13
 require (
 	github.com/DATA-DOG/go-sqlmock v1.3.3
 	github.com/jinzhu/gorm v1.9.11
+	github.com/jinzhu/inflection v1.0.0
+	github.com/lib/pq v1.1.1
 	github.com/stretchr/testify v1.2.2
 )
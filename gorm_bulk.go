@@ -1,97 +1,384 @@
 package gormbulk
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"reflect"
-	"sort"
 	"strings"
+	"time"
 
 	"github.com/jinzhu/gorm"
 )
 
 // BulkInsert will call BulkExec with the default InsertFunc.
-func BulkInsert(db *gorm.DB, objects []interface{}) error {
-	return BulkExec(db, objects, InsertFunc)
+func BulkInsert(db *gorm.DB, objects []interface{}, opts ...Option) error {
+	return BulkExec(db, objects, InsertFunc, opts...)
 }
 
 // BulkInsertIgnore will call BulkExec with the default InsertFunc.
-func BulkInsertIgnore(db *gorm.DB, objects []interface{}) error {
-	return BulkExec(db, objects, InsertIgnoreFunc)
+func BulkInsertIgnore(db *gorm.DB, objects []interface{}, opts ...Option) error {
+	return BulkExec(db, objects, InsertIgnoreFunc, opts...)
 }
 
 // BulkInsertOnDuplicateKeyUpdate will call BulkExec with the default InsertFunc.
-func BulkInsertOnDuplicateKeyUpdate(db *gorm.DB, objects []interface{}) error {
-	return BulkExec(db, objects, InsertOnDuplicateKeyUpdateFunc)
+func BulkInsertOnDuplicateKeyUpdate(db *gorm.DB, objects []interface{}, opts ...Option) error {
+	return BulkExec(db, objects, InsertOnDuplicateKeyUpdateFunc, opts...)
+}
+
+// BulkInsertIdempotent will call BulkExec with InsertIgnoreFunc and stamp each
+// row with an idempotency key (see WithIdempotencyKey). Given a unique index on
+// the column, retrying with the same key and objects won't insert any rows
+// already inserted.
+func BulkInsertIdempotent(db *gorm.DB, objects []interface{}, column, key string, opts ...Option) error {
+	return BulkExec(db, objects, InsertIgnoreFunc, append(opts, WithIdempotencyKey(column, key))...)
 }
 
 // BulkExecChunk will split the objects passed into the passed chunk size. A
-// slice of errors will be returned (if any).
-func BulkExecChunk(db *gorm.DB, objects []interface{}, execFunc ExecFunc, chunkSize int) []error {
-	var allErrors []error
+// slice of errors will be returned (if any). A chunk size less than one
+// returns a *ChunkSizeError and an empty slice of objects is a no-op. The
+// chunks are executed one by one in input order unless using WithConcurrency.
+func BulkExecChunk(db *gorm.DB, objects []interface{}, execFunc ExecFunc, chunkSize int, opts ...Option) []error {
+	return BulkExecChunkV2(db, objects, execFunc.toV2(), chunkSize, opts...)
+}
 
-	for {
-		var chunkObjects []interface{}
+// BulkExecChunkV2 works like BulkExecChunk but takes an ExecFuncV2.
+func BulkExecChunkV2(db *gorm.DB, objects []interface{}, execFunc ExecFuncV2, chunkSize int, opts ...Option) (errs []error) {
+	var (
+		allErrors []error
+		o         = newModelOptions(firstObject(objects), opts...)
+	)
 
-		if len(objects) <= chunkSize {
-			chunkObjects = objects
-			objects = []interface{}{}
-		} else {
-			chunkObjects = objects[:chunkSize]
-			objects = objects[chunkSize:]
-		}
+	if chunkSize < 1 {
+		return []error{&ChunkSizeError{Size: chunkSize}}
+	}
 
-		if err := BulkExec(db, chunkObjects, execFunc); err != nil {
-			allErrors = append(allErrors, err)
-		}
+	objects, rows, err := o.validate(objects)
+	if err != nil {
+		return []error{err}
+	}
 
-		// Nothing more to do
-		if len(objects) < 1 {
-			break
+	// Nothing to do.
+	if len(objects) < 1 {
+		return nil
+	}
+
+	if err := o.probeHealth(db); err != nil {
+		return []error{err}
+	}
+
+	end, err := o.startSession(db)
+	if err != nil {
+		return []error{err}
+	}
+
+	defer func() {
+		if err := end(); err != nil {
+			errs = append(errs, err)
 		}
+	}()
+
+	allObjects := objects
+
+	o.queueStatements = true
+
+	if o.inFlight(db) > 1 {
+		allErrors = o.execChunksConcurrently(db, objects, rows, execFunc, chunkSize)
+	} else {
+		allErrors = o.execChunks(db, objects, rows, execFunc, chunkSize)
+	}
+
+	if err := o.flushStatements(); err != nil {
+		allErrors = append(allErrors, err)
 	}
 
 	if len(allErrors) > 0 {
 		return allErrors
 	}
 
+	if o.deleteMissing {
+		if err := deleteMissing(db, allObjects, o); err != nil {
+			return []error{err}
+		}
+	}
+
 	return nil
 }
 
+// execChunks executes the objects in chunks one by one in input order.
+func (o *options) execChunks(db *gorm.DB, objects []interface{}, rows []int, execFunc ExecFuncV2, chunkSize int) []error {
+	var (
+		allErrors []error
+		elapsed   time.Duration
+		done      int
+	)
+
+	for len(objects) > 0 {
+		size := o.chunkLen(objects, chunkSize)
+
+		if err := o.canStartChunk(elapsed, done, size); err != nil {
+			allErrors = append(allErrors, &NotAttemptedError{Rows: rows, Err: err})
+			break
+		}
+
+		chunkObjects, chunkRows := objects[:size], rows[:size]
+		objects, rows = objects[size:], rows[size:]
+
+		// Only the last chunk may be partial.
+		var padSize int
+		if len(objects) == 0 {
+			padSize = chunkSize
+		}
+
+		started := time.Now()
+
+		if err := o.execPadded(db, chunkObjects, chunkRows, execFunc, padSize); err != nil {
+			allErrors = append(allErrors, err)
+		}
+
+		elapsed += time.Since(started)
+		done += len(chunkObjects)
+		o.chunk++
+	}
+
+	return allErrors
+}
+
 // BulkExec will convert a slice of interface to bulk SQL statement. The final
 // SQL will be determined by the ExecFunc passed.
-func BulkExec(db *gorm.DB, objects []interface{}, execFunc ExecFunc) error {
-	scope, err := scopeFromObjects(db, objects, execFunc)
+func BulkExec(db *gorm.DB, objects []interface{}, execFunc ExecFunc, opts ...Option) error {
+	return BulkExecV2(db, objects, execFunc.toV2(), opts...)
+}
+
+// BulkExecV2 works like BulkExec but takes an ExecFuncV2 which will get an
+// ExecContext with all the information used to build the SQL.
+func BulkExecV2(db *gorm.DB, objects []interface{}, execFunc ExecFuncV2, opts ...Option) (err error) {
+	o := newModelOptions(firstObject(objects), opts...)
+
+	objects, rows, err := o.validate(objects)
 	if err != nil {
 		return err
 	}
 
+	// Nothing to do.
+	if len(objects) < 1 {
+		return nil
+	}
+
+	end, err := o.startSession(db)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if endErr := end(); err == nil {
+			err = endErr
+		}
+	}()
+
+	if err := execObjects(db, objects, rows, execFunc, o); err != nil {
+		return err
+	}
+
+	if o.deleteMissing {
+		return deleteMissing(db, objects, o)
+	}
+
+	return nil
+}
+
+// execObjects executes the SQL for the objects, leaving out unchanged objects
+// if using WithSkipUnchanged. The rows holds the index of each object in the
+// slice passed to the bulk function. Failed objects are retried according to
+// the retry budget and then written to the dead letter table, if any.
+func execObjects(db *gorm.DB, objects []interface{}, rows []int, execFunc ExecFuncV2, o *options) error {
+	err := execChangedObjects(db, objects, rows, execFunc, o)
+	for err != nil && o.retry(err) {
+		err = execChangedObjects(db, objects, rows, execFunc, o)
+	}
+
+	o.retryState.chunkDone(err)
+
+	switch err.(type) {
+	case nil:
+		return nil
+	case *MultiStatementError, *DeadLetterError:
+		// The objects of a failed trip are written to the dead letter table
+		// by flushStatements since the trip holds objects from other chunks.
+		return err
+	}
+
+	if deadLetterErr := o.writeDeadLetters(db, objects, rows, err); deadLetterErr != nil {
+		return &DeadLetterError{Err: err, DeadLetterErr: deadLetterErr}
+	}
+
+	return err
+}
+
+// execChangedObjects executes the SQL for the objects changed, one statement
+// per shard if using WithShardFunc. The objects in each shard are compared
+// with the rows in the table of the shard.
+func execChangedObjects(db *gorm.DB, objects []interface{}, rows []int, execFunc ExecFuncV2, o *options) error {
+	if o.shardFunc == nil {
+		return execChangedStatement(db, objects, rows, execFunc, o)
+	}
+
+	for _, s := range o.shards(objects, rows) {
+		if err := execChangedStatement(db.Table(s.table), s.objects, s.rows, execFunc, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// execChangedStatement executes one single bulk statement for the objects
+// which differ from the existing rows in the table of the db.
+func execChangedStatement(db *gorm.DB, objects []interface{}, rows []int, execFunc ExecFuncV2, o *options) error {
+	objects, rows, err := o.changedObjects(db, objects, rows)
+	if err != nil {
+		return err
+	}
+
+	return execStatement(db, objects, rows, execFunc, o)
+}
+
+// execStatement executes one single bulk statement for all the objects.
+func execStatement(db *gorm.DB, objects []interface{}, rows []int, execFunc ExecFuncV2, o *options) error {
+	if err := o.checkRows(len(objects)); err != nil {
+		o.chunkDone(nil, err)
+		return err
+	}
+
+	padded, paddedRows := o.padChunk(objects, rows, o.padSize)
+
+	scope, err := scopeFromObjects(db, padded, paddedRows, execFunc, o)
+	if err != nil {
+		o.chunkDone(nil, err)
+		return err
+	}
+
 	// No scope and no error means nothing to do
 	if scope == nil {
 		return nil
 	}
 
-	return db.Exec(scope.SQL, scope.SQLVars...).Error
+	if err := o.checkVars(len(scope.SQLVars)); err != nil {
+		o.chunkDone(nil, err)
+		return err
+	}
+
+	if err := o.checkIDBackfill(db, objects, rows); err != nil {
+		o.chunkDone(nil, err)
+		return err
+	}
+
+	if o.auditTable == "" {
+		if o.multiStatement() {
+			return o.queueStatement(db, scope, objects, rows)
+		}
+
+		res, err := o.execStatementSQL(db, scope)
+		err = o.execError(scope, rows, err)
+
+		if err == nil {
+			err = o.backfillIDs(db, objects, rows, res)
+		}
+
+		o.chunkDone(res, err)
+
+		return err
+	}
+
+	var res sql.Result
+
+	err = o.transaction(db, func(tx *gorm.DB) error {
+		var err error
+
+		if res, err = o.execStatementSQL(tx, scope); err != nil {
+			return o.execError(scope, rows, err)
+		}
+
+		return execAudit(tx, objects, rows, o)
+	})
+
+	// The statement is rolled back if the audit fails.
+	if err != nil {
+		res = nil
+	}
+
+	if err == nil {
+		err = o.backfillIDs(db, objects, rows, res)
+	}
+
+	o.chunkDone(res, err)
+
+	return err
 }
 
-func scopeFromObjects(db *gorm.DB, objects []interface{}, execFunc ExecFunc) (*gorm.Scope, error) {
+// scopeFromObjects builds the scope with SQL and vars for the objects. The rows
+// holds the index of each object in the slice passed to the bulk function, if
+// nil the index in objects is used. Any panic while building the SQL, i.e. from
+// reflection on unexpected objects, is returned as a *RowError for the object
+// being processed.
+func scopeFromObjects(db *gorm.DB, objects []interface{}, rows []int, execFunc ExecFuncV2, o *options) (scope *gorm.Scope, err error) {
 	// No objects passed, nothing to do.
 	if len(objects) < 1 {
 		return nil, nil
 	}
 
+	// The index of the object being processed, reported if we panic. The
+	// first object is used to setup the scope and columns.
+	current := 0
+
+	defer func() {
+		if r := recover(); r != nil {
+			scope, err = nil, fmt.Errorf("panic while building SQL: %v", r)
+
+			if current < 0 {
+				return
+			}
+
+			row := current
+			if rows != nil {
+				row = rows[current]
+			}
+
+			err = &RowError{Row: row, Err: err}
+		}
+	}()
+
 	var (
 		columnNames       []string
 		quotedColumnNames []string
-		placeholders      []string
+		columns           []Column
 		groups            []string
-		scope             = db.NewScope(objects[0])
-		bulkNow           = gorm.NowFunc()
+		rowVars           [][]interface{}
+		rowPlaceholders   [][]string
+		rowVarIndexes     [][][]int
+		rowSensitive      []bool
+		nullCount         = map[string]int{}
+		bulkNow           = o.now()
 	)
 
+	scope = db.NewScope(objects[0])
+
+	o.dialect = scope.Dialect().GetName()
+
+	if tableName := o.scopeTableName(scope); tableName != scope.TableName() {
+		scope.Search.Table(tableName)
+	}
+
+	// The insert option is only set on the scope for this statement and not
+	// on the DB.
+	if o.insertOption != "" {
+		scope.Set("gorm:insert_option", o.insertOption)
+		scope.Set(insertOptionVarsKey, o.insertOptionArgs)
+	}
+
 	// Get a map of the first element to calculate field names and number of
 	// placeholders.
-	firstObjectFields, err := ObjectToMap(objects[0])
+	firstObjectFields, err := o.objectToMap(objects[0])
 	if err != nil {
 		return nil, err
 	}
@@ -100,12 +387,39 @@ func scopeFromObjects(db *gorm.DB, objects []interface{}, execFunc ExecFunc) (*g
 		// Add raw column names to use for iteration over each row later to get
 		// the correct order of columns.
 		columnNames = append(columnNames, k)
+	}
+
+	// Add columns not present in the object but injected by the options.
+	for k := range o.columnValues {
+		if _, ok := firstObjectFields[k]; !ok && k != o.checksumColumn {
+			columnNames = append(columnNames, k)
+		}
+	}
 
-		// Add as many placeholders (question marks) as there are columns.
-		placeholders = append(placeholders, "?")
+	if _, ok := firstObjectFields[o.checksumColumn]; !ok && o.checksumColumn != "" {
+		columnNames = append(columnNames, o.checksumColumn)
+	}
 
-		// Sort the column names to ensure the right order.
-		sort.Strings(columnNames)
+	if _, ok := firstObjectFields[o.expiryColumn]; !ok && o.expiryColumn != "" {
+		columnNames = append(columnNames, o.expiryColumn)
+	}
+
+	// Add the codec columns for compressed columns not present in the object.
+	codecColumns := o.codecColumns(firstObjectFields)
+	for k := range codecColumns {
+		if _, ok := firstObjectFields[k]; !ok {
+			columnNames = append(columnNames, k)
+		}
+	}
+
+	columnNames = o.omitNilPointerColumns(columnNames, objects)
+
+	// Sort the column names to ensure the right order.
+	o.sortColumns(scope, columnNames, firstObjectFields)
+
+	identifiers := append(strings.Split(scope.TableName(), "."), columnNames...)
+	if err := o.checkReserved(o.dialect, identifiers...); err != nil {
+		return nil, err
 	}
 
 	// We must setup quotedColumnNames after sorting columnNames since sorting
@@ -114,29 +428,82 @@ func scopeFromObjects(db *gorm.DB, objects []interface{}, execFunc ExecFunc) (*g
 	// field and values order.
 	for i := range columnNames {
 		quotedColumnNames = append(quotedColumnNames, scope.Quote(columnNames[i]))
+
+		column := Column{
+			Name:       columnNames[i],
+			QuotedName: quotedColumnNames[i],
+		}
+
+		if field, ok := firstObjectFields[columnNames[i]]; ok {
+			column.Field = field.StructField
+		}
+
+		columns = append(columns, column)
 	}
 
-	for _, r := range objects {
+	for i, r := range objects {
+		var (
+			placeholders []string
+			varIndexes   [][]int
+			rowIndex     = i
+		)
+
+		current = i
+
+		if rows != nil {
+			rowIndex = rows[i]
+		}
+
+		// Skip bind vars to get the raw placeholders (question marks) from
+		// AddToVars. Expressions such as gorm.Expr will return the whole
+		// expression as placeholder.
 		objectScope := db.NewScope(r)
+		objectScope.InstanceSet("skip_bindvar", true)
 
-		row, err := ObjectToMap(r)
+		row, err := o.objectToMap(r)
 		if err != nil {
 			return nil, err
 		}
 
-		for _, key := range columnNames {
-			field := row[key]
-			value := field.Field.Interface()
+		rowNow := bulkNow
+		if o.rowTimestamps {
+			rowNow = o.now()
+		}
+
+		for j, key := range columnNames {
+			var value interface{}
 
-			switch field.Struct.Name {
-			// Column CreatedAt and UpdatedAt with zero value will be set to same time
-			case "CreatedAt", "UpdatedAt":
-				if field.IsBlank {
-					value = bulkNow
-				}
+			if source, ok := codecColumns[key]; ok {
+				value = o.codec(row[source])
+			} else if key == o.checksumColumn {
+				value, err = o.checksum(row)
+			} else {
+				value, err = o.columnValue(key, row[key], rowIndex, r, rowNow)
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			if isNull(value) {
+				nullCount[key]++
+			}
+
+			if o.bindDefault(key, row[key]) {
+				value = gorm.Expr("DEFAULT")
+			} else {
+				value = o.columnExpr(key, value)
 			}
 
-			objectScope.AddToVars(value)
+			firstVar := len(objectScope.SQLVars)
+			placeholders = append(placeholders, objectScope.AddToVars(value))
+			varIndexes = append(varIndexes, rowRange(firstVar, len(objectScope.SQLVars)-firstVar))
+
+			if o.statementLog != nil {
+				for range varIndexes[j] {
+					rowSensitive = append(rowSensitive, isSensitiveField(columns[j].Field))
+				}
+			}
 		}
 
 		groups = append(
@@ -146,21 +513,107 @@ func scopeFromObjects(db *gorm.DB, objects []interface{}, execFunc ExecFunc) (*g
 
 		// Add object vars to the outer scope vars
 		scope.SQLVars = append(scope.SQLVars, objectScope.SQLVars...)
+		rowVars = append(rowVars, objectScope.SQLVars)
+		rowPlaceholders = append(rowPlaceholders, placeholders)
+		rowVarIndexes = append(rowVarIndexes, varIndexes)
+	}
+
+	if o.skipNullUpdates {
+		var nullColumns []string
+
+		for i, key := range columnNames {
+			if nullCount[key] == len(objects) {
+				nullColumns = append(nullColumns, quotedColumnNames[i])
+			}
+		}
+
+		scope.Set(nullColumnsKey, nullColumns)
+	}
+
+	for _, column := range columns {
+		if column.Name == o.versionColumn || isVersionField(column.Field) {
+			scope.Set(versionColumnKey, column.QuotedName)
+			break
+		}
+	}
+
+	if o.checksumColumn != "" {
+		scope.Set(checksumColumnKey, scope.Quote(o.checksumColumn))
+	}
+
+	scope.Set(excludedColumnsKey, o.updateExcluded(scope, columns))
+
+	// The SQL is built for all objects and not a single one.
+	current = -1
+
+	var (
+		shape   string
+		allVars []interface{}
+		cache   = o.statementCache
+	)
+
+	// The scope modifiers must be called for every statement.
+	if len(o.scopeModifiers) > 0 {
+		cache = nil
+	}
+
+	if cache != nil || o.statementLog != nil {
+		allVars = append([]interface{}{}, scope.SQLVars...)
 	}
 
-	execFunc(scope, quotedColumnNames, groups)
+	if cache != nil {
+		shape = shapeKey(scope, quotedColumnNames, groups)
+		if before, ok := cache.reuse(scope, shape); ok {
+			o.setSensitiveVars(scope, allVars, rowSensitive, before)
+			return scope, nil
+		}
+	}
+
+	ctx := &ExecContext{
+		Scope:             scope,
+		Objects:           objects,
+		Columns:           columns,
+		QuotedColumnNames: quotedColumnNames,
+		Groups:            groups,
+		RowVars:           rowVars,
+		Placeholders:      rowPlaceholders,
+		VarIndexes:        rowVarIndexes,
+		Dialect:           scope.Dialect(),
+	}
+
+	execFunc(ctx)
+	ctx.bindVars()
+
+	o.modifyScope(scope)
+	o.decorateSQL(scope)
+	cache.store(scope, shape, allVars, len(ctx.varsBefore))
+	o.setSensitiveVars(scope, allVars, rowSensitive, len(ctx.varsBefore))
 
 	return scope, nil
 }
 
+// rowRange returns a slice with the n indexes starting at offset.
+func rowRange(offset, n int) []int {
+	rows := make([]int, n)
+	for i := range rows {
+		rows[i] = offset + i
+	}
+
+	return rows
+}
+
 // ObjectToMap takes any object of type <T> and returns a map with the gorm
-// field DB name as key and the value as value. Special fields and actions
+// field DB name as key and the value as value. Fields implementing
+// driver.Valuer will be considered blank if the Valuer returns nil. Special
+// fields and actions
 //  * Foreign keys - Will be left out
 //  * Relationship fields - Will be left out
 //  * Fields marked to be ignored - Will be left out
+//  * Generated columns (tagged `gorm:"->"` or `bulk:"generated"`) - Will be left out
 //  * Fields named ID with auto increment - Will be left out
 //  * Fields named ID set as primary key with blank value - Will be left out
 //  * Blank fields with default value - Will be set to the default value
+//  * Fields tagged `bulk:"encrypt:column"` - Will use the column as key
 func ObjectToMap(object interface{}) (map[string]*gorm.Field, error) {
 	var (
 		attributes = map[string]*gorm.Field{}
@@ -170,26 +623,25 @@ func ObjectToMap(object interface{}) (map[string]*gorm.Field, error) {
 	rv := reflect.ValueOf(object)
 	if rv.Kind() == reflect.Ptr {
 		rv = rv.Elem()
-		object = rv.Interface()
 	}
 
 	if rv.Kind() != reflect.Struct {
 		return nil, errors.New("value must be kind of Struct")
 	}
 
-	for _, field := range (&gorm.Scope{Value: object}).Fields() {
-		// Exclude relational record because it's not directly contained in database columns
-		_, hasForeignKey := field.TagSettingsGet("FOREIGNKEY")
-		if hasForeignKey {
-			continue
-		}
-
-		if field.StructField.Relationship != nil {
-			continue
-		}
+	// Ensure we work with an addressable value so we can find driver.Valuer
+	// implementations with pointer receivers.
+	if !rv.CanAddr() {
+		ptr := reflect.New(rv.Type())
+		ptr.Elem().Set(rv)
+		rv = ptr.Elem()
+	}
 
-		if field.IsIgnored {
-			continue
+	// Foreign keys, relationships, ignored, generated and auto increment
+	// fields are already left out.
+	for _, field := range objectFields(rv) {
+		if valuer, ok := fieldValuer(field); ok {
+			field.IsBlank = valuerIsBlank(valuer)
 		}
 
 		// Let the DBM set the default values since these might be meta values
@@ -209,12 +661,10 @@ func ObjectToMap(object interface{}) (map[string]*gorm.Field, error) {
 			continue
 		}
 
-		// Check if auto increment is set (but not set to false). If so skip the
-		// field and let the DBM auto increment the value.
-		if value, ok := field.TagSettingsGet("AUTO_INCREMENT"); ok {
-			if !strings.EqualFold(value, "false") {
-				continue
-			}
+		// Encrypted fields may be written to another column.
+		if column, ok := encryptColumn(field.StructField); ok {
+			attributes[column] = field
+			continue
 		}
 
 		attributes[field.DBName] = field
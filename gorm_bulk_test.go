@@ -1,6 +1,8 @@
 package gormbulk
 
 import (
+	"database/sql"
+	"database/sql/driver"
 	"sort"
 	"testing"
 	"time"
@@ -59,6 +61,7 @@ func Test_scopeFromObjects(t *testing.T) {
 		description     string
 		slice           []interface{}
 		execFunc        ExecFunc
+		options         []Option
 		scopes          map[string]string
 		allVarsSame     bool
 		expectedSQL     string
@@ -92,6 +95,38 @@ func Test_scopeFromObjects(t *testing.T) {
 			scopes:      map[string]string{"gorm:insert_option": "ON DUPLICATE KEY UPDATE `foo` = VALUES(`foo`)"},
 			expectedSQL: "INSERT INTO `tests` (`bar`, `foo`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `foo` = VALUES(`foo`)",
 		},
+		{
+			description: "query option is appended",
+			slice: []interface{}{
+				test{"one", "two"},
+			},
+			execFunc:    InsertOnDuplicateKeyUpdateFunc,
+			options:     []Option{WithComment("import")},
+			scopes:      map[string]string{"gorm:query_option": "RETURNING *"},
+			expectedSQL: "/* import */ INSERT INTO `tests` (`bar`, `foo`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `bar` = VALUES(`bar`), `foo` = VALUES(`foo`) RETURNING *",
+		},
+		{
+			description: "query option is appended after insert option",
+			slice: []interface{}{
+				test{"one", "two"},
+			},
+			execFunc: InsertFunc,
+			scopes: map[string]string{
+				"gorm:insert_option": "ON CONFLICT DO NOTHING",
+				"gorm:query_option":  "RETURNING id",
+			},
+			expectedSQL: "INSERT INTO `tests` (`bar`, `foo`) VALUES (?, ?) ON CONFLICT DO NOTHING RETURNING id",
+		},
+		{
+			description: "insert option from option takes precedence",
+			slice: []interface{}{
+				test{"one", "two"},
+			},
+			execFunc:    InsertFunc,
+			options:     []Option{WithInsertOption("ON DUPLICATE KEY UPDATE `bar` = VALUES(`bar`)")},
+			scopes:      map[string]string{"gorm:insert_option": "ON DUPLICATE KEY UPDATE `foo` = VALUES(`foo`)"},
+			expectedSQL: "INSERT INTO `tests` (`bar`, `foo`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `bar` = VALUES(`bar`)",
+		},
 		{
 			description: "pointers are de-references OK",
 			slice: []interface{}{
@@ -256,16 +291,204 @@ func Test_scopeFromObjects(t *testing.T) {
 			expectedSQLVars: []interface{}{"Kept", "Changed"},
 			expectedSQL:     "INSERT INTO `tttts` (`ThisIsPreserved`, `normalize_field`) VALUES (?, ?)",
 		},
+		{
+			description: "valid sql.Null* zero values are not blank",
+			slice: []interface{}{
+				struct {
+					Foo sql.NullString `gorm:"default:'foo'"`
+					Bar sql.NullInt64  `gorm:"default:1"`
+				}{
+					Foo: sql.NullString{Valid: true},
+					Bar: sql.NullInt64{Valid: false},
+				},
+			},
+			execFunc:        InsertFunc,
+			expectedSQL:     "INSERT INTO `` (`foo`) VALUES (?)",
+			expectedSQLVars: []interface{}{sql.NullString{Valid: true}},
+		},
+		{
+			description: "columns with only NULL values are not updated",
+			slice: []interface{}{
+				struct {
+					Foo string
+					Bar sql.NullString
+					Baz sql.NullString
+				}{
+					Foo: "foo",
+					Bar: sql.NullString{String: "bar", Valid: true},
+				},
+				struct {
+					Foo string
+					Bar sql.NullString
+					Baz sql.NullString
+				}{
+					Foo: "foo",
+				},
+			},
+			execFunc:    InsertOnDuplicateKeyUpdateFunc,
+			options:     []Option{WithSkipNullUpdates()},
+			expectedSQL: "INSERT INTO `` (`bar`, `baz`, `foo`) VALUES (?, ?, ?), (?, ?, ?) ON DUPLICATE KEY UPDATE `bar` = VALUES(`bar`), `foo` = VALUES(`foo`)",
+		},
+		{
+			description: "no-op update when all columns only have NULL values",
+			slice: []interface{}{
+				struct {
+					Bar sql.NullString
+					Baz sql.NullString
+				}{},
+			},
+			execFunc:    InsertOnDuplicateKeyUpdateFunc,
+			options:     []Option{WithSkipNullUpdates()},
+			expectedSQL: "INSERT INTO `` (`bar`, `baz`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `id` = `id`",
+		},
+		{
+			description: "column expression wraps placeholder",
+			slice: []interface{}{
+				struct {
+					Location string
+					Foo      string
+				}{
+					Location: "POINT(1 2)",
+					Foo:      "foo",
+				},
+				struct {
+					Location string
+					Foo      string
+				}{
+					Location: "POINT(3 4)",
+					Foo:      "bar",
+				},
+			},
+			execFunc:        InsertFunc,
+			options:         []Option{WithColumnExpr("location", "ST_GeomFromText(?)")},
+			expectedSQL:     "INSERT INTO `` (`foo`, `location`) VALUES (?, ST_GeomFromText(?)), (?, ST_GeomFromText(?))",
+			expectedSQLVars: []interface{}{"foo", "POINT(1 2)", "bar", "POINT(3 4)"},
+		},
+		{
+			description: "serializer returning expression with multiple vars",
+			slice: []interface{}{
+				struct {
+					Point serializerTestType
+				}{
+					Point: serializerTestType{1, 2},
+				},
+			},
+			execFunc: InsertFunc,
+			options: []Option{
+				WithSerializer(serializerTestType{}, func(v interface{}) (interface{}, error) {
+					p := v.(serializerTestType)
+					return gorm.Expr("POINT(?, ?)", p.A, p.B), nil
+				}),
+			},
+			expectedSQL:     "INSERT INTO `` (`point`) VALUES (POINT(?, ?))",
+			expectedSQLVars: []interface{}{1, 2},
+		},
+		{
+			description: "comments are prepended",
+			slice: []interface{}{
+				test{"one", "two"},
+			},
+			execFunc: InsertFunc,
+			options: []Option{
+				WithComment("job:nightly-import team:data"),
+				WithCommentTags(map[string]string{
+					"route":     "/import?x=1",
+					"framework": "gorm bulk",
+				}),
+			},
+			expectedSQL: "/* job:nightly-import team:data */ /*framework='gorm%20bulk',route='%2Fimport%3Fx%3D1'*/ INSERT INTO `tests` (`bar`, `foo`) VALUES (?, ?)",
+		},
+		{
+			description: "optimizer hints added after first keyword",
+			slice: []interface{}{
+				test{"one", "two"},
+			},
+			execFunc: InsertFunc,
+			options: []Option{
+				WithComment("import"),
+				WithOptimizerHint("SET_VAR(foreign_key_checks=OFF)", "NO_INDEX_MERGE(t1)"),
+			},
+			expectedSQL: "/* import */ INSERT /*+ SET_VAR(foreign_key_checks=OFF) NO_INDEX_MERGE(t1) */ INTO `tests` (`bar`, `foo`) VALUES (?, ?)",
+		},
+		{
+			description: "generated columns are left out",
+			slice: []interface{}{
+				struct {
+					Foo      string
+					FullName string `gorm:"->"`
+					Upper    string `bulk:"generated"`
+				}{
+					Foo: "foo",
+				},
+			},
+			execFunc:    InsertOnDuplicateKeyUpdateFunc,
+			expectedSQL: "INSERT INTO `` (`foo`) VALUES (?) ON DUPLICATE KEY UPDATE `foo` = VALUES(`foo`)",
+		},
+		{
+			description: "version column from tag only updates newer rows",
+			slice: []interface{}{
+				struct {
+					Foo string
+					Rev int `bulk:"version"`
+					Bar string
+				}{
+					Foo: "foo",
+					Rev: 2,
+				},
+			},
+			execFunc:    InsertOnDuplicateKeyUpdateFunc,
+			expectedSQL: "INSERT INTO `` (`bar`, `foo`, `rev`) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE `bar` = IF(VALUES(`rev`) > `rev`, VALUES(`bar`), `bar`), `foo` = IF(VALUES(`rev`) > `rev`, VALUES(`foo`), `foo`), `rev` = IF(VALUES(`rev`) > `rev`, VALUES(`rev`), `rev`)",
+		},
+		{
+			description: "version column from option",
+			slice: []interface{}{
+				struct {
+					Version int
+					Foo     string
+				}{
+					Foo:     "foo",
+					Version: 2,
+				},
+			},
+			execFunc:    InsertOnDuplicateKeyUpdateFunc,
+			options:     []Option{WithVersionColumn("version")},
+			expectedSQL: "INSERT INTO `` (`foo`, `version`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `foo` = IF(VALUES(`version`) > `version`, VALUES(`foo`), `foo`), `version` = IF(VALUES(`version`) > `version`, VALUES(`version`), `version`)",
+		},
+		{
+			description: "table prefix and suffix",
+			slice: []interface{}{
+				test{"one", "two"},
+			},
+			execFunc: InsertFunc,
+			options: []Option{
+				WithTablePrefix("tenant_42_"),
+				WithTableSuffix("_v2"),
+			},
+			expectedSQL: "INSERT INTO `tenant_42_tests_v2` (`bar`, `foo`) VALUES (?, ?)",
+		},
+		{
+			description: "columns with only NULL values are updated by default",
+			slice: []interface{}{
+				struct {
+					Foo string
+					Bar sql.NullString
+				}{
+					Foo: "foo",
+				},
+			},
+			execFunc:    InsertOnDuplicateKeyUpdateFunc,
+			expectedSQL: "INSERT INTO `` (`bar`, `foo`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `bar` = VALUES(`bar`), `foo` = VALUES(`foo`)",
+		},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.description, func(t *testing.T) {
 			db := gdb
 			for k, v := range tc.scopes {
-				db = gdb.Set(k, v)
+				db = db.Set(k, v)
 			}
 
-			scope, err := scopeFromObjects(db, tc.slice, tc.execFunc)
+			scope, err := scopeFromObjects(db, tc.slice, nil, tc.execFunc.toV2(), newOptions(tc.options...))
 
 			if tc.errContains != "" {
 				require.Nil(t, scope)
@@ -310,12 +533,15 @@ func TestBulkExecChunk(t *testing.T) {
 	}
 
 	cases := []struct {
-		description      string
-		execFunc         ExecFunc
-		slices           []interface{}
-		chunkSize        int
-		expectedMockFunc func(mock sqlmock.Sqlmock)
-		countErrors      int
+		description          string
+		execFunc             ExecFunc
+		slices               []interface{}
+		chunkSize            int
+		options              []Option
+		expectedMockFunc     func(mock sqlmock.Sqlmock)
+		countErrors          int
+		expectedErr          error
+		expectedRowsAffected int64
 	}{
 		{
 			description: "errors returned",
@@ -379,20 +605,91 @@ func TestBulkExecChunk(t *testing.T) {
 					WillReturnResult(sqlmock.NewResult(0, 0))
 			},
 		},
+		{
+			description: "idempotency keys use index in all objects",
+			execFunc:    InsertIgnoreFunc,
+			slices: []interface{}{
+				test{Foo: "one", Bar: "two"},
+				test{Foo: "one", Bar: "two"},
+				test{Foo: "one", Bar: "two"},
+			},
+			chunkSize: 2,
+			options:   []Option{WithIdempotencyKey("idempotency_key", "job")},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT IGNORE INTO `tests` \\(`bar`, `foo`, `idempotency_key`\\)").
+					WithArgs("two", "one", "job:0", "two", "one", "job:1").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+
+				mock.ExpectExec("INSERT IGNORE INTO `tests` \\(`bar`, `foo`, `idempotency_key`\\)").
+					WithArgs("two", "one", "job:2").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			description:      "zero chunk size returns error",
+			execFunc:         InsertFunc,
+			slices:           []interface{}{test{Foo: "one", Bar: "two"}},
+			chunkSize:        0,
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {},
+			expectedErr:      &ChunkSizeError{Size: 0},
+		},
+		{
+			description:      "negative chunk size returns error",
+			execFunc:         InsertFunc,
+			slices:           []interface{}{test{Foo: "one", Bar: "two"}},
+			chunkSize:        -1,
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {},
+			expectedErr:      &ChunkSizeError{Size: -1},
+		},
+		{
+			description:      "empty slice is a no-op",
+			execFunc:         InsertFunc,
+			slices:           []interface{}{},
+			chunkSize:        2,
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {},
+		},
+		{
+			description: "rows affected summed in result",
+			execFunc:    InsertFunc,
+			slices: []interface{}{
+				test{Foo: "one", Bar: "two"},
+				test{Foo: "one", Bar: "two"},
+				test{Foo: "one", Bar: "two"},
+			},
+			chunkSize: 2,
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `tests`").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+
+				mock.ExpectExec("INSERT INTO `tests`").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedRowsAffected: 3,
+		},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.description, func(t *testing.T) {
 			tc.expectedMockFunc(mock)
 
-			err := BulkExecChunk(gdb, tc.slices, tc.execFunc, tc.chunkSize)
+			result := &Result{}
+			tc.options = append(tc.options, WithResult(result))
+
+			err := BulkExecChunk(gdb, tc.slices, tc.execFunc, tc.chunkSize, tc.options...)
 
 			if tc.countErrors > 0 {
 				assert.Len(t, err, tc.countErrors)
 				return
 			}
 
+			if tc.expectedErr != nil {
+				assert.Equal(t, []error{tc.expectedErr}, err)
+				return
+			}
+
 			require.Nil(t, err)
+			require.NoError(t, mock.ExpectationsWereMet())
+			assert.Equal(t, tc.expectedRowsAffected, result.RowsAffected)
 		})
 	}
 }
@@ -441,7 +738,7 @@ func Test_columnOrder(t *testing.T) {
 				t.Logf("sort after quite yields different result: %s", columns)
 			}
 
-			scope, err := scopeFromObjects(gdb, tc.slices, scopeFunc)
+			scope, err := scopeFromObjects(gdb, tc.slices, nil, ExecFunc(scopeFunc).toV2(), newOptions())
 
 			require.NoError(t, err)
 			require.NotNil(t, scope)
@@ -582,7 +879,7 @@ func Test_Timestamps(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.description, func(t *testing.T) {
-			scope, err := scopeFromObjects(gdb, tc.slice, InsertFunc)
+			scope, err := scopeFromObjects(gdb, tc.slice, nil, ExecFunc(InsertFunc).toV2(), newOptions())
 
 			require.NotNil(t, scope)
 			require.NoError(t, err)
@@ -593,3 +890,48 @@ func Test_Timestamps(t *testing.T) {
 		})
 	}
 }
+
+type panicValuer struct {
+	v string
+}
+
+func (p *panicValuer) Value() (driver.Value, error) {
+	return p.v, nil
+}
+
+func Test_scopeFromObjectsPanic(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	type test struct {
+		Name   string
+		Valuer driver.Valuer
+	}
+
+	var nilValuer *panicValuer
+
+	objects := []interface{}{
+		test{Name: "a", Valuer: &panicValuer{"a"}},
+		test{Name: "b", Valuer: &panicValuer{"b"}},
+		test{Name: "c", Valuer: nilValuer},
+	}
+
+	scope, err := scopeFromObjects(gdb, objects, []int{3, 5, 7}, ExecFunc(InsertFunc).toV2(), newOptions())
+	require.Error(t, err)
+	assert.Nil(t, scope)
+
+	rowErr, ok := err.(*RowError)
+	require.True(t, ok)
+	assert.Equal(t, 7, rowErr.Row)
+	assert.Contains(t, rowErr.Error(), "panic while building SQL")
+
+	_, err = scopeFromObjects(gdb, objects[:2], nil, func(*ExecContext) { panic("oops") }, newOptions())
+	require.Error(t, err)
+
+	_, ok = err.(*RowError)
+	assert.False(t, ok)
+	assert.Equal(t, "panic while building SQL: oops", err.Error())
+}
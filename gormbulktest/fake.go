@@ -0,0 +1,102 @@
+package gormbulktest
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+
+	gormbulk "github.com/bombsimon/gorm-bulk"
+	"github.com/jinzhu/gorm"
+)
+
+// errNoDatabase is returned when a *gorm.DB created by Fake.DB is used
+// directly instead of through the fake executor.
+var errNoDatabase = errors.New("gormbulktest: no database, use the fake executor")
+
+// Fake is an in-memory gormbulk.Executor recording all statements instead of
+// executing them. This makes it possible to unit test code using gorm-bulk
+// without sqlmock or a database. The statements are recorded as passed to
+// db.Exec, with `?` placeholders for all dialects.
+//
+//  fake := gormbulktest.NewFake()
+//  db, _ := fake.DB("mysql")
+//
+//  err := gormbulk.BulkInsert(db, objects, fake.Option())
+//
+//  statements := fake.Statements()
+type Fake struct {
+	// Err is returned for every statement executed if set.
+	Err error
+
+	mu         sync.Mutex
+	statements []Statement
+}
+
+// NewFake returns a new Fake.
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+// Exec implements gormbulk.Executor.
+func (f *Fake) Exec(_ *gorm.DB, sql string, vars ...interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	args := make([]driver.Value, len(vars))
+	for i := range vars {
+		args[i] = vars[i]
+	}
+
+	f.statements = append(f.statements, Statement{SQL: sql, Args: args})
+
+	return f.Err
+}
+
+// Option returns the option to pass to the bulk functions to execute all
+// statements with the fake.
+func (f *Fake) Option() gormbulk.Option {
+	return gormbulk.WithExecutor(f)
+}
+
+// DB returns a *gorm.DB for the dialect which isn't connected to any
+// database. Statements not executed through the fake, such as transactions,
+// will fail.
+func (f *Fake) DB(dialect string) (*gorm.DB, error) {
+	return gorm.Open(dialect, noDatabase{})
+}
+
+// Statements returns all statements recorded.
+func (f *Fake) Statements() []Statement {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Statement{}, f.statements...)
+}
+
+// Reset removes all statements recorded.
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.statements = nil
+}
+
+// noDatabase implements gorm.SQLCommon and fails for all statements.
+type noDatabase struct{}
+
+func (noDatabase) Exec(string, ...interface{}) (sql.Result, error) {
+	return nil, errNoDatabase
+}
+
+func (noDatabase) Prepare(string) (*sql.Stmt, error) {
+	return nil, errNoDatabase
+}
+
+func (noDatabase) Query(string, ...interface{}) (*sql.Rows, error) {
+	return nil, errNoDatabase
+}
+
+func (noDatabase) QueryRow(string, ...interface{}) *sql.Row {
+	return nil
+}
@@ -0,0 +1,42 @@
+package gormbulktest
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	gormbulk "github.com/bombsimon/gorm-bulk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFake(t *testing.T) {
+	fake := NewFake()
+
+	db, err := fake.DB("postgres")
+	require.NoError(t, err)
+
+	objects := []interface{}{
+		user{Name: "a", Age: 1},
+		user{Name: "b", Age: 2},
+	}
+
+	require.Empty(t, gormbulk.BulkExecChunk(db, objects, gormbulk.InsertFunc, 1, fake.Option()))
+
+	statements := fake.Statements()
+	require.Len(t, statements, 2)
+
+	assert.Equal(t, `INSERT INTO "users" ("age", "email", "name") VALUES (?, ?, ?)`, statements[0].SQL)
+	assert.Equal(t, 2, statements[1].Args[0])
+	assert.Equal(t, driver.Value("b"), statements[1].Args[2])
+
+	fake.Reset()
+	assert.Empty(t, fake.Statements())
+
+	fake.Err = assert.AnError
+
+	assert.Equal(t, assert.AnError, gormbulk.BulkInsert(db, objects, fake.Option()))
+	assert.Len(t, fake.Statements(), 1)
+
+	// Statements not executed through the fake fails.
+	assert.Equal(t, errNoDatabase, gormbulk.BulkInsert(db, objects))
+}
@@ -0,0 +1,122 @@
+package gormbulktest
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gormbulk "github.com/bombsimon/gorm-bulk"
+)
+
+// UpdateGoldenEnv is the environment variable which, when set to a non empty
+// value, makes AssertGolden write the golden file instead of comparing it.
+const UpdateGoldenEnv = "GORMBULK_UPDATE_GOLDEN"
+
+// SnapshotTime is the time used for timestamps while rendering a snapshot.
+var SnapshotTime = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Snapshot renders all statements gorm-bulk executes for the objects, with
+// their vars, in a deterministic format suitable for golden file tests.
+// Timestamps are rendered as SnapshotTime unless another time is set with
+// gormbulk.WithNowFunc or gormbulk.WithBatchTime.
+//
+//  INSERT INTO `users` (`age`, `name`) VALUES (?, ?)
+//  -- 1: (int) 42
+//  -- 2: (string) "Bob"
+func Snapshot(dialect string, objects []interface{}, execFunc gormbulk.ExecFunc, opts ...gormbulk.Option) (string, error) {
+	nowFunc := gormbulk.WithNowFunc(func() time.Time { return SnapshotTime })
+
+	statements, err := Statements(dialect, objects, execFunc, append([]gormbulk.Option{nowFunc}, opts...)...)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+
+	for i, statement := range statements {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+
+		fmt.Fprintf(&buf, "%s\n", statement.SQL)
+
+		for j, arg := range statement.Args {
+			value, err := formatArg(arg)
+			if err != nil {
+				return "", err
+			}
+
+			fmt.Fprintf(&buf, "-- %d: %s\n", j+1, value)
+		}
+	}
+
+	return buf.String(), nil
+}
+
+// AssertGolden renders a snapshot (see Snapshot) for the objects and compares
+// it with the content of the golden file. If the environment variable
+// UpdateGoldenEnv is set, the golden file is written instead.
+func AssertGolden(t testing.TB, path, dialect string, objects []interface{}, execFunc gormbulk.ExecFunc, opts ...gormbulk.Option) {
+	t.Helper()
+
+	snapshot, err := Snapshot(dialect, objects, execFunc, opts...)
+	if err != nil {
+		t.Fatalf("could not render snapshot: %v", err)
+	}
+
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("could not create golden file directory: %v", err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte(snapshot), 0644); err != nil {
+			t.Fatalf("could not write golden file: %v", err)
+		}
+
+		return
+	}
+
+	golden, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read golden file (set %s=1 to create it): %v", UpdateGoldenEnv, err)
+	}
+
+	if string(golden) != snapshot {
+		t.Errorf(
+			"snapshot differs from golden file %s (set %s=1 to update it)\n--- golden\n%s\n--- snapshot\n%s",
+			path, UpdateGoldenEnv, strings.TrimSpace(string(golden)), strings.TrimSpace(snapshot),
+		)
+	}
+}
+
+// formatArg formats the arg with its type. Values implementing driver.Valuer
+// are formatted as the value they return.
+func formatArg(arg interface{}) (string, error) {
+	if valuer, ok := arg.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+
+		arg = value
+	}
+
+	switch v := arg.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return fmt.Sprintf("(string) %q", v), nil
+	case []byte:
+		return fmt.Sprintf("([]byte) %q", v), nil
+	case time.Time:
+		return fmt.Sprintf("(time.Time) %s", v.Format(time.RFC3339Nano)), nil
+	}
+
+	return fmt.Sprintf("(%T) %v", arg, arg), nil
+}
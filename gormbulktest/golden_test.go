@@ -0,0 +1,42 @@
+package gormbulktest
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	gormbulk "github.com/bombsimon/gorm-bulk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	type event struct {
+		Name      string
+		Note      sql.NullString
+		Payload   []byte
+		CreatedAt time.Time
+	}
+
+	objects := []interface{}{
+		event{Name: "a", Payload: []byte("x")},
+		event{Name: "b", Note: sql.NullString{String: "note", Valid: true}},
+	}
+
+	snapshot, err := Snapshot("mysql", objects, gormbulk.InsertFunc)
+	require.NoError(t, err)
+
+	expected := "INSERT INTO `events` (`created_at`, `name`, `note`, `payload`) VALUES (?, ?, ?, ?), (?, ?, ?, ?)\n" +
+		"-- 1: (time.Time) 2000-01-01T00:00:00Z\n" +
+		"-- 2: (string) \"a\"\n" +
+		"-- 3: NULL\n" +
+		"-- 4: ([]byte) \"x\"\n" +
+		"-- 5: (time.Time) 2000-01-01T00:00:00Z\n" +
+		"-- 6: (string) \"b\"\n" +
+		"-- 7: (string) \"note\"\n" +
+		"-- 8: ([]byte) \"\"\n"
+
+	assert.Equal(t, expected, snapshot)
+
+	AssertGolden(t, "testdata/events.golden", "mysql", objects, gormbulk.InsertFunc)
+}
@@ -0,0 +1,106 @@
+// Package gormbulktest contains helpers to test code using gorm-bulk with
+// sqlmock. Instead of maintaining the expected SQL and args by hand, the
+// helpers build them the same way as gorm-bulk does for the objects.
+//
+//  gormbulktest.ExpectBulkExec(t, mock, db, objects, gormbulk.InsertFunc)
+//
+//  err := gormbulk.BulkInsert(db, objects)
+//
+// Blank CreatedAt and UpdatedAt fields are bound to the time returned by
+// gorm.NowFunc so it must return a fixed time when such fields are used, or
+// the same gormbulk.WithNowFunc must be passed to both calls.
+package gormbulktest
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	gormbulk "github.com/bombsimon/gorm-bulk"
+	"github.com/jinzhu/gorm"
+)
+
+// Statement is a SQL statement with its args.
+type Statement struct {
+	SQL  string
+	Args []driver.Value
+}
+
+// Statements returns all statements gorm-bulk executes for the objects with
+// BulkExec for the dialect, i.e. `mysql`. Options executing statements in a
+// transaction, such as WithAuditTable, are not supported.
+func Statements(dialect string, objects []interface{}, execFunc gormbulk.ExecFunc, opts ...gormbulk.Option) ([]Statement, error) {
+	r := &recorder{}
+
+	db, err := gorm.Open(dialect, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := gormbulk.BulkExec(db, objects, execFunc, opts...); err != nil {
+		return nil, err
+	}
+
+	return r.statements, nil
+}
+
+// ExpectBulkExec will add one ExpectExec to the mock for each statement
+// gorm-bulk executes for the objects with BulkExec, matching both the SQL and
+// the args. The dialect is taken from db. Each expectation returns a result
+// with all objects affected which may be changed with the returned
+// expectations. The test fails if the statements can't be built.
+func ExpectBulkExec(t testing.TB, mock sqlmock.Sqlmock, db *gorm.DB, objects []interface{}, execFunc gormbulk.ExecFunc, opts ...gormbulk.Option) []*sqlmock.ExpectedExec {
+	t.Helper()
+
+	statements, err := Statements(db.Dialect().GetName(), objects, execFunc, opts...)
+	if err != nil {
+		t.Fatalf("could not build bulk statements: %v", err)
+	}
+
+	expectations := make([]*sqlmock.ExpectedExec, len(statements))
+
+	for i, statement := range statements {
+		expectations[i] = mock.ExpectExec(ExpectedSQL(statement.SQL)).
+			WithArgs(statement.Args...).
+			WillReturnResult(sqlmock.NewResult(0, int64(len(objects))))
+	}
+
+	return expectations
+}
+
+// ExpectedSQL returns a regular expression matching exactly the SQL.
+func ExpectedSQL(sql string) string {
+	return "^" + regexp.QuoteMeta(sql) + "$"
+}
+
+// recorder implements gorm.SQLCommon and records all executed statements
+// instead of executing them.
+type recorder struct {
+	statements []Statement
+}
+
+func (r *recorder) Exec(query string, args ...interface{}) (sql.Result, error) {
+	values := make([]driver.Value, len(args))
+	for i := range args {
+		values[i] = args[i]
+	}
+
+	r.statements = append(r.statements, Statement{SQL: query, Args: values})
+
+	return driver.RowsAffected(0), nil
+}
+
+func (r *recorder) Prepare(string) (*sql.Stmt, error) {
+	return nil, errors.New("prepare is not supported")
+}
+
+func (r *recorder) Query(string, ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("query is not supported")
+}
+
+func (r *recorder) QueryRow(string, ...interface{}) *sql.Row {
+	return nil
+}
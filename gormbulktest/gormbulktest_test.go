@@ -0,0 +1,83 @@
+package gormbulktest
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	gormbulk "github.com/bombsimon/gorm-bulk"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	Name  string
+	Email sql.NullString
+	Age   int
+}
+
+func TestStatements(t *testing.T) {
+	objects := []interface{}{
+		user{Name: "a", Age: 1},
+		user{Name: "b", Age: 2},
+	}
+
+	statements, err := Statements("postgres", objects, gormbulk.InsertFunc, gormbulk.WithComment("test"))
+	require.NoError(t, err)
+	require.Len(t, statements, 1)
+
+	assert.Equal(t, `/* test */ INSERT INTO "users" ("age", "email", "name") VALUES ($1, $2, $3), ($4, $5, $6)`, statements[0].SQL)
+	assert.Len(t, statements[0].Args, 6)
+	assert.Equal(t, 1, statements[0].Args[0])
+	assert.Equal(t, "b", statements[0].Args[5])
+}
+
+func TestExpectBulkExec(t *testing.T) {
+	objects := []interface{}{
+		user{Name: "a", Email: sql.NullString{String: "a@example.com", Valid: true}, Age: 1},
+		user{Name: "b", Age: 2},
+	}
+
+	for _, dialect := range []string{"mysql", "postgres"} {
+		t.Run(dialect, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open(dialect, db)
+			require.NoError(t, err)
+
+			ExpectBulkExec(t, mock, gdb, objects, gormbulk.InsertFunc)
+
+			require.NoError(t, gormbulk.BulkInsert(gdb, objects))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+
+	t.Run("mismatching args fails", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+
+		gdb, err := gorm.Open("mysql", db)
+		require.NoError(t, err)
+
+		ExpectBulkExec(t, mock, gdb, objects, gormbulk.InsertFunc)
+
+		assert.Error(t, gormbulk.BulkInsert(gdb, []interface{}{user{Name: "c"}, user{Name: "d"}}))
+	})
+
+	t.Run("override result", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+
+		gdb, err := gorm.Open("mysql", db)
+		require.NoError(t, err)
+
+		expectations := ExpectBulkExec(t, mock, gdb, objects, gormbulk.InsertIgnoreFunc)
+		require.Len(t, expectations, 1)
+
+		expectations[0].WillReturnError(assert.AnError)
+
+		assert.Equal(t, assert.AnError, gormbulk.BulkInsertIgnore(gdb, objects))
+	})
+}
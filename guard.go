@@ -0,0 +1,72 @@
+package gormbulk
+
+import "context"
+
+// Guard guards the bulk operation with a distributed lock or leader check,
+// i.e. backed by etcd or Redis, so the operation is only executed by one of
+// multiple replicas running the same importer.
+type Guard interface {
+	// Acquire is called before the bulk operation is started. Returning an
+	// error aborts the operation before any statement is executed.
+	Acquire(ctx context.Context) error
+
+	// Check is called before each chunk is started. Returning an error, i.e.
+	// if the leadership or lock is lost, stops the operation and the rows not
+	// attempted are returned in a *NotAttemptedError.
+	Check(ctx context.Context) error
+
+	// Release is called when the bulk operation is done, even if it failed.
+	Release(ctx context.Context) error
+}
+
+// WithGuard will acquire the guard before the bulk operation, check it before
+// each chunk and release it when done. The guard is acquired before the
+// connection is pinned and any advisory lock is taken and released after the
+// teardown statements are executed. The context passed is the one set with
+// WithContext, if any. Like WithPinnedConnection the guard is used by
+// BulkExec, BulkExecChunk, BulkExecAsync, BulkSync, SeedTable and the
+// functions reading objects from a reader, and by a BulkWriter for each call
+// executing any chunks.
+func WithGuard(guard Guard) Option {
+	return func(o *options) {
+		o.guard = guard
+	}
+}
+
+// acquireGuard acquires the guard set with WithGuard, if any. The returned
+// function must be called to release the guard.
+func (o *options) acquireGuard() (func() error, error) {
+	if o.guard == nil {
+		return func() error { return nil }, nil
+	}
+
+	ctx := o.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := o.guard.Acquire(ctx); err != nil {
+		return nil, err
+	}
+
+	o.guarded = true
+
+	return func() error {
+		o.guarded = false
+		return o.guard.Release(ctx)
+	}, nil
+}
+
+// checkGuard checks the guard if acquired.
+func (o *options) checkGuard() error {
+	if !o.guarded {
+		return nil
+	}
+
+	ctx := o.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return o.guard.Check(ctx)
+}
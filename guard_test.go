@@ -0,0 +1,117 @@
+package gormbulk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGuard records all calls, including the statements executed, and fails
+// on the call configured.
+type fakeGuard struct {
+	calls      []string
+	acquireErr error
+	checkErrAt int
+	checkErr   error
+	checks     int
+}
+
+func (g *fakeGuard) Acquire(context.Context) error {
+	g.calls = append(g.calls, "acquire")
+	return g.acquireErr
+}
+
+func (g *fakeGuard) Check(context.Context) error {
+	g.calls = append(g.calls, "check")
+
+	g.checks++
+	if g.checks == g.checkErrAt {
+		return g.checkErr
+	}
+
+	return nil
+}
+
+func (g *fakeGuard) Release(context.Context) error {
+	g.calls = append(g.calls, "release")
+	return nil
+}
+
+func (g *fakeGuard) Exec(_ *gorm.DB, sql string, _ ...interface{}) error {
+	g.calls = append(g.calls, sql)
+	return nil
+}
+
+func TestWithGuard(t *testing.T) {
+	type item struct {
+		Name string
+	}
+
+	var (
+		errNotLeader = errors.New("not leader")
+		objects      = []interface{}{item{"a"}, item{"b"}, item{"c"}}
+	)
+
+	cases := []struct {
+		description   string
+		guard         *fakeGuard
+		opts          []Option
+		expectedCalls []string
+		expectedErrs  []error
+	}{
+		{
+			description: "guard acquired, checked and released around chunks",
+			guard:       &fakeGuard{},
+			opts:        []Option{WithSetupSQL("SET unique_checks = 0")},
+			expectedCalls: []string{
+				"acquire",
+				"SET unique_checks = 0",
+				"check",
+				"INSERT INTO `items` (`name`) VALUES (?), (?)",
+				"check",
+				"INSERT INTO `items` (`name`) VALUES (?)",
+				"release",
+			},
+		},
+		{
+			description:   "nothing executed if not acquired",
+			guard:         &fakeGuard{acquireErr: errNotLeader},
+			expectedCalls: []string{"acquire"},
+			expectedErrs:  []error{errNotLeader},
+		},
+		{
+			description: "stopped when check fails",
+			guard:       &fakeGuard{checkErrAt: 2, checkErr: errNotLeader},
+			expectedCalls: []string{
+				"acquire",
+				"check",
+				"INSERT INTO `items` (`name`) VALUES (?), (?)",
+				"check",
+				"release",
+			},
+			expectedErrs: []error{&NotAttemptedError{Rows: []int{2}, Err: errNotLeader}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, _, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			opts := append([]Option{WithGuard(tc.guard), WithExecutor(tc.guard)}, tc.opts...)
+
+			errs := BulkExecChunk(gdb, objects, InsertFunc, 2, opts...)
+
+			assert.Equal(t, tc.expectedErrs, errs)
+			assert.Equal(t, tc.expectedCalls, tc.guard.calls)
+		})
+	}
+}
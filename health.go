@@ -0,0 +1,60 @@
+package gormbulk
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ErrPoolSaturated is the error in a *HealthCheckError when the connection
+// pool has fewer free connections than required, see WithHealthProbe.
+var ErrPoolSaturated = errors.New("not enough free connections")
+
+// WithHealthProbe will ping the database and check that the connection pool
+// has at least minFreeConnections free connections before starting a bulk
+// operation with multiple chunks, i.e. with BulkExecChunk or the functions
+// reading objects from a reader. This fails fast with a *HealthCheckError
+// instead of half completing an import against a saturated pool. The free
+// connections are only checked if the pool has a max number of open
+// connections. Nothing is checked if the db passed is a transaction.
+func WithHealthProbe(minFreeConnections int) Option {
+	return func(o *options) {
+		o.healthProbe = true
+		o.minFreeConnections = minFreeConnections
+	}
+}
+
+// probeHealth checks the free connections and pings the database when using
+// WithHealthProbe.
+func (o *options) probeHealth(db *gorm.DB) error {
+	if !o.healthProbe {
+		return nil
+	}
+
+	sqlDB, ok := db.CommonDB().(*sql.DB)
+	if !ok {
+		return nil
+	}
+
+	// The pool is checked before pinging since the ping would wait for a
+	// free connection.
+	if stats := sqlDB.Stats(); stats.MaxOpenConnections > 0 {
+		free := stats.MaxOpenConnections - stats.InUse
+		if free < o.minFreeConnections {
+			return &HealthCheckError{Free: free, Err: ErrPoolSaturated}
+		}
+	}
+
+	ctx := o.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return &HealthCheckError{Free: -1, Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package gormbulk
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithHealthProbe(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	cases := []struct {
+		description  string
+		setup        func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock)
+		expectedErrs []error
+	}{
+		{
+			description: "healthy pool",
+			setup: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock) {
+				db.SetMaxOpenConns(2)
+
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?)")).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			description: "unlimited pool",
+			setup: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?)")).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			description: "saturated pool",
+			setup: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock) {
+				db.SetMaxOpenConns(1)
+
+				_, err := db.Conn(context.Background())
+				require.NoError(t, err)
+			},
+			expectedErrs: []error{&HealthCheckError{Free: 0, Err: ErrPoolSaturated}},
+		},
+		{
+			description: "ping failed",
+			setup: func(t *testing.T, db *sql.DB, mock sqlmock.Sqlmock) {
+				mock.ExpectClose()
+				require.NoError(t, db.Close())
+			},
+			expectedErrs: []error{&HealthCheckError{Free: -1, Err: errors.New("sql: database is closed")}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			tc.setup(t, db, mock)
+
+			errs := BulkExecChunk(gdb, []interface{}{user{"a"}}, InsertFunc, 2, WithHealthProbe(1))
+
+			assert.Equal(t, tc.expectedErrs, errs)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package gormbulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+
+	"github.com/jinzhu/gorm"
+)
+
+// BulkInsertJSONLines will read all lines from the newline delimited JSON
+// (NDJSON) and insert them as objects of the same type as model, in chunks of
+// DefaultChunkSize (see WithChunkSize). Each line is unmarshalled to a new
+// object with encoding/json and blank lines are ignored. A line which can't
+// be read or unmarshalled returns a *LineError holding the line number.
+func BulkInsertJSONLines(db *gorm.DB, model interface{}, r io.Reader, opts ...Option) error {
+	modelType, err := structType(model)
+	if err != nil {
+		return err
+	}
+
+	var (
+		reader = bufio.NewReader(r)
+		line   = 0
+	)
+
+	next := func() (interface{}, error) {
+		for {
+			data, err := reader.ReadBytes('\n')
+			if err != nil && err != io.EOF {
+				return nil, &LineError{Line: line + 1, Err: err}
+			}
+
+			if len(data) == 0 && err == io.EOF {
+				return nil, io.EOF
+			}
+
+			line++
+
+			data = bytes.TrimSpace(data)
+			if len(data) == 0 {
+				continue
+			}
+
+			object := reflect.New(modelType).Interface()
+
+			if err := json.Unmarshal(data, object); err != nil {
+				return nil, &LineError{Line: line, Err: err}
+			}
+
+			return object, nil
+		}
+	}
+
+	return streamObjects(db, next, ExecFunc(InsertFunc).toV2(), newModelOptions(model, opts...))
+}
@@ -0,0 +1,86 @@
+package gormbulk
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkInsertJSONLines(t *testing.T) {
+	type event struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+
+	cases := []struct {
+		description      string
+		input            string
+		options          []Option
+		expectedMockFunc func(mock sqlmock.Sqlmock)
+		expectedErr      *LineError
+	}{
+		{
+			description: "all lines inserted",
+			input:       "{\"name\":\"a\",\"count\":1}\n\n{\"name\":\"b\",\"count\":2}",
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `events` (`count`, `name`) VALUES (?, ?), (?, ?)")).
+					WithArgs(1, "a", 2, "b").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+		},
+		{
+			description: "chunked inserts",
+			input:       "{\"name\":\"a\"}\n{\"name\":\"b\"}\n{\"name\":\"c\"}\n",
+			options:     []Option{WithChunkSize(2)},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `events` (`count`, `name`) VALUES (?, ?), (?, ?)")).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `events` (`count`, `name`) VALUES (?, ?)")).
+					WithArgs(0, "c").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			description:      "empty input",
+			input:            "",
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {},
+		},
+		{
+			description:      "invalid line reports line number",
+			input:            "{\"name\":\"a\"}\n\n{\"name\":1}\n",
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {},
+			expectedErr:      &LineError{Line: 3},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			tc.expectedMockFunc(mock)
+
+			err = BulkInsertJSONLines(gdb, &event{}, strings.NewReader(tc.input), tc.options...)
+
+			if tc.expectedErr != nil {
+				require.Error(t, err)
+
+				lineErr, ok := err.(*LineError)
+				require.True(t, ok)
+				assert.Equal(t, tc.expectedErr.Line, lineErr.Line)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package gormbulk
+
+import (
+	"errors"
+)
+
+// ErrTooManyRows is returned when a statement would hold more rows than the
+// limit set with WithMaxRows.
+var ErrTooManyRows = errors.New("too many rows in statement")
+
+// ErrTooManyVars is returned when a statement would bind more vars than the
+// limit set with WithMaxVars.
+var ErrTooManyVars = errors.New("too many vars in statement")
+
+// WithMaxRows sets the maximum number of rows in one statement. A statement
+// with more rows isn't executed and ErrTooManyRows is returned instead, i.e. to
+// protect against huge statements from unexpected input. Use BulkExecChunk to
+// split the objects into statements within the limit.
+func WithMaxRows(n int) Option {
+	return func(o *options) {
+		o.maxRows = n
+	}
+}
+
+// WithMaxVars sets the maximum number of vars bound in one statement. A
+// statement with more vars isn't executed and ErrTooManyVars is returned
+// instead.
+func WithMaxVars(n int) Option {
+	return func(o *options) {
+		o.maxVars = n
+	}
+}
+
+// checkRows returns ErrTooManyRows if the number of rows exceeds the limit.
+func (o *options) checkRows(rows int) error {
+	if o.maxRows > 0 && rows > o.maxRows {
+		return ErrTooManyRows
+	}
+
+	return nil
+}
+
+// checkVars returns ErrTooManyVars if the number of vars exceeds the limit.
+func (o *options) checkVars(vars int) error {
+	if o.maxVars > 0 && vars > o.maxVars {
+		return ErrTooManyVars
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package gormbulk
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimits(t *testing.T) {
+	type item struct {
+		Name  string
+		Value int
+	}
+
+	objects := []interface{}{
+		item{Name: "a", Value: 1},
+		item{Name: "b", Value: 2},
+		item{Name: "c", Value: 3},
+	}
+
+	cases := []struct {
+		description string
+		opts        []Option
+		chunkSize   int
+		executed    int
+		expected    []error
+	}{
+		{
+			description: "within limits",
+			opts:        []Option{WithMaxRows(3), WithMaxVars(6)},
+			chunkSize:   3,
+			executed:    1,
+		},
+		{
+			description: "too many rows",
+			opts:        []Option{WithMaxRows(2)},
+			chunkSize:   3,
+			expected:    []error{ErrTooManyRows},
+		},
+		{
+			description: "too many vars",
+			opts:        []Option{WithMaxVars(5)},
+			chunkSize:   3,
+			expected:    []error{ErrTooManyVars},
+		},
+		{
+			description: "chunks within limits",
+			opts:        []Option{WithMaxRows(2), WithMaxVars(4)},
+			chunkSize:   2,
+			executed:    2,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			for i := 0; i < tc.executed; i++ {
+				mock.ExpectExec("INSERT INTO `items`").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			errs := BulkExecChunk(gdb, objects, InsertFunc, tc.chunkSize, tc.opts...)
+			require.NoError(t, mock.ExpectationsWereMet())
+
+			assert.Equal(t, tc.expected, errs)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package gormbulk
+
+import (
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// WithMultiStatement will send the statements for statementsPerTrip chunks in
+// one round trip to the database when using BulkExecChunk or BulkExecChunkV2,
+// reducing the impact of network latency. The driver must support multiple
+// statements in one call, i.e. MySQL with `multiStatements=true` in the DSN.
+// A failed trip is returned as a *MultiStatementError and all objects in the
+// trip are written to the dead letter table, if any (see
+// WithDeadLetterTable). Statements with an audit table (see WithAuditTable)
+// are always executed one by one.
+func WithMultiStatement(statementsPerTrip int) Option {
+	return func(o *options) {
+		o.statementsPerTrip = statementsPerTrip
+	}
+}
+
+// pendingStatement is a statement waiting to be sent with WithMultiStatement.
+type pendingStatement struct {
+	db      *gorm.DB
+	scope   *gorm.Scope
+	objects []interface{}
+	rows    []int
+	chunk   int
+}
+
+// multiStatement returns true if statements should be sent in multi statement
+// round trips. Statements are only queued while executing chunks with
+// BulkExecChunkV2 which sends the remaining statements when done.
+func (o *options) multiStatement() bool {
+	return o.statementsPerTrip > 1 && o.queueStatements
+}
+
+// queueStatement adds the statement to the pending statements and sends them
+// when there are statementsPerTrip statements pending.
+func (o *options) queueStatement(db *gorm.DB, scope *gorm.Scope, objects []interface{}, rows []int) error {
+	o.pending = append(o.pending, pendingStatement{
+		db:      db,
+		scope:   scope,
+		objects: objects,
+		rows:    rows,
+		chunk:   o.chunk,
+	})
+
+	if len(o.pending) < o.statementsPerTrip {
+		return nil
+	}
+
+	return o.flushStatements()
+}
+
+// flushStatements sends all pending statements in one round trip. The
+// function set with OnChunkDone is called for each statement with the result
+// for the whole trip. If the trip fails the objects of all statements are
+// written to the dead letter table, if any.
+func (o *options) flushStatements() error {
+	if len(o.pending) == 0 {
+		return nil
+	}
+
+	var (
+		pending    = o.pending
+		statements = make([]string, len(pending))
+		objects    []interface{}
+		rows       []int
+		sensitive  []bool
+		db         = pending[len(pending)-1].db
+		trip       = db.NewScope(nil)
+	)
+
+	o.pending = nil
+
+	for i, p := range pending {
+		statements[i] = p.scope.SQL
+		trip.SQLVars = append(trip.SQLVars, p.scope.SQLVars...)
+		objects = append(objects, p.objects...)
+		rows = append(rows, p.rows...)
+		sensitive = append(sensitive, sensitiveVars(p.scope)...)
+	}
+
+	trip.SQL = strings.Join(statements, ";\n")
+
+	// If not known for all statements RedactSensitive will redact all vars
+	// since the length differs.
+	trip.Set(sensitiveVarsKey, sensitive)
+
+	// The trip is counted as the last chunk in it.
+	chunk := o.chunk
+	o.chunk = pending[len(pending)-1].chunk
+
+	res, err := o.execStatementSQL(db, trip)
+	o.chunk = chunk
+
+	if o.onChunkDone != nil {
+		for _, p := range pending {
+			o.onChunkDone(p.chunk, res, err)
+		}
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	tripErr := &MultiStatementError{Statements: len(pending), Rows: rows, Err: err}
+
+	if deadLetterErr := o.writeDeadLetters(db, objects, rows, err); deadLetterErr != nil {
+		return &DeadLetterError{Err: tripErr, DeadLetterErr: deadLetterErr}
+	}
+
+	return tripErr
+}
@@ -0,0 +1,106 @@
+package gormbulk
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMultiStatement(t *testing.T) {
+	type item struct {
+		Name string
+	}
+
+	const (
+		one = "INSERT INTO `items` (`name`) VALUES (?)"
+		two = one + ";\n" + one
+	)
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	mock.ExpectExec(two).
+		WithArgs("a", "b").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	mock.ExpectExec(two).
+		WithArgs("c", "d").
+		WillReturnError(errors.New("deadlock"))
+
+	mock.ExpectExec(one).
+		WithArgs("e").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var (
+		chunks  []int
+		failed  []int
+		result  = &Result{}
+		objects = []interface{}{
+			item{Name: "a"},
+			item{Name: "b"},
+			item{Name: "c"},
+			item{Name: "d"},
+			item{Name: "e"},
+		}
+	)
+
+	errs := BulkExecChunk(
+		gdb, objects, InsertFunc, 1,
+		WithMultiStatement(2),
+		WithResult(result),
+		OnChunkDone(func(chunk int, _ sql.Result, err error) {
+			chunks = append(chunks, chunk)
+
+			if err != nil {
+				failed = append(failed, chunk)
+			}
+		}),
+	)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	require.Len(t, errs, 1)
+	assert.Equal(t, &MultiStatementError{
+		Statements: 2,
+		Rows:       []int{2, 3},
+		Err:        errors.New("deadlock"),
+	}, errs[0])
+
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, chunks)
+	assert.Equal(t, []int{2, 3}, failed)
+	assert.Equal(t, int64(3), result.RowsAffected)
+
+	assert.Equal(t, []StatementSize{
+		{Chunk: 1, Bytes: len(two) + 2},
+		{Chunk: 3, Bytes: len(two) + 2},
+		{Chunk: 4, Bytes: len(one) + 1},
+	}, result.StatementSizes)
+}
+
+func TestWithMultiStatementSingleStatement(t *testing.T) {
+	type item struct {
+		Name string
+	}
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	mock.ExpectExec("INSERT INTO `items`").
+		WithArgs("a", "b").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	objects := []interface{}{item{Name: "a"}, item{Name: "b"}}
+
+	require.NoError(t, BulkInsert(gdb, objects, WithMultiStatement(2)))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,129 @@
+package gormbulk
+
+import (
+	"reflect"
+
+	"github.com/jinzhu/gorm"
+	"github.com/jinzhu/inflection"
+)
+
+// WithNamingStrategy will derive the table and column names with the passed
+// naming strategy instead of gorm.TheNamingStrategy for this call, i.e. for
+// schemas using camelCase column names. Only names derived from the type and
+// field names are changed; names set with the column tag, a TableName method
+// or db.Table are used as is. Namers not set in the strategy are not used.
+// Rows scanned with WithSkipUnchanged are still mapped by gorm's names.
+func WithNamingStrategy(ns *gorm.NamingStrategy) Option {
+	return func(o *options) {
+		o.namingStrategy = ns
+	}
+}
+
+// WithColumnAlias will write the fields to the columns in the map, keyed by
+// the name of the struct field, instead of the column derived from the field
+// or set with a tag. This is useful when loading objects into a table where
+// the column names differ from the model, i.e.
+//
+//  WithColumnAlias(map[string]string{"Email": "email_address"})
+func WithColumnAlias(aliases map[string]string) Option {
+	return func(o *options) {
+		if o.columnAliases == nil {
+			o.columnAliases = map[string]string{}
+		}
+
+		for field, column := range aliases {
+			o.columnAliases[field] = column
+		}
+	}
+}
+
+// renamesColumns returns true if any column names are changed by the options.
+func (o *options) renamesColumns() bool {
+	return len(o.columnAliases) > 0 || (o.namingStrategy != nil && o.namingStrategy.Column != nil)
+}
+
+// objectToMap works like ObjectToMap but with the column names set with
+// WithColumnAlias or derived with the naming strategy set with
+// WithNamingStrategy.
+func (o *options) objectToMap(object interface{}) (map[string]*gorm.Field, error) {
+	fields, err := ObjectToMap(object)
+	if err != nil || !o.renamesColumns() {
+		return fields, err
+	}
+
+	named := make(map[string]*gorm.Field, len(fields))
+
+	for column, field := range fields {
+		if column == field.DBName {
+			column = o.columnName(field.StructField)
+		}
+
+		named[column] = field
+	}
+
+	return named, nil
+}
+
+// columnName returns the column name for the field, either the alias set with
+// WithColumnAlias or derived with the naming strategy set with
+// WithNamingStrategy unless set with a tag.
+func (o *options) columnName(field *gorm.StructField) string {
+	if alias, ok := o.columnAliases[field.Name]; ok {
+		return alias
+	}
+
+	if o.namingStrategy == nil || o.namingStrategy.Column == nil {
+		return field.DBName
+	}
+
+	if field.DBName != gorm.ToColumnName(field.Name) {
+		return field.DBName
+	}
+
+	return o.namingStrategy.ColumnName(field.Name)
+}
+
+// scopeTableName returns the table name for the scope, derived with the
+// naming strategy set with WithNamingStrategy and with the table prefix and
+// suffix added.
+func (o *options) scopeTableName(scope *gorm.Scope) string {
+	return o.tableName(o.namedTable(scope))
+}
+
+// namedTable returns the table name for the scope derived with the naming
+// strategy set with WithNamingStrategy, unless set with a TableName method or
+// db.Table.
+func (o *options) namedTable(scope *gorm.Scope) string {
+	name := scope.TableName()
+
+	if o.namingStrategy == nil || o.namingStrategy.Table == nil {
+		return name
+	}
+
+	modelStruct := scope.GetModelStruct()
+	if modelStruct.ModelType == nil || modelStruct.ModelType.Name() == "" {
+		return name
+	}
+
+	if _, ok := reflect.New(modelStruct.ModelType).Interface().(interface{ TableName() string }); ok {
+		return name
+	}
+
+	// The name differs from the default name for the model if set with
+	// db.Table.
+	if name != modelStruct.TableName(scope.DB()) {
+		return name
+	}
+
+	var (
+		typeName = modelStruct.ModelType.Name()
+		named    = o.namingStrategy.TableName(typeName)
+	)
+
+	// The default name is only plural unless using db.SingularTable.
+	if name == gorm.DefaultTableNameHandler(scope.DB(), gorm.ToTableName(typeName)) {
+		return gorm.DefaultTableNameHandler(scope.DB(), named)
+	}
+
+	return gorm.DefaultTableNameHandler(scope.DB(), inflection.Plural(named))
+}
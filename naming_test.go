@@ -0,0 +1,122 @@
+package gormbulk
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+)
+
+type namingTabler struct {
+	UserName string
+}
+
+func (namingTabler) TableName() string {
+	return "legacy_users"
+}
+
+func TestWithNamingStrategy(t *testing.T) {
+	type UserAccount struct {
+		UserName  string
+		Email     string `gorm:"column:e_mail"`
+		CreatedAt string `gorm:"-"`
+	}
+
+	lowerCamel := func(name string) string {
+		return strings.ToLower(name[:1]) + name[1:]
+	}
+
+	cases := []struct {
+		description string
+		db          func(*gorm.DB) *gorm.DB
+		object      interface{}
+		strategy    *gorm.NamingStrategy
+		expectedSQL string
+	}{
+		{
+			description: "column and table namers",
+			object:      UserAccount{UserName: "a", Email: "b"},
+			strategy: &gorm.NamingStrategy{
+				Table:  func(name string) string { return "tbl" + name },
+				Column: lowerCamel,
+			},
+			expectedSQL: "INSERT INTO `tblUserAccounts` (`e_mail`, `userName`) VALUES (?, ?)",
+		},
+		{
+			description: "only column namer",
+			object:      UserAccount{UserName: "a", Email: "b"},
+			strategy:    &gorm.NamingStrategy{Column: lowerCamel},
+			expectedSQL: "INSERT INTO `user_accounts` (`e_mail`, `userName`) VALUES (?, ?)",
+		},
+		{
+			description: "singular table",
+			db:          func(db *gorm.DB) *gorm.DB { db.SingularTable(true); return db },
+			object:      UserAccount{UserName: "a", Email: "b"},
+			strategy:    &gorm.NamingStrategy{Table: strings.ToUpper},
+			expectedSQL: "INSERT INTO `USERACCOUNT` (`e_mail`, `user_name`) VALUES (?, ?)",
+		},
+		{
+			description: "table from db.Table is kept",
+			db:          func(db *gorm.DB) *gorm.DB { return db.Table("staging") },
+			object:      UserAccount{UserName: "a", Email: "b"},
+			strategy:    &gorm.NamingStrategy{Table: strings.ToUpper},
+			expectedSQL: "INSERT INTO `staging` (`e_mail`, `user_name`) VALUES (?, ?)",
+		},
+		{
+			description: "table from TableName is kept",
+			object:      namingTabler{UserName: "a"},
+			strategy:    &gorm.NamingStrategy{Table: strings.ToUpper, Column: strings.ToUpper},
+			expectedSQL: "INSERT INTO `legacy_users` (`USERNAME`) VALUES (?)",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			if tc.db != nil {
+				gdb = tc.db(gdb)
+			}
+
+			mock.ExpectExec(regexp.QuoteMeta(tc.expectedSQL)).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			require.NoError(t, BulkInsert(gdb, []interface{}{tc.object}, WithNamingStrategy(tc.strategy)))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWithColumnAlias(t *testing.T) {
+	type contact struct {
+		Name  string
+		Email string `gorm:"column:e_mail"`
+		Phone string
+	}
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `staging_contacts` (`contact_name`, `email_address`, `phone`) VALUES (?, ?, ?)")).
+		WithArgs("a", "b", "c").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	objects := []interface{}{contact{Name: "a", Email: "b", Phone: "c"}}
+
+	require.NoError(t, BulkInsert(
+		gdb.Table("staging_contacts"), objects,
+		WithColumnAlias(map[string]string{"Email": "email_address"}),
+		WithColumnAlias(map[string]string{"Name": "contact_name"}),
+	))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,456 @@
+package gormbulk
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Option is a function that will configure how the bulk SQL is built and
+// executed. Options are passed to any of the bulk functions.
+type Option func(*options)
+
+type options struct {
+	dialect                  string
+	serializers              map[reflect.Type]SerializerFunc
+	skipNullUpdates          bool
+	emptyStringAsNull        bool
+	emptyStringAsNullColumns map[string]struct{}
+	columnExpressions        map[string]string
+	location                 *time.Location
+	dateLayouts              map[string]string
+	batchTime                time.Time
+	nowFunc                  func() time.Time
+	rowTimestamps            bool
+	watermark                time.Time
+	validateSize             bool
+	truncateStrings          bool
+	onTruncate               func(*ColumnSizeError)
+	typeCoercion             bool
+	checkReservedWords       bool
+	columnOrder              ColumnOrder
+	onReserved               func(*ReservedWordError)
+	validators               []ValidatorFunc
+	rowTransformers          []RowTransformerFunc
+	invalidPolicy            InvalidPolicy
+	nilPolicy                NilPolicy
+	nilPointerPolicy         NilPointerPolicy
+	result                   *Result
+	comments                 []string
+	optimizerHints           []string
+	insertOption             string
+	insertOptionArgs         []interface{}
+	versionColumn            string
+	updateExcludedColumns    []string
+	createdAtPolicy          CreatedAtPolicy
+	checksumColumn           string
+	expiryColumn             string
+	expiryTTL                time.Duration
+	skipUnchanged            bool
+	diffKeys                 []string
+	columnValues             map[string]ColumnValueFunc
+	columnTransformers       map[string][]ColumnTransformerFunc
+	batchID                  string
+	encrypter                Encrypter
+	compressor               Compressor
+	compressedColumns        map[string]string
+	auditTable               string
+	auditOperation           string
+	shardFunc                ShardFunc
+	tablePrefix              string
+	tableSuffix              string
+	namingStrategy           *gorm.NamingStrategy
+	columnAliases            map[string]string
+	syncKeys                 []string
+	stagingTable             string
+	deleteMissing            bool
+	deleteMissingWhere       string
+	deleteMissingArgs        []interface{}
+	chunkSize                int
+	maxChunkBytes            int
+	blobRowPerStatement      bool
+	maxRows                  int
+	maxVars                  int
+	rowOffset                int
+	chunk                    int
+	onChunkDone              ChunkDoneFunc
+	idBackfill               bool
+	statementsPerTrip        int
+	queueStatements          bool
+	concurrencyLimit         int
+	poolReserve              int
+	orderedChunks            bool
+	pending                  []pendingStatement
+	statementCache           *StatementCache
+	partialChunkPolicy       PartialChunkPolicy
+	padSize                  int
+	executor                 Executor
+	statementChecks          []StatementCheckFunc
+	errorVerbosity           ErrorVerbosity
+	statementLog             StatementLogFunc
+	scopeModifiers           []ScopeModifierFunc
+	redactor                 Redactor
+	pinConnection            bool
+	conn                     *sql.Conn
+	setupSQL                 []string
+	teardownSQL              []string
+	foreignKeyChecksDisabled bool
+	advisoryLock             string
+	guard                    Guard
+	guarded                  bool
+	deadLetterTable          string
+	retryState               *retryState
+	healthProbe              bool
+	minFreeConnections       int
+	ctx                      context.Context
+	txOptions                *sql.TxOptions
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{}
+
+	for _, opt := range defaultOptions() {
+		opt(o)
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithSkipNullUpdates will exclude columns where every row in the batch is
+// NULL (nil pointers, invalid sql.Null* types or a driver.Valuer returning
+// nil) from the update clause used by InsertOnDuplicateKeyUpdateFunc. This
+// ensures a batch without any values for a column won't overwrite existing
+// data.
+func WithSkipNullUpdates() Option {
+	return func(o *options) {
+		o.skipNullUpdates = true
+	}
+}
+
+// WithEmptyStringAsNull will bind empty strings as NULL for the passed column
+// names. If no column names are passed, all empty strings will be bound as
+// NULL.
+func WithEmptyStringAsNull(columns ...string) Option {
+	return func(o *options) {
+		o.emptyStringAsNull = true
+
+		if len(columns) == 0 {
+			o.emptyStringAsNullColumns = nil
+			return
+		}
+
+		if o.emptyStringAsNullColumns == nil {
+			o.emptyStringAsNullColumns = map[string]struct{}{}
+		}
+
+		for _, column := range columns {
+			o.emptyStringAsNullColumns[column] = struct{}{}
+		}
+	}
+}
+
+// WithUTC will convert all time values to UTC before they're bound.
+func WithUTC() Option {
+	return WithLocation(time.UTC)
+}
+
+// WithLocation will convert all time values to the passed location before
+// they're bound. This ensures that objects from different sources with mixed
+// offsets are written the same way.
+func WithLocation(loc *time.Location) Option {
+	return func(o *options) {
+		o.location = loc
+	}
+}
+
+// WithBatchTime sets the time used for blank CreatedAt and UpdatedAt fields
+// instead of the current time. Together with WithColumnValue this may be used
+// to record the batch time in a separate column while keeping the original
+// event time set on the objects.
+func WithBatchTime(t time.Time) Option {
+	return func(o *options) {
+		o.batchTime = t
+	}
+}
+
+// WithRowTimestamps will give each row its own current time for blank
+// CreatedAt and UpdatedAt fields instead of using the same time for all rows
+// in the statement. The times are taken in the order of the objects.
+func WithRowTimestamps() Option {
+	return func(o *options) {
+		o.rowTimestamps = true
+	}
+}
+
+// now returns the time to use for blank CreatedAt and UpdatedAt fields in a
+// statement (or row if using WithRowTimestamps).
+func (o *options) now() time.Time {
+	if !o.batchTime.IsZero() {
+		return o.batchTime
+	}
+
+	return o.currentTime()
+}
+
+// WithNowFunc will use fn instead of gorm.NowFunc to get the current time for
+// timestamps, i.e. for blank CreatedAt and UpdatedAt fields, the audit table
+// and soft deletes. A time set with WithBatchTime takes precedence.
+func WithNowFunc(fn func() time.Time) Option {
+	return func(o *options) {
+		o.nowFunc = fn
+	}
+}
+
+// currentTime returns the current time from the function set with
+// WithNowFunc or gorm.NowFunc.
+func (o *options) currentTime() time.Time {
+	if o.nowFunc != nil {
+		return o.nowFunc()
+	}
+
+	return gorm.NowFunc()
+}
+
+// WithValidateStringSize will validate all string values against the column
+// size configured with the SIZE tag or a char/varchar TYPE tag. A
+// *ColumnSizeError identifying the row and column will be returned for the
+// first value exceeding the size.
+func WithValidateStringSize() Option {
+	return func(o *options) {
+		o.validateSize = true
+	}
+}
+
+// WithTruncateStrings will truncate all string values exceeding the column
+// size configured with the SIZE tag or a char/varchar TYPE tag. If onTruncate
+// is not nil it will be called for every value truncated.
+func WithTruncateStrings(onTruncate func(*ColumnSizeError)) Option {
+	return func(o *options) {
+		o.truncateStrings = true
+		o.onTruncate = onTruncate
+	}
+}
+
+// WithColumnExpr will wrap the value bound for the column in a SQL expression,
+// i.e. WithColumnExpr("location", "ST_GeomFromText(?)"). The expression must
+// contain exactly one placeholder. To bind multiple values in one expression,
+// use a SerializerFunc returning gorm.Expr.
+func WithColumnExpr(column, expression string) Option {
+	return func(o *options) {
+		if o.columnExpressions == nil {
+			o.columnExpressions = map[string]string{}
+		}
+
+		o.columnExpressions[column] = expression
+	}
+}
+
+// columnExpr wraps the value in the expression configured for the column, if
+// any.
+func (o *options) columnExpr(column string, value interface{}) interface{} {
+	expression, ok := o.columnExpressions[column]
+	if !ok {
+		return value
+	}
+
+	return gorm.Expr(expression, value)
+}
+
+// WithVersionColumn sets the column used for optimistic locking. When set,
+// InsertOnDuplicateKeyUpdateFunc will only update existing rows if the
+// inserted version is greater than the existing version. The version column
+// may also be set with the tag `bulk:"version"`.
+func WithVersionColumn(column string) Option {
+	return func(o *options) {
+		o.versionColumn = column
+	}
+}
+
+// WithUpdateExcludedColumns will leave out the columns from the update clause
+// used by the bundled upsert functions, such as InsertOnDuplicateKeyUpdateFunc
+// and BulkPatch, i.e. to never overwrite `created_by` or `tenant_id` of existing
+// rows. The columns are excluded in addition to the CreatedAt field, see
+// WithCreatedAtPolicy.
+func WithUpdateExcludedColumns(columns ...string) Option {
+	return func(o *options) {
+		o.updateExcludedColumns = append(o.updateExcludedColumns, columns...)
+	}
+}
+
+// CreatedAtPolicy decides if the CreatedAt field is updated for existing rows
+// by the bundled upsert functions.
+type CreatedAtPolicy int
+
+// Available policies for the CreatedAt field.
+const (
+	// KeepCreatedAt will never update the CreatedAt field of existing rows,
+	// regardless of the column name. This is the default policy.
+	KeepCreatedAt CreatedAtPolicy = iota
+
+	// UpdateCreatedAt will update the CreatedAt field the same way as all
+	// other columns.
+	UpdateCreatedAt
+)
+
+// WithCreatedAtPolicy sets the policy for updating the CreatedAt field of
+// existing rows.
+func WithCreatedAtPolicy(policy CreatedAtPolicy) Option {
+	return func(o *options) {
+		o.createdAtPolicy = policy
+	}
+}
+
+// updateExcluded returns the quoted names of the columns never updated for
+// existing rows. The CreatedAt field is found by the field name so it's
+// excluded even with a custom column name.
+func (o *options) updateExcluded(scope *gorm.Scope, columns []Column) []string {
+	excluded := quoteColumns(scope, o.updateExcludedColumns)
+
+	if o.createdAtPolicy != KeepCreatedAt {
+		return excluded
+	}
+
+	for _, column := range columns {
+		if column.Field != nil && column.Field.Name == "CreatedAt" {
+			excluded = append(excluded, column.QuotedName)
+		}
+	}
+
+	return excluded
+}
+
+// ColumnValueFunc returns the value for an injected column. The row is the
+// index of the object in the slice passed to the bulk function.
+type ColumnValueFunc func(row int, object interface{}) interface{}
+
+// WithColumnValue will set the value of the column for every row to the value
+// returned by fn. If the objects doesn't have the column it will be added to
+// the statement.
+func WithColumnValue(column string, fn ColumnValueFunc) Option {
+	return func(o *options) {
+		if o.columnValues == nil {
+			o.columnValues = map[string]ColumnValueFunc{}
+		}
+
+		o.columnValues[column] = fn
+	}
+}
+
+// WithIdempotencyKey will stamp every row with an idempotency key in the passed
+// column. The key for each row is the passed key and the index of the object,
+// i.e. `nightly-2019-11-01:42`. Together with a unique index on the column and
+// BulkInsertIdempotent, a retried call with the same key and objects won't
+// insert the same rows twice.
+func WithIdempotencyKey(column, key string) Option {
+	return WithColumnValue(column, func(row int, _ interface{}) interface{} {
+		return fmt.Sprintf("%s:%d", key, row)
+	})
+}
+
+// WithTablePrefix will add the prefix to the table name, i.e. `tenant_42_`
+// which will write to `tenant_42_users`. If the table name includes a schema,
+// only the table part is prefixed. The prefix is also added to the audit table.
+func WithTablePrefix(prefix string) Option {
+	return func(o *options) {
+		o.tablePrefix = prefix
+	}
+}
+
+// WithTableSuffix will add the suffix to the table name the same way as
+// WithTablePrefix.
+func WithTableSuffix(suffix string) Option {
+	return func(o *options) {
+		o.tableSuffix = suffix
+	}
+}
+
+// tableName returns the table name with prefix and suffix added to the last
+// part of the (possibly schema qualified) name.
+func (o *options) tableName(name string) string {
+	if o.tablePrefix == "" && o.tableSuffix == "" {
+		return name
+	}
+
+	parts := strings.Split(name, ".")
+	last := len(parts) - 1
+	parts[last] = o.tablePrefix + parts[last] + o.tableSuffix
+
+	return strings.Join(parts, ".")
+}
+
+// WithComment will prepend the SQL with the comment, i.e. `/* job:import */`,
+// to make it possible to attribute bulk statements in slow query logs.
+func WithComment(comment string) Option {
+	return func(o *options) {
+		o.comments = append(o.comments, sqlComment(comment))
+	}
+}
+
+// WithCommentTags will prepend the SQL with the tags formatted as a
+// sqlcommenter comment, i.e. `/*job='import',team='data'*/`.
+func WithCommentTags(tags map[string]string) Option {
+	return func(o *options) {
+		o.comments = append(o.comments, sqlCommenter(tags))
+	}
+}
+
+// WithInsertOption sets the extra clause added to the statement by InsertFunc
+// and InsertIgnoreFunc, i.e. `ON DUPLICATE KEY UPDATE ...`. Unlike setting
+// `gorm:insert_option` with db.Set it only applies to this call. The option may
+// contain placeholders for the args which are bound after the vars for all
+// rows, i.e.
+//
+//  WithInsertOption("ON DUPLICATE KEY UPDATE status = ?", "archived")
+func WithInsertOption(option string, args ...interface{}) Option {
+	return func(o *options) {
+		o.insertOption = option
+		o.insertOptionArgs = args
+	}
+}
+
+// WithOptimizerHint will add the optimizer hints directly after the first
+// keyword of the statement, i.e.
+//
+//  INSERT /*+ SET_VAR(foreign_key_checks=OFF) */ INTO ...
+func WithOptimizerHint(hints ...string) Option {
+	return func(o *options) {
+		o.optimizerHints = append(o.optimizerHints, hints...)
+	}
+}
+
+// decorateSQL adds all comments and hints from the options and any
+// `gorm:query_option` set on the scope to the SQL set by the ExecFunc.
+func (o *options) decorateSQL(scope *gorm.Scope) {
+	if queryOption, ok := scope.Get("gorm:query_option"); ok {
+		if option := fmt.Sprint(queryOption); option != "" {
+			scope.Raw(fmt.Sprintf("%s %s", scope.SQL, option))
+		}
+	}
+
+	if len(o.optimizerHints) > 0 {
+		sql := strings.TrimLeft(scope.SQL, " \t\n")
+		keywordEnd := strings.IndexAny(sql, " \t\n")
+
+		if keywordEnd > 0 {
+			scope.Raw(fmt.Sprintf(
+				"%s %s%s",
+				sql[:keywordEnd],
+				optimizerHint(o.optimizerHints),
+				sql[keywordEnd:],
+			))
+		}
+	}
+
+	if len(o.comments) > 0 {
+		scope.Raw(fmt.Sprintf("%s %s", strings.Join(o.comments, " "), scope.SQL))
+	}
+}
@@ -0,0 +1,277 @@
+package gormbulk
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_tableName(t *testing.T) {
+	cases := []struct {
+		description string
+		name        string
+		options     []Option
+		expected    string
+	}{
+		{
+			description: "no prefix or suffix",
+			name:        "users",
+			expected:    "users",
+		},
+		{
+			description: "prefix",
+			name:        "users",
+			options:     []Option{WithTablePrefix("tenant_42_")},
+			expected:    "tenant_42_users",
+		},
+		{
+			description: "prefix and suffix with schema",
+			name:        "public.users",
+			options:     []Option{WithTablePrefix("tenant_42_"), WithTableSuffix("_v2")},
+			expected:    "public.tenant_42_users_v2",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			assert.Equal(t, tc.expected, newOptions(tc.options...).tableName(tc.name))
+		})
+	}
+}
+
+func TestTimestamps(t *testing.T) {
+	type event struct {
+		Name      string
+		CreatedAt time.Time
+	}
+
+	var (
+		eventTime = time.Date(2019, 11, 1, 12, 0, 0, 0, time.UTC)
+		batchTime = time.Date(2019, 11, 2, 0, 0, 0, 0, time.UTC)
+		first     = time.Date(2019, 11, 3, 0, 0, 0, 0, time.UTC)
+	)
+
+	cases := []struct {
+		description  string
+		options      []Option
+		expectedArgs []driver.Value
+	}{
+		{
+			description:  "same time for all blank rows",
+			expectedArgs: []driver.Value{first, "a", first, "b", eventTime, "c"},
+		},
+		{
+			description:  "time for each row",
+			options:      []Option{WithRowTimestamps()},
+			expectedArgs: []driver.Value{first.Add(time.Second), "a", first.Add(2 * time.Second), "b", eventTime, "c"},
+		},
+		{
+			description: "batch time set by caller and recorded in column",
+			options: []Option{
+				WithBatchTime(batchTime),
+				WithColumnValue("ingested_at", func(_ int, _ interface{}) interface{} {
+					return batchTime
+				}),
+			},
+			expectedArgs: []driver.Value{
+				batchTime, batchTime, "a",
+				batchTime, batchTime, "b",
+				eventTime, batchTime, "c",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			now := first
+
+			nowFunc := WithNowFunc(func() time.Time {
+				defer func() { now = now.Add(time.Second) }()
+				return now
+			})
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			mock.ExpectExec("INSERT INTO `events`").
+				WithArgs(tc.expectedArgs...).
+				WillReturnResult(sqlmock.NewResult(0, 3))
+
+			objects := []interface{}{
+				event{Name: "a"},
+				event{Name: "b"},
+				event{Name: "c", CreatedAt: eventTime},
+			}
+
+			require.NoError(t, BulkInsert(gdb, objects, append(tc.options, nowFunc)...))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWithInsertOption(t *testing.T) {
+	type item struct {
+		Name   string
+		Status string
+	}
+
+	cases := []struct {
+		dialect     string
+		option      string
+		expectedSQL string
+	}{
+		{
+			dialect:     "mysql",
+			option:      "ON DUPLICATE KEY UPDATE `status` = ?",
+			expectedSQL: "INSERT INTO `items` (`name`, `status`) VALUES (?, ?), (?, ?) ON DUPLICATE KEY UPDATE `status` = ?",
+		},
+		{
+			dialect:     "postgres",
+			option:      `ON CONFLICT ("name") DO UPDATE SET "status" = ?`,
+			expectedSQL: `INSERT INTO "items" ("name", "status") VALUES ($1, $2), ($3, $4) ON CONFLICT ("name") DO UPDATE SET "status" = $5`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.dialect, func(t *testing.T) {
+			db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open(tc.dialect, db)
+			require.NoError(t, err)
+
+			mock.ExpectExec(tc.expectedSQL).
+				WithArgs("a", "new", "b", "new", "archived").
+				WillReturnResult(sqlmock.NewResult(0, 2))
+
+			objects := []interface{}{
+				item{Name: "a", Status: "new"},
+				item{Name: "b", Status: "new"},
+			}
+
+			require.NoError(t, BulkInsert(gdb, objects, WithInsertOption(tc.option, "archived")))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWithUpdateExcludedColumns(t *testing.T) {
+	type item struct {
+		Name      string
+		TenantID  int
+		CreatedBy string
+		CreatedAt time.Time
+	}
+
+	cases := []struct {
+		description string
+		dialect     string
+		execFunc    ExecFuncV2
+		expectedSQL string
+	}{
+		{
+			description: "on duplicate key update",
+			dialect:     "mysql",
+			execFunc:    ExecFunc(InsertOnDuplicateKeyUpdateFunc).toV2(),
+			expectedSQL: "INSERT INTO `items` (`created_at`, `created_by`, `name`, `tenant_id`) VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)",
+		},
+		{
+			description: "on duplicate key update coalesce",
+			dialect:     "mysql",
+			execFunc:    ExecFunc(InsertOnDuplicateKeyUpdateCoalesceFunc).toV2(),
+			expectedSQL: "INSERT INTO `items` (`created_at`, `created_by`, `name`, `tenant_id`) VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE `name` = COALESCE(VALUES(`name`), `name`)",
+		},
+		{
+			description: "on conflict coalesce",
+			dialect:     "postgres",
+			execFunc:    InsertOnConflictCoalesceFunc("name").toV2(),
+			expectedSQL: `INSERT INTO "items" ("created_at", "created_by", "name", "tenant_id") VALUES ($1, $2, $3, $4) ON CONFLICT ("name") DO UPDATE SET "name" = COALESCE(EXCLUDED."name", "items"."name")`,
+		},
+		{
+			description: "patch",
+			dialect:     "mysql",
+			execFunc:    PatchFunc("name"),
+			expectedSQL: "INSERT INTO `items` (`created_at`, `created_by`, `name`, `tenant_id`) VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open(tc.dialect, db)
+			require.NoError(t, err)
+
+			mock.ExpectExec(tc.expectedSQL).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			objects := []interface{}{
+				item{Name: "a", TenantID: 1, CreatedBy: "import"},
+			}
+
+			require.NoError(t, BulkExecV2(
+				gdb, objects, tc.execFunc,
+				WithUpdateExcludedColumns("tenant_id", "created_by"),
+			))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWithCreatedAtPolicy(t *testing.T) {
+	type item struct {
+		Name      string
+		CreatedAt time.Time `gorm:"column:inserted_at"`
+	}
+
+	cases := []struct {
+		description string
+		opts        []Option
+		expectedSQL string
+	}{
+		{
+			description: "created at with custom column name is kept",
+			expectedSQL: "INSERT INTO `items` (`inserted_at`, `name`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)",
+		},
+		{
+			description: "created at is updated",
+			opts:        []Option{WithCreatedAtPolicy(UpdateCreatedAt)},
+			expectedSQL: "INSERT INTO `items` (`inserted_at`, `name`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `inserted_at` = VALUES(`inserted_at`), `name` = VALUES(`name`)",
+		},
+		{
+			description: "created at is updated but other columns excluded",
+			opts: []Option{
+				WithCreatedAtPolicy(UpdateCreatedAt),
+				WithUpdateExcludedColumns("name"),
+			},
+			expectedSQL: "INSERT INTO `items` (`inserted_at`, `name`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `inserted_at` = VALUES(`inserted_at`)",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			mock.ExpectExec(tc.expectedSQL).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			objects := []interface{}{item{Name: "a"}}
+
+			require.NoError(t, BulkInsertOnDuplicateKeyUpdate(gdb, objects, tc.opts...))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package gormbulk
+
+import "github.com/jinzhu/gorm"
+
+// PartialChunkPolicy decides what to do with the last chunk when it holds
+// fewer objects than the chunk size, i.e. to keep the same statement shape
+// for every chunk when using WithStatementReuse.
+type PartialChunkPolicy int
+
+// Available policies for partial chunks.
+const (
+	// ExecPartialChunk will execute the partial chunk in a statement with
+	// fewer rows. This is the default policy.
+	ExecPartialChunk PartialChunkPolicy = iota
+
+	// PadPartialChunk will pad the partial chunk to the chunk size by
+	// repeating the last object. The repeated rows are only no-ops when
+	// using an upsert such as InsertOnDuplicateKeyUpdateFunc, so this
+	// must not be used with plain inserts. Rows affected include the
+	// repeated rows but audit rows and dead letters are only written for
+	// the objects passed.
+	PadPartialChunk
+
+	// CarryOverPartialChunk will keep the partial chunk buffered when calling
+	// Flush on a BulkWriter so it's executed with the objects written
+	// next. The partial chunk is always executed when the writer is closed.
+	// Other bulk functions execute the partial chunk like ExecPartialChunk.
+	CarryOverPartialChunk
+)
+
+// WithPartialChunkPolicy sets the policy for the last chunk when it holds
+// fewer objects than the chunk size.
+func WithPartialChunkPolicy(policy PartialChunkPolicy) Option {
+	return func(o *options) {
+		o.partialChunkPolicy = policy
+	}
+}
+
+// execPadded executes the objects like execObjects with the statement padded
+// to size when using PadPartialChunk. The pad size is only used for this call.
+func (o *options) execPadded(db *gorm.DB, objects []interface{}, rows []int, execFunc ExecFuncV2, size int) error {
+	o.padSize = size
+	defer func() {
+		o.padSize = 0
+	}()
+
+	return execObjects(db, objects, rows, execFunc, o)
+}
+
+// padChunk pads the objects and rows to size by repeating the last object
+// when using PadPartialChunk. Only the statement is built from the padded
+// objects, audit rows, dead letters and counts use the objects as is. The
+// padded chunk never exceeds the size set with WithMaxChunkBytes.
+func (o *options) padChunk(objects []interface{}, rows []int, size int) ([]interface{}, []int) {
+	if o.partialChunkPolicy != PadPartialChunk || len(objects) < 1 {
+		return objects, rows
+	}
+
+	size = o.maxPadSize(objects, size)
+	if len(objects) >= size {
+		return objects, rows
+	}
+
+	var (
+		padded     = make([]interface{}, size)
+		paddedRows = make([]int, size)
+		last       = len(objects) - 1
+	)
+
+	copy(padded, objects)
+	copy(paddedRows, rows)
+
+	for i := len(objects); i < size; i++ {
+		padded[i] = objects[last]
+		paddedRows[i] = rows[last]
+	}
+
+	return padded, paddedRows
+}
+
+// maxPadSize returns size capped to the number of objects fitting in the size
+// set with WithMaxChunkBytes when the last object is repeated.
+func (o *options) maxPadSize(objects []interface{}, size int) int {
+	if o.maxChunkBytes < 1 {
+		return size
+	}
+
+	var bytes int
+
+	for _, object := range objects {
+		objectBytes, _ := o.objectSize(object)
+		bytes += objectBytes
+	}
+
+	lastBytes, _ := o.objectSize(objects[len(objects)-1])
+	if lastBytes < 1 {
+		return size
+	}
+
+	if bytes >= o.maxChunkBytes {
+		return len(objects)
+	}
+
+	if maxSize := len(objects) + (o.maxChunkBytes-bytes)/lastBytes; maxSize < size {
+		return maxSize
+	}
+
+	return size
+}
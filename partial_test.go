@@ -0,0 +1,165 @@
+package gormbulk
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithPartialChunkPolicy(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	type statement struct {
+		sql  string
+		args []driver.Value
+	}
+
+	const (
+		upsert       = " ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)"
+		twoRowsSQL   = "INSERT INTO `users` (`name`) VALUES (?), (?)" + upsert
+		singleRowSQL = "INSERT INTO `users` (`name`) VALUES (?)" + upsert
+	)
+
+	cases := []struct {
+		description string
+		policy      PartialChunkPolicy
+		opts        []Option
+		expected    []statement
+	}{
+		{
+			description: "partial chunk executed",
+			policy:      ExecPartialChunk,
+			expected: []statement{
+				{sql: twoRowsSQL, args: []driver.Value{"a", "b"}},
+				{sql: singleRowSQL, args: []driver.Value{"c"}},
+			},
+		},
+		{
+			description: "partial chunk padded",
+			policy:      PadPartialChunk,
+			expected: []statement{
+				{sql: twoRowsSQL, args: []driver.Value{"a", "b"}},
+				{sql: twoRowsSQL, args: []driver.Value{"c", "c"}},
+			},
+		},
+		{
+			description: "padding capped by max chunk bytes",
+			policy:      PadPartialChunk,
+			opts:        []Option{WithMaxChunkBytes(1)},
+			expected: []statement{
+				{sql: singleRowSQL, args: []driver.Value{"a"}},
+				{sql: singleRowSQL, args: []driver.Value{"b"}},
+				{sql: singleRowSQL, args: []driver.Value{"c"}},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			for _, expected := range tc.expected {
+				mock.ExpectExec(regexp.QuoteMeta(expected.sql) + "$").
+					WithArgs(expected.args...).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			errs := BulkExecChunk(
+				gdb,
+				[]interface{}{user{"a"}, user{"b"}, user{"c"}},
+				InsertOnDuplicateKeyUpdateFunc,
+				2,
+				append(tc.opts, WithStatementReuse(), WithPartialChunkPolicy(tc.policy))...,
+			)
+			require.Empty(t, errs)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCarryOverPartialChunk(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	w := NewBulkWriter(gdb, InsertFunc, WithChunkSize(2), WithPartialChunkPolicy(CarryOverPartialChunk))
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?), (?)")).
+		WithArgs("a", "b").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	require.NoError(t, w.Write(user{"a"}, user{"b"}, user{"c"}))
+	require.NoError(t, w.Flush())
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?), (?)")).
+		WithArgs("c", "d").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?)")).
+		WithArgs("e").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, w.Write(user{"d"}, user{"e"}))
+	require.NoError(t, w.Flush())
+	require.NoError(t, w.Close(context.Background()))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPadPartialChunkDeadLetters(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	const upsert = " ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)"
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	now := time.Date(2019, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?), (?)"+upsert)+"$").
+		WithArgs("a", "b").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?), (?)"+upsert)+"$").
+		WithArgs("c", "c").
+		WillReturnError(errors.New("deadlock"))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users_errors` (`error`, `failed_at`, `object`, `row_index`) VALUES (?, ?, ?, ?)")+"$").
+		WithArgs("deadlock", now, `{"Name":"c"}`, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	errs := BulkExecChunk(
+		gdb,
+		[]interface{}{user{"a"}, user{"b"}, user{"c"}},
+		InsertOnDuplicateKeyUpdateFunc,
+		2,
+		WithPartialChunkPolicy(PadPartialChunk),
+		WithDeadLetterTable("users_errors"),
+		WithNowFunc(func() time.Time { return now }),
+	)
+
+	assert.Equal(t, []error{errors.New("deadlock")}, errs)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
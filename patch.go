@@ -0,0 +1,292 @@
+package gormbulk
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/jinzhu/gorm"
+)
+
+// BulkPatch will upsert the objects with patch semantics where NULL values,
+// i.e. nil pointer fields, mean "don't touch". Only columns with a value in at
+// least one object are updated and columns with a value in only some of the
+// objects keep the existing value for the rest. For postgres the objects are
+// keyed on the columns set with WithSyncKeys or, if not set, the same columns
+// as SeedTable.
+//
+//  INSERT INTO `tbl` (`key`, `col1`, `col2`) VALUES (?, ?, ?), (?, ?, ?)
+//  ON DUPLICATE KEY UPDATE
+//    `col1` = VALUES(`col1`),
+//    `col2` = COALESCE(VALUES(`col2`), `col2`)
+func BulkPatch(db *gorm.DB, objects []interface{}, opts ...Option) error {
+	return bulkPatch(db, objects, newModelOptions(firstObject(objects), opts...))
+}
+
+// BulkPatchMaps works like BulkPatch but takes each patch as a map keyed by
+// column (or field) name, i.e. decoded from a JSON merge patch. Only the keys
+// present in each map are written and a nil value is the same as a missing
+// key. The model is used for the table and column names and every patch must
+// include the columns identifying the row, i.e. the primary key.
+//
+//  gormbulk.BulkPatchMaps(db, &User{}, []map[string]interface{}{
+//      {"id": 1, "name": "Alice"},
+//      {"id": 2, "email": "bob@example.com"},
+//  })
+func BulkPatchMaps(db *gorm.DB, model interface{}, patches []map[string]interface{}, opts ...Option) error {
+	o := newModelOptions(model, opts...)
+
+	objects := make([]interface{}, len(patches))
+
+	for i, patch := range patches {
+		object, err := patchObject(db, model, patch)
+		if err != nil {
+			return &RowError{Row: i, Err: err}
+		}
+
+		objects[i] = object
+	}
+
+	return bulkPatch(db.Table(o.namedTable(db.NewScope(model))), objects, o)
+}
+
+func bulkPatch(db *gorm.DB, objects []interface{}, o *options) (err error) {
+	objects, rows, err := o.validate(objects)
+	if err != nil {
+		return err
+	}
+
+	if len(objects) < 1 {
+		return nil
+	}
+
+	keys := o.syncKeys
+	if len(keys) == 0 && db.Dialect().GetName() == "postgres" {
+		fields, err := o.objectToMap(objects[0])
+		if err != nil {
+			return err
+		}
+
+		if keys = seedKeys(fields); len(keys) == 0 {
+			return ErrMissingSyncKeys
+		}
+	}
+
+	end, err := o.startSession(db)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if endErr := end(); err == nil {
+			err = endErr
+		}
+	}()
+
+	return execObjects(db, objects, rows, PatchFunc(keys...), o)
+}
+
+// patchTypeCache holds the patch type for each model type, keyed by
+// reflect.Type.
+var patchTypeCache sync.Map
+
+// patchType returns a struct type with a pointer field for every column of the
+// model, with the same names and tags, so a missing value is NULL.
+func patchType(db *gorm.DB, t reflect.Type) reflect.Type {
+	if pt, ok := patchTypeCache.Load(t); ok {
+		return pt.(reflect.Type)
+	}
+
+	var (
+		fields []reflect.StructField
+		names  = map[string]struct{}{}
+	)
+
+	for _, field := range db.NewScope(reflect.New(t).Interface()).GetStructFields() {
+		if !field.IsNormal || field.IsIgnored {
+			continue
+		}
+
+		if _, ok := names[field.Name]; ok {
+			continue
+		}
+
+		names[field.Name] = struct{}{}
+
+		fieldType := field.Struct.Type
+		if fieldType.Kind() != reflect.Ptr {
+			fieldType = reflect.PtrTo(fieldType)
+		}
+
+		fields = append(fields, reflect.StructField{
+			Name: field.Name,
+			Type: fieldType,
+			Tag:  field.Tag,
+		})
+	}
+
+	pt := reflect.StructOf(fields)
+	patchTypeCache.Store(t, pt)
+
+	return pt
+}
+
+// patchObject returns a pointer to a patch type value for the model with the
+// fields in the patch set. The keys are matched against the column and field
+// names.
+func patchObject(db *gorm.DB, model interface{}, patch map[string]interface{}) (interface{}, error) {
+	t := modelType(model)
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("model must be a struct, got %T", model)
+	}
+
+	var (
+		pt     = patchType(db, t)
+		object = reflect.New(pt)
+		fields = map[string]reflect.Value{}
+	)
+
+	for i := 0; i < pt.NumField(); i++ {
+		field := pt.Field(i)
+		fields[field.Name] = object.Elem().Field(i)
+	}
+
+	for _, field := range db.NewScope(object.Interface()).GetStructFields() {
+		if fv, ok := fields[field.Name]; ok {
+			fields[field.DBName] = fv
+		}
+	}
+
+	for key, value := range patch {
+		fv, ok := fields[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %s", key)
+		}
+
+		if value == nil {
+			continue
+		}
+
+		rv := reflect.ValueOf(value)
+		elemType := fv.Type().Elem()
+
+		// Only convert numbers to strings if bound as a string already, i.e.
+		// never convert 65 to "A".
+		convertible := rv.Type().ConvertibleTo(elemType) &&
+			(elemType.Kind() != reflect.String || rv.Kind() == reflect.String)
+
+		switch {
+		case rv.Type().AssignableTo(fv.Type()):
+			fv.Set(rv)
+		case convertible:
+			ptr := reflect.New(elemType)
+			ptr.Elem().Set(rv.Convert(elemType))
+			fv.Set(ptr)
+		default:
+			return nil, fmt.Errorf("invalid value %T for column %s", value, key)
+		}
+	}
+
+	return object.Interface(), nil
+}
+
+// PatchFunc returns the ExecFuncV2 used by BulkPatch. The keys are used as
+// conflict target for postgres.
+func PatchFunc(keys ...string) ExecFuncV2 {
+	return func(ctx *ExecContext) {
+		var (
+			scope    = ctx.Scope
+			postgres = ctx.Dialect.GetName() == "postgres"
+			present  = presentColumns(ctx)
+			updates  []string
+		)
+
+		excluded := excludedColumns(scope)
+
+		for i, column := range ctx.QuotedColumnNames {
+			// Don't update created at or excluded columns on duplicate.
+			if _, ok := excluded[column]; ok {
+				continue
+			}
+
+			var (
+				value    = fmt.Sprintf("VALUES(%s)", column)
+				existing = column
+			)
+
+			if postgres {
+				value = fmt.Sprintf("EXCLUDED.%s", column)
+				existing = fmt.Sprintf("%s.%s", scope.QuotedTableName(), column)
+			}
+
+			switch present[i] {
+			case 0:
+				continue
+			case len(ctx.Objects):
+				updates = append(updates, fmt.Sprintf("%s = %s", column, value))
+			default:
+				updates = append(updates, fmt.Sprintf("%s = COALESCE(%s, %s)", column, value, existing))
+			}
+		}
+
+		plainInsertFunc(ctx)
+
+		if postgres {
+			action := "DO NOTHING"
+			if len(updates) > 0 {
+				action = fmt.Sprintf("DO UPDATE SET %s", strings.Join(updates, ", "))
+			}
+
+			scope.Raw(fmt.Sprintf(
+				"%s ON CONFLICT (%s) %s",
+				scope.SQL,
+				strings.Join(quoteColumns(scope, keys), ", "),
+				action,
+			))
+
+			return
+		}
+
+		// Nothing to update but the statement must still ignore duplicates.
+		if len(updates) == 0 {
+			updates = []string{fmt.Sprintf("%s = %s", ctx.QuotedColumnNames[0], ctx.QuotedColumnNames[0])}
+		}
+
+		scope.Raw(fmt.Sprintf("%s ON DUPLICATE KEY UPDATE %s", scope.SQL, strings.Join(updates, ", ")))
+	}
+}
+
+// presentColumns returns the number of objects with a value that isn't NULL
+// for each column. Columns injected by the options are always present.
+func presentColumns(ctx *ExecContext) []int {
+	present := make([]int, len(ctx.Columns))
+
+	for _, object := range ctx.Objects {
+		fields, err := ObjectToMap(object)
+		if err != nil {
+			continue
+		}
+
+		// Match the fields by their struct field since the column name may
+		// differ from the name of the field.
+		structFields := make(map[*gorm.StructField]*gorm.Field, len(fields))
+		for _, field := range fields {
+			structFields[field.StructField] = field
+		}
+
+		for i, column := range ctx.Columns {
+			if column.Field == nil {
+				present[i]++
+				continue
+			}
+
+			field, ok := structFields[column.Field]
+			if ok && !isNull(fieldValue(field)) {
+				present[i]++
+			}
+		}
+	}
+
+	return present
+}
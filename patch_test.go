@@ -0,0 +1,195 @@
+package gormbulk
+
+import (
+	"database/sql/driver"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkPatch(t *testing.T) {
+	type user struct {
+		Email string `gorm:"unique"`
+		Name  *string
+		Age   *int
+		Nick  *string
+	}
+
+	type tag struct {
+		Name *string
+	}
+
+	var (
+		name = "a"
+		age  = 42
+	)
+
+	cases := []struct {
+		description string
+		dialect     string
+		objects     []interface{}
+		options     []Option
+		expectedSQL string
+	}{
+		{
+			description: "only present columns updated",
+			dialect:     "mysql",
+			objects: []interface{}{
+				user{Email: "a@example.com", Name: &name, Age: &age},
+				user{Email: "b@example.com", Name: &name},
+			},
+			expectedSQL: "INSERT INTO `users` (`age`, `email`, `name`, `nick`) VALUES (?, ?, ?, ?), (?, ?, ?, ?) " +
+				"ON DUPLICATE KEY UPDATE `age` = COALESCE(VALUES(`age`), `age`), `email` = VALUES(`email`), `name` = VALUES(`name`)",
+		},
+		{
+			description: "postgres keyed on unique column",
+			dialect:     "postgres",
+			objects: []interface{}{
+				user{Email: "a@example.com", Age: &age},
+				user{Email: "b@example.com"},
+			},
+			expectedSQL: `INSERT INTO "users" ("age", "email", "name", "nick") VALUES ($1, $2, $3, $4), ($5, $6, $7, $8) ` +
+				`ON CONFLICT ("email") DO UPDATE SET "age" = COALESCE(EXCLUDED."age", "users"."age"), "email" = EXCLUDED."email"`,
+		},
+		{
+			description: "postgres with sync keys and nothing to update",
+			dialect:     "postgres",
+			objects:     []interface{}{tag{}},
+			options:     []Option{WithSyncKeys("name")},
+			expectedSQL: `INSERT INTO "tags" ("name") VALUES ($1) ON CONFLICT ("name") DO NOTHING`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open(tc.dialect, db)
+			require.NoError(t, err)
+
+			mock.ExpectExec(regexp.QuoteMeta(tc.expectedSQL)).
+				WillReturnResult(sqlmock.NewResult(0, int64(len(tc.objects))))
+
+			require.NoError(t, BulkPatch(gdb, tc.objects, tc.options...))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestBulkPatchMaps(t *testing.T) {
+	type user struct {
+		ID    int64 `gorm:"primary_key"`
+		Name  string
+		Email string `gorm:"column:email_address"`
+		Age   int
+	}
+
+	cases := []struct {
+		description  string
+		dialect      string
+		patches      []map[string]interface{}
+		expectedSQL  string
+		expectedArgs []driver.Value
+		errContains  string
+	}{
+		{
+			description: "only present keys updated",
+			dialect:     "mysql",
+			patches: []map[string]interface{}{
+				{"id": 1, "name": "a", "age": 42.0},
+				{"id": 2, "Name": "b", "email_address": nil},
+			},
+			expectedSQL: "INSERT INTO `users` (`age`, `email_address`, `id`, `name`) VALUES (?, ?, ?, ?), (?, ?, ?, ?) " +
+				"ON DUPLICATE KEY UPDATE `age` = COALESCE(VALUES(`age`), `age`), `id` = VALUES(`id`), `name` = VALUES(`name`)",
+			expectedArgs: []driver.Value{42, nil, 1, "a", nil, nil, 2, "b"},
+		},
+		{
+			description: "postgres keyed on primary key",
+			dialect:     "postgres",
+			patches: []map[string]interface{}{
+				{"id": 1, "email_address": "a@example.com"},
+			},
+			expectedSQL: `INSERT INTO "users" ("age", "email_address", "id", "name") VALUES ($1, $2, $3, $4) ` +
+				`ON CONFLICT ("id") DO UPDATE SET "email_address" = EXCLUDED."email_address", "id" = EXCLUDED."id"`,
+			expectedArgs: []driver.Value{nil, "a@example.com", 1, nil},
+		},
+		{
+			description: "unknown column",
+			dialect:     "mysql",
+			patches: []map[string]interface{}{
+				{"id": 1},
+				{"id": 2, "nick": "b"},
+			},
+			errContains: "row 1: unknown column nick",
+		},
+		{
+			description: "invalid value",
+			dialect:     "mysql",
+			patches: []map[string]interface{}{
+				{"id": 1, "name": 65},
+			},
+			errContains: "row 0: invalid value int for column name",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open(tc.dialect, db)
+			require.NoError(t, err)
+
+			if tc.expectedSQL != "" {
+				mock.ExpectExec(regexp.QuoteMeta(tc.expectedSQL) + "$").
+					WithArgs(tc.expectedArgs...).
+					WillReturnResult(sqlmock.NewResult(0, int64(len(tc.patches))))
+			}
+
+			err = BulkPatchMaps(gdb, &user{}, tc.patches)
+			if tc.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errContains)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestBulkPatchSession(t *testing.T) {
+	type user struct {
+		Email string `gorm:"unique"`
+		Name  *string
+	}
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	name := "a"
+
+	mock.ExpectExec(regexp.QuoteMeta("SET unique_checks = 0")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users`")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("SET unique_checks = 1")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, BulkPatch(
+		gdb,
+		[]interface{}{user{Email: "a@example.com", Name: &name}},
+		WithSetupSQL("SET unique_checks = 0"),
+		WithTeardownSQL("SET unique_checks = 1"),
+	))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
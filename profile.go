@@ -0,0 +1,113 @@
+package gormbulk
+
+import (
+	"reflect"
+	"sync"
+)
+
+var (
+	profilesMu sync.RWMutex
+	profiles   = map[reflect.Type][]Option{}
+)
+
+// Configure registers default options for the model, i.e. columns excluded
+// from updates or the retry budget, so they can be set once at startup next to
+// the model definition instead of at every call site. The options are used by
+// all bulk functions for objects (or a model) of the same struct type, pointer
+// or not. Options passed to the bulk function are applied after the registered
+// options and take precedence. Calling Configure again for the same model
+// replaces the options and calling it without options removes them. A
+// BulkWriter and StreamChunks never use the registered options since the
+// model isn't known when they're created.
+//
+// WithChunkSize is only used by the functions which don't take a chunk size,
+// i.e. BulkExecAsync and BulkInsertCSV. The ExecFunc is always the one passed
+// to the bulk function and can't be registered for the model.
+//
+//  gormbulk.Configure(&User{},
+//      gormbulk.WithUpdateExcludedColumns("email"),
+//      gormbulk.WithRetryBudget(3, time.Second),
+//  )
+func Configure(model interface{}, opts ...Option) {
+	t := modelType(model)
+	if t == nil {
+		return
+	}
+
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+
+	if len(opts) == 0 {
+		delete(profiles, t)
+		return
+	}
+
+	profiles[t] = append([]Option{}, opts...)
+}
+
+// modelType returns the type of the model with all pointers dereferenced.
+func modelType(model interface{}) reflect.Type {
+	t := reflect.TypeOf(model)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+// OptionsProvider may be implemented by a model to declare its own options,
+// i.e. columns excluded from updates or the retry budget. The options are used
+// by all bulk calls for objects of the model's type, before the options
+// registered with Configure and the options passed to the call. The method
+// is called on a zero value of the model.
+//
+//  func (User) BulkOptions() []gormbulk.Option {
+//      return []gormbulk.Option{gormbulk.WithUpdateExcludedColumns("email")}
+//  }
+type OptionsProvider interface {
+	BulkOptions() []Option
+}
+
+// newModelOptions works like newOptions but applies the options declared by
+// the model and registered with Configure for the model first.
+func newModelOptions(model interface{}, opts ...Option) *options {
+	t := modelType(model)
+	if t == nil {
+		return newOptions(opts...)
+	}
+
+	var declared []Option
+
+	// A pointer to the type implements the interface regardless of the
+	// receiver.
+	if provider, ok := reflect.New(t).Interface().(OptionsProvider); ok {
+		declared = provider.BulkOptions()
+	}
+
+	profilesMu.RLock()
+	profile := profiles[t]
+	profilesMu.RUnlock()
+
+	if len(declared) == 0 && len(profile) == 0 {
+		return newOptions(opts...)
+	}
+
+	all := make([]Option, 0, len(declared)+len(profile)+len(opts))
+	all = append(all, declared...)
+	all = append(all, profile...)
+	all = append(all, opts...)
+
+	return newOptions(all...)
+}
+
+// firstObject returns the first object which isn't nil, used to find the
+// options registered for the objects.
+func firstObject(objects []interface{}) interface{} {
+	for _, object := range objects {
+		if !isNil(object) {
+			return object
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package gormbulk
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigure(t *testing.T) {
+	type user struct {
+		Name  string
+		Email string
+	}
+
+	const (
+		columns = " (`email`, `name`) VALUES (?, ?), (?, ?) ON DUPLICATE KEY UPDATE "
+		updated = "`email` = VALUES(`email`), `name` = VALUES(`name`)"
+	)
+
+	cases := []struct {
+		description string
+		model       interface{}
+		profile     []Option
+		opts        []Option
+		expectedSQL string
+	}{
+		{
+			description: "no profile",
+			model:       user{},
+			expectedSQL: "INSERT INTO `users`" + columns + updated,
+		},
+		{
+			description: "profile used",
+			model:       user{},
+			profile:     []Option{WithTablePrefix("app_"), WithUpdateExcludedColumns("email")},
+			expectedSQL: "INSERT INTO `app_users`" + columns + "`name` = VALUES(`name`)",
+		},
+		{
+			description: "profile registered with pointer",
+			model:       &user{},
+			profile:     []Option{WithTablePrefix("app_")},
+			expectedSQL: "INSERT INTO `app_users`" + columns + updated,
+		},
+		{
+			description: "call options take precedence",
+			model:       user{},
+			profile:     []Option{WithTablePrefix("app_")},
+			opts:        []Option{WithTablePrefix("tmp_")},
+			expectedSQL: "INSERT INTO `tmp_users`" + columns + updated,
+		},
+		{
+			description: "profile for other model not used",
+			model:       struct{ Name string }{},
+			profile:     []Option{WithTablePrefix("app_")},
+			expectedSQL: "INSERT INTO `users`" + columns + updated,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			Configure(tc.model, tc.profile...)
+			defer Configure(tc.model)
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			mock.ExpectExec(regexp.QuoteMeta(tc.expectedSQL)+"$").
+				WithArgs("a@example.com", "a", "b@example.com", "b").
+				WillReturnResult(sqlmock.NewResult(0, 2))
+
+			err = BulkInsertOnDuplicateKeyUpdate(
+				gdb,
+				[]interface{}{user{"a", "a@example.com"}, user{"b", "b@example.com"}},
+				tc.opts...,
+			)
+			require.NoError(t, err)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+type providerUser struct {
+	Name  string
+	Email string
+}
+
+func (*providerUser) TableName() string {
+	return "users"
+}
+
+func (providerUser) BulkOptions() []Option {
+	return []Option{WithTablePrefix("app_"), WithUpdateExcludedColumns("email")}
+}
+
+func TestOptionsProvider(t *testing.T) {
+	const columns = " (`email`, `name`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)"
+
+	cases := []struct {
+		description string
+		profile     []Option
+		opts        []Option
+		expectedSQL string
+	}{
+		{
+			description: "declared options used",
+			expectedSQL: "INSERT INTO `app_users`" + columns,
+		},
+		{
+			description: "profile takes precedence",
+			profile:     []Option{WithTablePrefix("model_")},
+			expectedSQL: "INSERT INTO `model_users`" + columns,
+		},
+		{
+			description: "call options take precedence",
+			profile:     []Option{WithTablePrefix("model_")},
+			opts:        []Option{WithTablePrefix("tmp_")},
+			expectedSQL: "INSERT INTO `tmp_users`" + columns,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			Configure(providerUser{}, tc.profile...)
+			defer Configure(providerUser{})
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			mock.ExpectExec(regexp.QuoteMeta(tc.expectedSQL)+"$").
+				WithArgs("a@example.com", "a").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			err = BulkInsertOnDuplicateKeyUpdate(
+				gdb,
+				[]interface{}{&providerUser{"a", "a@example.com"}},
+				tc.opts...,
+			)
+			require.NoError(t, err)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
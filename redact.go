@@ -0,0 +1,149 @@
+package gormbulk
+
+import (
+	"reflect"
+
+	"github.com/jinzhu/gorm"
+)
+
+// sensitiveVarsKey is the scope setting holding if each of the vars is bound
+// for a sensitive column, set when using WithStatementLog.
+const sensitiveVarsKey = "gormbulk:sensitive_vars"
+
+// RedactedValue replaces the redacted vars passed to the StatementLogFunc.
+const RedactedValue = "[REDACTED]"
+
+// StatementLogFunc is called with the SQL and the redacted vars before each
+// statement is executed.
+type StatementLogFunc func(sql string, vars []interface{})
+
+// WithStatementLog will call fn with the SQL and vars redacted by the Redactor
+// set with WithRedactor before executing any statement, i.e. to log or trace
+// the statements without leaking personal data. Like WithStatementCheck this
+// includes all statements executed by the bulk function.
+func WithStatementLog(fn StatementLogFunc) Option {
+	return func(o *options) {
+		o.statementLog = fn
+	}
+}
+
+// Redactor redacts the vars before they're passed to the StatementLogFunc.
+// Sensitive holds if each var is bound for a column tagged
+// `bulk:"sensitive"`. It's nil if not known, such as for statements not built
+// from the objects.
+type Redactor interface {
+	Redact(vars []interface{}, sensitive []bool) []interface{}
+}
+
+// RedactorFunc is a function implementing Redactor.
+type RedactorFunc func(vars []interface{}, sensitive []bool) []interface{}
+
+// Redact implements Redactor.
+func (fn RedactorFunc) Redact(vars []interface{}, sensitive []bool) []interface{} {
+	return fn(vars, sensitive)
+}
+
+// Bundled redactors.
+var (
+	// RedactAll replaces all vars with RedactedValue.
+	RedactAll Redactor = RedactorFunc(redactAll)
+
+	// RedactSensitive replaces the vars for columns tagged
+	// `bulk:"sensitive"` with RedactedValue. All vars are replaced if it's
+	// not known which are sensitive. This is the default redactor.
+	RedactSensitive Redactor = RedactorFunc(redactSensitive)
+
+	// RedactNone passes all vars as is.
+	RedactNone Redactor = RedactorFunc(redactNone)
+)
+
+// WithRedactor sets the Redactor used for the vars passed to the
+// StatementLogFunc set with WithStatementLog. Defaults to RedactSensitive.
+func WithRedactor(redactor Redactor) Option {
+	return func(o *options) {
+		o.redactor = redactor
+	}
+}
+
+func redactAll(vars []interface{}, _ []bool) []interface{} {
+	redacted := make([]interface{}, len(vars))
+	for i := range redacted {
+		redacted[i] = RedactedValue
+	}
+
+	return redacted
+}
+
+func redactSensitive(vars []interface{}, sensitive []bool) []interface{} {
+	if len(sensitive) != len(vars) {
+		return redactAll(vars, sensitive)
+	}
+
+	redacted := make([]interface{}, len(vars))
+	for i := range redacted {
+		redacted[i] = vars[i]
+
+		if sensitive[i] {
+			redacted[i] = RedactedValue
+		}
+	}
+
+	return redacted
+}
+
+func redactNone(vars []interface{}, _ []bool) []interface{} {
+	return append([]interface{}{}, vars...)
+}
+
+// isSensitiveField returns true if the field is tagged with
+// `bulk:"sensitive"`.
+func isSensitiveField(field *gorm.StructField) bool {
+	_, ok := bulkTagSettings(field)["SENSITIVE"]
+
+	return ok
+}
+
+// setSensitiveVars sets which of the vars in the scope are sensitive. The
+// rowVars are the vars for all rows, bound after the first before vars, and
+// rowSensitive holds if each of them is sensitive. Other vars are never
+// sensitive. Nothing is set if the row vars aren't bound in order, which
+// makes RedactSensitive redact all vars.
+func (o *options) setSensitiveVars(scope *gorm.Scope, rowVars []interface{}, rowSensitive []bool, before int) {
+	if o.statementLog == nil {
+		return
+	}
+
+	after := before + len(rowVars)
+	if after > len(scope.SQLVars) || !reflect.DeepEqual(scope.SQLVars[before:after], rowVars) {
+		return
+	}
+
+	sensitive := make([]bool, len(scope.SQLVars))
+	copy(sensitive[before:], rowSensitive)
+
+	scope.Set(sensitiveVarsKey, sensitive)
+}
+
+// sensitiveVars returns which of the vars in the scope are sensitive, nil if
+// not known.
+func sensitiveVars(scope *gorm.Scope) []bool {
+	sensitive, _ := scope.Get(sensitiveVarsKey)
+	s, _ := sensitive.([]bool)
+
+	return s
+}
+
+// logStatement calls the function set with WithStatementLog with the SQL and
+// the redacted vars.
+func (o *options) logStatement(sql string, vars []interface{}, sensitive []bool) {
+	if o.statementLog == nil {
+		return
+	}
+
+	redactor := o.redactor
+	if redactor == nil {
+		redactor = RedactSensitive
+	}
+
+	o.statementLog(sql, redactor.Redact(vars, sensitive))
+}
@@ -0,0 +1,167 @@
+package gormbulk
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithStatementLog(t *testing.T) {
+	type user struct {
+		Name  string
+		Email string `bulk:"sensitive"`
+	}
+
+	type loggedStatement struct {
+		sql  string
+		vars []interface{}
+	}
+
+	objects := []interface{}{
+		user{Name: "a", Email: "a@example.com"},
+		user{Name: "b", Email: "b@example.com"},
+		user{Name: "c", Email: "c@example.com"},
+	}
+
+	cases := []struct {
+		description string
+		opts        []Option
+		expected    []loggedStatement
+	}{
+		{
+			description: "sensitive columns are redacted by default",
+			expected: []loggedStatement{
+				{
+					sql:  "INSERT INTO `users` (`email`, `name`) VALUES (?, ?), (?, ?)",
+					vars: []interface{}{RedactedValue, "a", RedactedValue, "b"},
+				},
+				{
+					sql:  "INSERT INTO `users` (`email`, `name`) VALUES (?, ?)",
+					vars: []interface{}{RedactedValue, "c"},
+				},
+			},
+		},
+		{
+			description: "all vars redacted",
+			opts:        []Option{WithRedactor(RedactAll)},
+			expected: []loggedStatement{
+				{
+					sql:  "INSERT INTO `users` (`email`, `name`) VALUES (?, ?), (?, ?)",
+					vars: []interface{}{RedactedValue, RedactedValue, RedactedValue, RedactedValue},
+				},
+				{
+					sql:  "INSERT INTO `users` (`email`, `name`) VALUES (?, ?)",
+					vars: []interface{}{RedactedValue, RedactedValue},
+				},
+			},
+		},
+		{
+			description: "no vars redacted",
+			opts:        []Option{WithRedactor(RedactNone)},
+			expected: []loggedStatement{
+				{
+					sql:  "INSERT INTO `users` (`email`, `name`) VALUES (?, ?), (?, ?)",
+					vars: []interface{}{"a@example.com", "a", "b@example.com", "b"},
+				},
+				{
+					sql:  "INSERT INTO `users` (`email`, `name`) VALUES (?, ?)",
+					vars: []interface{}{"c@example.com", "c"},
+				},
+			},
+		},
+		{
+			description: "statement level vars are not redacted",
+			opts: []Option{
+				WithInsertOption("ON DUPLICATE KEY UPDATE `name` = ?", "x"),
+			},
+			expected: []loggedStatement{
+				{
+					sql:  "INSERT INTO `users` (`email`, `name`) VALUES (?, ?), (?, ?) ON DUPLICATE KEY UPDATE `name` = ?",
+					vars: []interface{}{RedactedValue, "a", RedactedValue, "b", "x"},
+				},
+				{
+					sql:  "INSERT INTO `users` (`email`, `name`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `name` = ?",
+					vars: []interface{}{RedactedValue, "c", "x"},
+				},
+			},
+		},
+		{
+			description: "reused statements",
+			opts:        []Option{WithStatementReuse()},
+			expected: []loggedStatement{
+				{
+					sql:  "INSERT INTO `users` (`email`, `name`) VALUES (?, ?), (?, ?)",
+					vars: []interface{}{RedactedValue, "a", RedactedValue, "b"},
+				},
+				{
+					sql:  "INSERT INTO `users` (`email`, `name`) VALUES (?, ?)",
+					vars: []interface{}{RedactedValue, "c"},
+				},
+			},
+		},
+		{
+			description: "multiple statements per round trip",
+			opts:        []Option{WithMultiStatement(2)},
+			expected: []loggedStatement{
+				{
+					sql:  "INSERT INTO `users` (`email`, `name`) VALUES (?, ?), (?, ?);\nINSERT INTO `users` (`email`, `name`) VALUES (?, ?)",
+					vars: []interface{}{RedactedValue, "a", RedactedValue, "b", RedactedValue, "c"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, _, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			var logged []loggedStatement
+
+			opts := append([]Option{
+				WithExecutor(noopExecutor),
+				WithStatementLog(func(sql string, vars []interface{}) {
+					logged = append(logged, loggedStatement{sql: sql, vars: vars})
+				}),
+			}, tc.opts...)
+
+			errs := BulkExecChunk(gdb, objects, InsertFunc, 2, opts...)
+			require.Empty(t, errs)
+
+			assert.Equal(t, tc.expected, logged)
+		})
+	}
+}
+
+func Test_redactSensitive(t *testing.T) {
+	cases := []struct {
+		description string
+		vars        []interface{}
+		sensitive   []bool
+		expected    []interface{}
+	}{
+		{
+			description: "only sensitive vars are redacted",
+			vars:        []interface{}{1, "a"},
+			sensitive:   []bool{false, true},
+			expected:    []interface{}{1, RedactedValue},
+		},
+		{
+			description: "unknown sensitive vars redacts all",
+			vars:        []interface{}{1, "a"},
+			expected:    []interface{}{RedactedValue, RedactedValue},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			assert.Equal(t, tc.expected, redactSensitive(tc.vars, tc.sensitive))
+		})
+	}
+}
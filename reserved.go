@@ -0,0 +1,101 @@
+package gormbulk
+
+import "strings"
+
+// commonReservedWords are reserved in all supported dialects.
+var commonReservedWords = []string{
+	"all", "alter", "and", "as", "asc", "between", "by", "case", "check",
+	"column", "constraint", "create", "cross", "default", "delete", "desc",
+	"distinct", "drop", "else", "exists", "false", "for", "foreign", "from",
+	"group", "having", "in", "index", "inner", "insert", "into", "is", "join",
+	"key", "left", "like", "limit", "not", "null", "on", "or", "order",
+	"outer", "primary", "references", "right", "select", "set", "table",
+	"then", "to", "true", "union", "unique", "update", "using", "values",
+	"when", "where", "with",
+}
+
+// dialectReservedWords are reserved words specific to a dialect.
+var dialectReservedWords = map[string][]string{
+	"mysql": {
+		"add", "before", "both", "call", "change", "condition", "database",
+		"databases", "dual", "fulltext", "ignore", "interval", "keys", "kill",
+		"leading", "load", "lock", "match", "mod", "natural", "option",
+		"range", "read", "regexp", "release", "rename", "repeat", "replace",
+		"require", "return", "rlike", "schema", "show", "signal", "spatial",
+		"sql", "trailing", "trigger", "usage", "use", "write", "xor",
+	},
+	"postgres": {
+		"analyse", "analyze", "any", "array", "asymmetric", "both",
+		"cast", "collate", "current_date", "current_role", "current_time",
+		"current_timestamp", "current_user", "deferrable", "do", "end",
+		"except", "fetch", "grant", "initially", "intersect", "lateral",
+		"leading", "localtime", "localtimestamp", "offset", "only", "placing",
+		"returning", "session_user", "some", "symmetric", "trailing", "user",
+		"variadic", "window",
+	},
+	"sqlite3": {
+		"abort", "action", "add", "after", "attach", "autoincrement",
+		"before", "begin", "cascade", "cast", "collate", "commit", "conflict",
+		"database", "deferrable", "deferred", "detach", "each", "end",
+		"escape", "except", "exclusive", "explain", "fail", "glob", "if",
+		"ignore", "immediate", "indexed", "initially", "instead",
+		"intersect", "isnull", "match", "natural", "no", "notnull", "of",
+		"offset", "plan", "pragma", "query", "raise", "recursive", "regexp",
+		"reindex", "release", "rename", "replace", "restrict", "rollback",
+		"row", "savepoint", "temp", "temporary", "transaction", "trigger",
+		"vacuum", "view", "virtual",
+	},
+}
+
+// WithReservedWordCheck will check the table and column names against a list
+// of reserved words for the dialect before executing, catching identifiers
+// which break custom ExecFuncs not quoting identifiers. If onReserved is nil a
+// *ReservedWordError is returned for the first reserved word. Otherwise
+// onReserved is called for every reserved word, i.e. to log a warning, and the
+// statement is executed.
+func WithReservedWordCheck(onReserved func(*ReservedWordError)) Option {
+	return func(o *options) {
+		o.checkReservedWords = true
+		o.onReserved = onReserved
+	}
+}
+
+// IsReservedWord returns true if the identifier is a reserved word in the
+// dialect. Identifiers are compared case insensitive.
+func IsReservedWord(dialect, identifier string) bool {
+	identifier = strings.ToLower(identifier)
+
+	for _, words := range [][]string{commonReservedWords, dialectReservedWords[dialect]} {
+		for _, word := range words {
+			if word == identifier {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// checkReserved checks the identifiers against the reserved words for the
+// dialect if using WithReservedWordCheck.
+func (o *options) checkReserved(dialect string, identifiers ...string) error {
+	if !o.checkReservedWords {
+		return nil
+	}
+
+	for _, identifier := range identifiers {
+		if !IsReservedWord(dialect, identifier) {
+			continue
+		}
+
+		err := &ReservedWordError{Dialect: dialect, Identifier: identifier}
+
+		if o.onReserved == nil {
+			return err
+		}
+
+		o.onReserved(err)
+	}
+
+	return nil
+}
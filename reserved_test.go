@@ -0,0 +1,61 @@
+package gormbulk
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsReservedWord(t *testing.T) {
+	cases := []struct {
+		dialect    string
+		identifier string
+		expected   bool
+	}{
+		{dialect: "mysql", identifier: "order", expected: true},
+		{dialect: "postgres", identifier: "GROUP", expected: true},
+		{dialect: "postgres", identifier: "user", expected: true},
+		{dialect: "mysql", identifier: "user", expected: false},
+		{dialect: "sqlite3", identifier: "name", expected: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.dialect+"/"+tc.identifier, func(t *testing.T) {
+			assert.Equal(t, tc.expected, IsReservedWord(tc.dialect, tc.identifier))
+		})
+	}
+}
+
+func TestWithReservedWordCheck(t *testing.T) {
+	type item struct {
+		Name  string
+		Order int
+		Key   string
+	}
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	objects := []interface{}{item{Name: "a"}}
+
+	err = BulkInsert(gdb, objects, WithReservedWordCheck(nil))
+	assert.Equal(t, &ReservedWordError{Dialect: "mysql", Identifier: "key"}, err)
+
+	mock.ExpectExec("INSERT INTO `items`").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var reserved []string
+
+	require.NoError(t, BulkInsert(gdb, objects, WithReservedWordCheck(func(err *ReservedWordError) {
+		reserved = append(reserved, err.Identifier)
+	})))
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, []string{"key", "order"}, reserved)
+}
@@ -0,0 +1,50 @@
+package gormbulk
+
+// Result holds details about a bulk operation. Pass a pointer to WithResult to
+// have it populated when the bulk function returns.
+type Result struct {
+	// Skipped holds all objects left out due to the SkipInvalid policy with
+	// the index in the passed slice and the reason.
+	Skipped []*RowError
+
+	// SkippedNil is the number of nil objects left out due to the SkipNil
+	// policy.
+	SkippedNil int
+
+	// SkippedStale is the number of objects left out since they were updated
+	// before the watermark set with WithWatermark.
+	SkippedStale int
+
+	// Unchanged is the number of objects left out since they didn't differ
+	// from the existing rows, when using WithSkipUnchanged.
+	Unchanged int
+
+	// RowsAffected is the sum of rows affected by all bulk statements as
+	// reported by the driver. Statements executed with WithExecutor are not
+	// counted.
+	RowsAffected int64
+
+	// BatchID is the batch ID stamped on every row with WithBatchID.
+	BatchID string
+
+	// StatementSizes holds the approximate encoded size of each bulk
+	// statement executed, in the order executed. Use it to tune the chunk size
+	// against the packet size limit of the server.
+	StatementSizes []StatementSize
+}
+
+// StatementSize is the approximate encoded size of a bulk statement, the SQL
+// and all the vars, in bytes. Statements sent in one round trip with
+// WithMultiStatement are counted as one.
+type StatementSize struct {
+	Chunk int
+	Bytes int
+}
+
+// WithResult will populate the passed Result with details about the bulk
+// operation.
+func WithResult(result *Result) Option {
+	return func(o *options) {
+		o.result = result
+	}
+}
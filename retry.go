@@ -0,0 +1,124 @@
+package gormbulk
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned in a *NotAttemptedError when no more chunks are
+// started because of too many consecutive failures, see WithCircuitBreaker.
+var ErrCircuitOpen = errors.New("circuit breaker open after consecutive failures")
+
+// retryState holds the retries left and the number of consecutive failures
+// for the bulk operation. It's shared by chunks executed concurrently.
+type retryState struct {
+	mu                  sync.Mutex
+	budget              int
+	backoff             time.Duration
+	breakerThreshold    int
+	consecutiveFailures int
+}
+
+// WithRetryBudget will retry failed statements, waiting backoff before each
+// retry, until a total of retries are made for the whole bulk operation. The
+// budget is shared by all chunks so a database being down won't cause a retry
+// storm for a big import. The whole chunk is executed again, including
+// statements for shards already executed (see WithShardFunc), so the
+// statements should be idempotent, i.e. upserts. Errors for a single row
+// (*RowError), round trips with multiple statements (see
+// WithMultiStatement) and IDs that can't be backfilled (see WithIDBackfill)
+// are never retried.
+func WithRetryBudget(retries int, backoff time.Duration) Option {
+	return func(o *options) {
+		if o.retryState == nil {
+			o.retryState = &retryState{}
+		}
+
+		o.retryState.budget = retries
+		o.retryState.backoff = backoff
+	}
+}
+
+// WithCircuitBreaker will stop starting new chunks after the number of
+// consecutive failed chunks, retries not counted. The rows not attempted are
+// returned in a *NotAttemptedError with ErrCircuitOpen.
+func WithCircuitBreaker(failures int) Option {
+	return func(o *options) {
+		if o.retryState == nil {
+			o.retryState = &retryState{}
+		}
+
+		o.retryState.breakerThreshold = failures
+	}
+}
+
+// retry returns true if the statement failing with err should be retried
+// and, if so, waits for the backoff. No retry is made if the budget is spent
+// or the context is done while waiting.
+func (o *options) retry(err error) bool {
+	if o.retryState == nil {
+		return false
+	}
+
+	switch err.(type) {
+	case *RowError, *MultiStatementError, *DeadLetterError, *IDBackfillError:
+		return false
+	}
+
+	o.retryState.mu.Lock()
+
+	if o.retryState.budget < 1 {
+		o.retryState.mu.Unlock()
+		return false
+	}
+
+	o.retryState.budget--
+	o.retryState.mu.Unlock()
+
+	ctx := o.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	select {
+	case <-time.After(o.retryState.backoff):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// chunkDone counts the consecutive failed chunks.
+func (s *retryState) chunkDone(err error) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err == nil {
+		s.consecutiveFailures = 0
+		return
+	}
+
+	s.consecutiveFailures++
+}
+
+// checkBreaker returns ErrCircuitOpen if no more chunks should be started.
+func (s *retryState) checkBreaker() error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.breakerThreshold > 0 && s.consecutiveFailures >= s.breakerThreshold {
+		return ErrCircuitOpen
+	}
+
+	return nil
+}
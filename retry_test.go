@@ -0,0 +1,100 @@
+package gormbulk
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRetryBudget(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	var (
+		errDown = errors.New("connection refused")
+		objects = []interface{}{user{"a"}, user{"b"}, user{"c"}}
+	)
+
+	cases := []struct {
+		description      string
+		opts             []Option
+		expectedMockFunc func(mock sqlmock.Sqlmock)
+		expectedErrs     []error
+	}{
+		{
+			description: "failed statement retried",
+			opts:        []Option{WithRetryBudget(1, 0)},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?), (?)")).
+					WillReturnError(errDown)
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?), (?)")).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?)")).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			description: "budget shared by all chunks",
+			opts:        []Option{WithRetryBudget(1, 0)},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?), (?)")).
+					WillReturnError(errDown)
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?), (?)")).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?)")).
+					WillReturnError(errDown)
+			},
+			expectedErrs: []error{errDown},
+		},
+		{
+			description: "circuit breaker stops chunks after consecutive failures",
+			opts:        []Option{WithCircuitBreaker(1)},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?), (?)")).
+					WillReturnError(errDown)
+			},
+			expectedErrs: []error{
+				errDown,
+				&NotAttemptedError{Rows: []int{2}, Err: ErrCircuitOpen},
+			},
+		},
+		{
+			description: "retries not counted as failures",
+			opts: []Option{
+				WithRetryBudget(1, 0),
+				WithCircuitBreaker(1),
+			},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?), (?)")).
+					WillReturnError(errDown)
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?), (?)")).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?)")).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			tc.expectedMockFunc(mock)
+
+			errs := BulkExecChunk(gdb, objects, InsertFunc, 2, tc.opts...)
+
+			assert.Equal(t, tc.expectedErrs, errs)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
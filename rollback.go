@@ -0,0 +1,76 @@
+package gormbulk
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// BulkRollbackBatch will delete all rows in the table for the model stamped
+// with the batch ID in the column (see WithBatchID). Rows are deleted in chunks
+// of DefaultChunkSize (see WithChunkSize) to not lock the whole table. If the
+// model has a DeletedAt field the rows are soft deleted unless the db is
+// unscoped, the same way as with gorm. The number of rows deleted is returned.
+// Since the rows affected aren't known when using WithExecutor, only the first
+// chunk is executed.
+//
+//  DELETE FROM `tbl` WHERE `batch_id` = ? LIMIT 1000
+//  UPDATE `tbl` SET `deleted_at` = ? WHERE `batch_id` = ? AND `deleted_at` IS NULL LIMIT 1000
+func BulkRollbackBatch(db *gorm.DB, model interface{}, column, batchID string, opts ...Option) (int64, error) {
+	var (
+		o         = newModelOptions(model, opts...)
+		scope     = db.NewScope(model)
+		table     = scope.Quote(o.scopeTableName(scope))
+		chunkSize = o.streamChunkSize()
+		where     = fmt.Sprintf("%s = ?", scope.Quote(column))
+		vars      []interface{}
+		deleted   int64
+	)
+
+	statement := func(where string) string {
+		return fmt.Sprintf("DELETE FROM %s WHERE %s", table, where)
+	}
+
+	if deletedAt, ok := scope.FieldByName("DeletedAt"); ok && !scope.Search.Unscoped {
+		quoted := scope.Quote(o.columnName(deletedAt.StructField))
+
+		where = fmt.Sprintf("%s AND %s IS NULL", where, quoted)
+		vars = append(vars, o.currentTime())
+
+		statement = func(where string) string {
+			return fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s", table, quoted, where)
+		}
+	}
+
+	vars = append(vars, batchID)
+
+	// Postgres and SQLite doesn't support a limit for deletes and updates so
+	// the rows are selected by their physical location.
+	var sql string
+
+	switch scope.Dialect().GetName() {
+	case "postgres":
+		sql = statement(fmt.Sprintf("ctid IN (SELECT ctid FROM %s WHERE %s LIMIT %d)", table, where, chunkSize))
+	case "sqlite3":
+		sql = statement(fmt.Sprintf("rowid IN (SELECT rowid FROM %s WHERE %s LIMIT %d)", table, where, chunkSize))
+	default:
+		sql = fmt.Sprintf("%s LIMIT %d", statement(where), chunkSize)
+	}
+
+	for {
+		if o.ctx != nil && o.ctx.Err() != nil {
+			return deleted, o.ctx.Err()
+		}
+
+		rowsAffected, err := o.execRowsAffected(db, sql, vars...)
+		if err != nil {
+			return deleted, err
+		}
+
+		deleted += rowsAffected
+
+		if rowsAffected < int64(chunkSize) {
+			return deleted, nil
+		}
+	}
+}
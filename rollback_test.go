@@ -0,0 +1,110 @@
+package gormbulk
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkRollbackBatch(t *testing.T) {
+	type user struct {
+		Name    string
+		BatchID string
+	}
+
+	type softUser struct {
+		Name      string
+		BatchID   string
+		DeletedAt *time.Time
+	}
+
+	now := time.Date(2019, 11, 1, 0, 0, 0, 0, time.UTC)
+	nowFunc := WithNowFunc(func() time.Time { return now })
+
+	cases := []struct {
+		description      string
+		dialect          string
+		model            interface{}
+		unscoped         bool
+		expectedMockFunc func(mock sqlmock.Sqlmock)
+		expectedDeleted  int64
+	}{
+		{
+			description: "deleted in chunks",
+			dialect:     "mysql",
+			model:       user{},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				for _, n := range []int64{2, 1} {
+					mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `users` WHERE `batch_id` = ? LIMIT 2")).
+						WithArgs("import-42").
+						WillReturnResult(sqlmock.NewResult(0, n))
+				}
+			},
+			expectedDeleted: 3,
+		},
+		{
+			description: "soft deleted",
+			dialect:     "mysql",
+			model:       softUser{},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(
+					"UPDATE `soft_users` SET `deleted_at` = ? WHERE `batch_id` = ? AND `deleted_at` IS NULL LIMIT 2",
+				)).
+					WithArgs(now, "import-42").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedDeleted: 1,
+		},
+		{
+			description: "unscoped deleted",
+			dialect:     "mysql",
+			model:       softUser{},
+			unscoped:    true,
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `soft_users` WHERE `batch_id` = ? LIMIT 2")).
+					WithArgs("import-42").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			description: "postgres deleted by ctid",
+			dialect:     "postgres",
+			model:       user{},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(
+					`DELETE FROM "users" WHERE ctid IN (SELECT ctid FROM "users" WHERE "batch_id" = $1 LIMIT 2)`,
+				)).
+					WithArgs("import-42").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedDeleted: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open(tc.dialect, db)
+			require.NoError(t, err)
+
+			if tc.unscoped {
+				gdb = gdb.Unscoped()
+			}
+
+			tc.expectedMockFunc(mock)
+
+			deleted, err := BulkRollbackBatch(gdb, tc.model, "batch_id", "import-42", WithChunkSize(2), nowFunc)
+			require.NoError(t, err)
+			require.NoError(t, mock.ExpectationsWereMet())
+
+			assert.Equal(t, tc.expectedDeleted, deleted)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package gormbulk
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// BulkInsertFromRows will scan all rows, i.e. from a query on another
+// connection, to objects of the same type as model and insert them in chunks
+// of DefaultChunkSize (see WithChunkSize). Each column in the result set must
+// match a column or field name of the model. Slices, maps and structs not
+// implementing sql.Scanner are scanned as JSON. The rows are closed when
+// returning.
+//
+//  rows, err := sourceDB.Table("users").Rows()
+//  err = gormbulk.BulkInsertFromRows(targetDB, rows, User{})
+func BulkInsertFromRows(db *gorm.DB, rows *sql.Rows, model interface{}, opts ...Option) error {
+	defer rows.Close()
+
+	modelType, err := structType(model)
+	if err != nil {
+		return err
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	fieldNames := make([]string, len(columns))
+	modelScope := &gorm.Scope{Value: reflect.New(modelType).Interface()}
+
+	for i, column := range columns {
+		field, ok := modelScope.FieldByName(column)
+		if !ok {
+			return fmt.Errorf("column %s not found in model", column)
+		}
+
+		fieldNames[i] = field.Name
+	}
+
+	row := 0
+
+	next := func() (interface{}, error) {
+		if !rows.Next() {
+			if err := rows.Err(); err != nil {
+				return nil, err
+			}
+
+			return nil, io.EOF
+		}
+
+		var (
+			object  = reflect.New(modelType)
+			scope   = &gorm.Scope{Value: object.Interface()}
+			targets = make([]interface{}, len(fieldNames))
+			decoded = map[int]*[]byte{}
+		)
+
+		for i, name := range fieldNames {
+			field, _ := scope.FieldByName(name)
+
+			if isJSONScanned(field.Field) {
+				decoded[i] = &[]byte{}
+				targets[i] = decoded[i]
+
+				continue
+			}
+
+			targets[i] = field.Field.Addr().Interface()
+		}
+
+		if err := rows.Scan(targets...); err != nil {
+			return nil, &RowError{Row: row, Err: err}
+		}
+
+		for i, data := range decoded {
+			if len(*data) == 0 {
+				continue
+			}
+
+			field, _ := scope.FieldByName(fieldNames[i])
+
+			if err := json.Unmarshal(*data, field.Field.Addr().Interface()); err != nil {
+				return nil, &RowError{
+					Row: row,
+					Err: fmt.Errorf("could not unmarshal column %s: %v", columns[i], err),
+				}
+			}
+		}
+
+		row++
+
+		return object.Interface(), nil
+	}
+
+	return streamObjects(db, next, ExecFunc(InsertFunc).toV2(), newModelOptions(model, opts...))
+}
+
+// isJSONScanned returns true if the field can't be scanned by database/sql and
+// should be scanned as JSON. This is true for slices (other than []byte), maps
+// and structs (other than time.Time) not implementing sql.Scanner.
+func isJSONScanned(fv reflect.Value) bool {
+	if _, ok := fv.Addr().Interface().(sql.Scanner); ok {
+		return false
+	}
+
+	switch fv.Kind() {
+	case reflect.Slice:
+		return fv.Type().Elem().Kind() != reflect.Uint8
+	case reflect.Map:
+		return true
+	case reflect.Struct:
+		return fv.Type() != reflect.TypeOf(time.Time{})
+	}
+
+	return false
+}
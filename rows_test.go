@@ -0,0 +1,106 @@
+package gormbulk
+
+import (
+	"database/sql/driver"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkInsertFromRows(t *testing.T) {
+	type copyUser struct {
+		Name string
+		Age  int
+		Tags []string `gorm:"type:json"`
+	}
+
+	cases := []struct {
+		description      string
+		columns          []string
+		rows             [][]driver.Value
+		options          []Option
+		expectedMockFunc func(mock sqlmock.Sqlmock)
+		expectedErr      string
+	}{
+		{
+			description: "rows copied",
+			columns:     []string{"name", "Age", "tags"},
+			rows: [][]driver.Value{
+				{"a", 1, []byte(`["x"]`)},
+				{"b", 2, nil},
+			},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `copy_users` (`age`, `name`, `tags`) VALUES (?, ?, ?), (?, ?, ?)")).
+					WithArgs(1, "a", `["x"]`, 2, "b", nil).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+		},
+		{
+			description: "rows copied in chunks",
+			columns:     []string{"name"},
+			rows:        [][]driver.Value{{"a"}, {"b"}, {"c"}},
+			options:     []Option{WithChunkSize(2)},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `copy_users` (`age`, `name`, `tags`) VALUES (?, ?, ?), (?, ?, ?)")).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `copy_users` (`age`, `name`, `tags`) VALUES (?, ?, ?)")).
+					WithArgs(0, "c", nil).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			description:      "unknown column",
+			columns:          []string{"name", "unknown"},
+			rows:             [][]driver.Value{{"a", 1}},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {},
+			expectedErr:      "column unknown not found in model",
+		},
+		{
+			description:      "invalid JSON reports row",
+			columns:          []string{"name", "tags"},
+			rows:             [][]driver.Value{{"a", []byte(`[`)}},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {},
+			expectedErr:      "row 0: could not unmarshal column tags",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			sourceDB, sourceMock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			mockRows := sqlmock.NewRows(tc.columns)
+			for _, row := range tc.rows {
+				mockRows.AddRow(row...)
+			}
+
+			sourceMock.ExpectQuery("SELECT").WillReturnRows(mockRows)
+
+			rows, err := sourceDB.Query("SELECT * FROM users")
+			require.NoError(t, err)
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			tc.expectedMockFunc(mock)
+
+			err = BulkInsertFromRows(gdb, rows, copyUser{}, tc.options...)
+
+			if tc.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package gormbulk
+
+import "github.com/jinzhu/gorm"
+
+// ScopeModifierFunc is called with the scope holding the SQL and vars built by
+// the ExecFunc. The SQL may be changed with scope.Raw and the vars by changing
+// scope.SQLVars.
+type ScopeModifierFunc func(scope *gorm.Scope)
+
+// WithScopeModifier will call fn with the scope after the ExecFunc but before
+// the comments and hints are added and the statement is executed, i.e. to
+// append clauses or rewrite table names without replacing the bundled
+// ExecFuncs. Modifiers are called in the order passed and for every
+// statement, so statements are never reused (see WithStatementReuse) when
+// using modifiers.
+func WithScopeModifier(fn ScopeModifierFunc) Option {
+	return func(o *options) {
+		o.scopeModifiers = append(o.scopeModifiers, fn)
+	}
+}
+
+// modifyScope calls all functions set with WithScopeModifier.
+func (o *options) modifyScope(scope *gorm.Scope) {
+	for _, modify := range o.scopeModifiers {
+		modify(scope)
+	}
+}
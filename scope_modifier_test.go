@@ -0,0 +1,112 @@
+package gormbulk
+
+import (
+	"database/sql/driver"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithScopeModifier(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	appendClause := func(scope *gorm.Scope) {
+		scope.Raw(scope.SQL + " ON DUPLICATE KEY UPDATE `name` = ?")
+		scope.SQLVars = append(scope.SQLVars, "c")
+	}
+
+	renameTable := func(scope *gorm.Scope) {
+		scope.Raw(strings.Replace(scope.SQL, "`users`", "`users_v2`", 1))
+	}
+
+	cases := []struct {
+		description  string
+		opts         []Option
+		expectedSQL  string
+		expectedArgs []driver.Value
+	}{
+		{
+			description:  "clause appended",
+			opts:         []Option{WithScopeModifier(appendClause)},
+			expectedSQL:  "INSERT INTO `users` (`name`) VALUES (?), (?) ON DUPLICATE KEY UPDATE `name` = ?",
+			expectedArgs: []driver.Value{"a", "b", "c"},
+		},
+		{
+			description: "modifiers called in order",
+			opts: []Option{
+				WithScopeModifier(renameTable),
+				WithScopeModifier(appendClause),
+			},
+			expectedSQL:  "INSERT INTO `users_v2` (`name`) VALUES (?), (?) ON DUPLICATE KEY UPDATE `name` = ?",
+			expectedArgs: []driver.Value{"a", "b", "c"},
+		},
+		{
+			description: "hints added after modifiers",
+			opts: []Option{
+				WithScopeModifier(renameTable),
+				WithOptimizerHint("NO_INDEX_MERGE(users_v2)"),
+			},
+			expectedSQL:  "INSERT /*+ NO_INDEX_MERGE(users_v2) */ INTO `users_v2` (`name`) VALUES (?), (?)",
+			expectedArgs: []driver.Value{"a", "b"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			mock.ExpectExec(regexp.QuoteMeta(tc.expectedSQL)).
+				WithArgs(tc.expectedArgs...).
+				WillReturnResult(sqlmock.NewResult(0, 2))
+
+			require.NoError(t, BulkInsert(gdb, []interface{}{user{"a"}, user{"b"}}, tc.opts...))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWithScopeModifierStatementReuse(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	var calls int
+
+	countCalls := func(scope *gorm.Scope) {
+		calls++
+	}
+
+	for _, args := range [][]driver.Value{{"a", "b"}, {"c", "d"}} {
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`name`) VALUES (?), (?)")).
+			WithArgs(args...).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+	}
+
+	errs := BulkExecChunk(
+		gdb,
+		[]interface{}{user{"a"}, user{"b"}, user{"c"}, user{"d"}},
+		InsertFunc,
+		2,
+		WithStatementReuse(),
+		WithScopeModifier(countCalls),
+	)
+	require.Empty(t, errs)
+	require.NoError(t, mock.ExpectationsWereMet())
+	require.Equal(t, 2, calls)
+}
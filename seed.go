@@ -0,0 +1,165 @@
+package gormbulk
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// SeedResult holds the summary of a SeedTable call.
+type SeedResult struct {
+	// Inserted is the number of objects not already present in the table.
+	Inserted int
+
+	// Present is the number of objects already present in the table. These
+	// rows are updated with the values from the objects.
+	Present int
+}
+
+// SeedTable will upsert the objects in a single transaction, intended for
+// seeding a table on application startup. The objects are keyed on the
+// columns set with WithSyncKeys or, if not set, the columns tagged as unique,
+// the columns tagged with a unique index or the primary key (in that order).
+// Since present rows are updated, seeding the same objects multiple times is
+// idempotent. The number of objects already present is counted before the
+// upsert and returned in the SeedResult.
+//
+//  SELECT COUNT(*) FROM `tbl` WHERE (`key`) IN ((?), (?))
+//  INSERT INTO `tbl` (`key`, `value`) VALUES (?, ?), (?, ?)
+//  ON DUPLICATE KEY UPDATE `key` = VALUES(`key`), `value` = VALUES(`value`)
+func SeedTable(db *gorm.DB, objects []interface{}, opts ...Option) (result *SeedResult, err error) {
+	o := newModelOptions(firstObject(objects), opts...)
+
+	objects, rows, err := o.validate(objects)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(objects) < 1 {
+		return &SeedResult{}, nil
+	}
+
+	keys := o.syncKeys
+	if len(keys) == 0 {
+		fields, err := o.objectToMap(objects[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if keys = seedKeys(fields); len(keys) == 0 {
+			return nil, ErrMissingSyncKeys
+		}
+	}
+
+	end, err := o.startSession(db)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if endErr := end(); err == nil && endErr != nil {
+			result, err = nil, endErr
+		}
+	}()
+
+	result = &SeedResult{}
+
+	err = o.transaction(db, func(tx *gorm.DB) error {
+		scope := tx.NewScope(objects[0])
+
+		in, vars, err := o.keysCondition(scope, objects, keys, "IN")
+		if err != nil {
+			return err
+		}
+
+		countSQL := fmt.Sprintf(
+			"SELECT COUNT(*) FROM %s WHERE %s",
+			scope.Quote(o.scopeTableName(scope)), in,
+		)
+
+		if result.Present, err = o.queryCount(tx, countSQL, vars...); err != nil {
+			return err
+		}
+
+		return execStatement(tx, objects, rows, seedFunc(keys), o)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Inserted = len(objects) - result.Present
+
+	return result, nil
+}
+
+// queryCount runs the query returning a single count. The count is always 0
+// when using WithExecutor since the executor returns no rows.
+func (o *options) queryCount(db *gorm.DB, query string, vars ...interface{}) (int, error) {
+	rows, err := o.queryRows(db, query, vars...)
+	if err != nil || rows == nil {
+		return 0, err
+	}
+
+	defer rows.Close()
+
+	var count int
+
+	if rows.Next() {
+		if err := rows.Scan(&count); err != nil {
+			return 0, err
+		}
+	}
+
+	return count, rows.Err()
+}
+
+// seedKeys returns the columns tagged as unique, or if none, the columns
+// tagged with a unique index, or if none, the primary key columns.
+func seedKeys(fields map[string]*gorm.Field) []string {
+	var unique, uniqueIndex, primaryKeys []string
+
+	for name, field := range fields {
+		if _, ok := field.TagSettingsGet("UNIQUE"); ok {
+			unique = append(unique, name)
+		}
+
+		if _, ok := field.TagSettingsGet("UNIQUE_INDEX"); ok {
+			uniqueIndex = append(uniqueIndex, name)
+		}
+
+		if field.IsPrimaryKey {
+			primaryKeys = append(primaryKeys, name)
+		}
+	}
+
+	for _, keys := range [][]string{unique, uniqueIndex, primaryKeys} {
+		if len(keys) > 0 {
+			sort.Strings(keys)
+			return keys
+		}
+	}
+
+	return nil
+}
+
+// seedFunc returns the ExecFuncV2 upserting the objects for the dialect. The
+// keys are used as conflict target for postgres.
+func seedFunc(keys []string) ExecFuncV2 {
+	return func(ctx *ExecContext) {
+		if ctx.Dialect.GetName() != "postgres" {
+			ExecFunc(InsertOnDuplicateKeyUpdateFunc).toV2()(ctx)
+			return
+		}
+
+		plainInsertFunc(ctx)
+
+		ctx.Scope.Raw(fmt.Sprintf(
+			"%s ON CONFLICT (%s) DO UPDATE SET %s",
+			ctx.Scope.SQL,
+			strings.Join(quoteColumns(ctx.Scope, keys), ", "),
+			strings.Join(excludedUpdates(ctx.Scope, ctx.QuotedColumnNames), ", "),
+		))
+	}
+}
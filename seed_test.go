@@ -0,0 +1,132 @@
+package gormbulk
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeedTable(t *testing.T) {
+	type role struct {
+		Name        string `gorm:"unique"`
+		Description string
+	}
+
+	objects := []interface{}{
+		role{Name: "admin", Description: "Administrator"},
+		role{Name: "user", Description: "User"},
+		role{Name: "guest", Description: "Guest"},
+	}
+
+	cases := []struct {
+		description      string
+		dialect          string
+		options          []Option
+		expectedMockFunc func(mock sqlmock.Sqlmock)
+		expectedResult   *SeedResult
+		expectedErr      error
+	}{
+		{
+			description: "keyed on unique column",
+			dialect:     "mysql",
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM `roles` WHERE (`name`) IN ((?), (?), (?))")).
+					WithArgs("admin", "user", "guest").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `roles` (`description`, `name`) VALUES (?, ?), (?, ?), (?, ?) ON DUPLICATE KEY UPDATE `description` = VALUES(`description`), `name` = VALUES(`name`)")).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			expectedResult: &SeedResult{Inserted: 1, Present: 2},
+		},
+		{
+			description: "postgres with sync keys",
+			dialect:     "postgres",
+			options:     []Option{WithSyncKeys("description")},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM "roles" WHERE ("description") IN (($1), ($2), ($3))`)).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "roles" ("description", "name") VALUES ($1, $2), ($3, $4), ($5, $6) ON CONFLICT ("description") DO UPDATE SET "description" = EXCLUDED."description", "name" = EXCLUDED."name"`)).
+					WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectCommit()
+			},
+			expectedResult: &SeedResult{Inserted: 3, Present: 0},
+		},
+		{
+			description: "rolled back on error",
+			dialect:     "mysql",
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*)")).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `roles`")).
+					WillReturnError(assert.AnError)
+				mock.ExpectRollback()
+			},
+			expectedErr: assert.AnError,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open(tc.dialect, db)
+			require.NoError(t, err)
+
+			tc.expectedMockFunc(mock)
+
+			result, err := SeedTable(gdb, objects, tc.options...)
+
+			if tc.expectedErr != nil {
+				assert.Equal(t, tc.expectedErr, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectedResult, result)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+
+	t.Run("keys are required", func(t *testing.T) {
+		type noKeys struct {
+			Name string
+		}
+
+		db, _, err := sqlmock.New()
+		require.NoError(t, err)
+
+		gdb, err := gorm.Open("mysql", db)
+		require.NoError(t, err)
+
+		_, err = SeedTable(gdb, []interface{}{noKeys{Name: "foo"}})
+		assert.Equal(t, ErrMissingSyncKeys, err)
+	})
+}
+
+func Test_seedKeys(t *testing.T) {
+	type test struct {
+		ID     int    `gorm:"primary_key;auto_increment:false"`
+		Code   string `gorm:"unique_index:idx_code_region"`
+		Region string `gorm:"unique_index:idx_code_region"`
+		Name   string
+	}
+
+	fields, err := ObjectToMap(test{ID: 1})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"code", "region"}, seedKeys(fields))
+
+	delete(fields, "code")
+	delete(fields, "region")
+
+	assert.Equal(t, []string{"id"}, seedKeys(fields))
+}
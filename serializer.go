@@ -0,0 +1,80 @@
+package gormbulk
+
+import (
+	"reflect"
+	"sync"
+)
+
+// SerializerFunc converts a value of a registered type to a value supported by
+// the driver. The returned value may also be a SQL expression created with
+// gorm.Expr, i.e. gorm.Expr("POINT(?, ?)", p.X, p.Y).
+type SerializerFunc func(value interface{}) (interface{}, error)
+
+var (
+	serializersMu sync.RWMutex
+	serializers   = map[reflect.Type]SerializerFunc{}
+)
+
+// RegisterSerializer will register a SerializerFunc for all values with the
+// same type as typ, i.e. RegisterSerializer(uuid.UUID{}, fn). The serializer
+// is used by all bulk calls and may be overridden per call with
+// WithSerializer.
+func RegisterSerializer(typ interface{}, fn SerializerFunc) {
+	serializersMu.Lock()
+	defer serializersMu.Unlock()
+
+	serializers[reflect.TypeOf(typ)] = fn
+}
+
+// WithSerializer will use the SerializerFunc for all values with the same type
+// as typ for this call only. It takes precedence over any serializer
+// registered with RegisterSerializer.
+func WithSerializer(typ interface{}, fn SerializerFunc) Option {
+	return func(o *options) {
+		if o.serializers == nil {
+			o.serializers = map[reflect.Type]SerializerFunc{}
+		}
+
+		o.serializers[reflect.TypeOf(typ)] = fn
+	}
+}
+
+// serializer returns the SerializerFunc for the type, looking at the options
+// first and the global registry second.
+func (o *options) serializer(t reflect.Type) (SerializerFunc, bool) {
+	if fn, ok := o.serializers[t]; ok {
+		return fn, true
+	}
+
+	serializersMu.RLock()
+	defer serializersMu.RUnlock()
+
+	fn, ok := serializers[t]
+
+	return fn, ok
+}
+
+// serialize will serialize the value if a SerializerFunc is registered for its
+// type. If no serializer is found for a pointer, the type it points to is
+// tried. The boolean returned reports if a serializer was used.
+func (o *options) serialize(value interface{}) (interface{}, bool, error) {
+	if value == nil {
+		return nil, false, nil
+	}
+
+	rv := reflect.ValueOf(value)
+
+	if fn, ok := o.serializer(rv.Type()); ok {
+		v, err := fn(value)
+		return v, true, err
+	}
+
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		if fn, ok := o.serializer(rv.Elem().Type()); ok {
+			v, err := fn(rv.Elem().Interface())
+			return v, true, err
+		}
+	}
+
+	return value, false, nil
+}
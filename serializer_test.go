@@ -0,0 +1,111 @@
+package gormbulk
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type serializerTestType struct {
+	A, B int
+}
+
+func TestSerializer(t *testing.T) {
+	RegisterSerializer(net.IP{}, func(v interface{}) (interface{}, error) {
+		return v.(net.IP).String(), nil
+	})
+
+	RegisterSerializer(serializerTestType{}, func(v interface{}) (interface{}, error) {
+		st := v.(serializerTestType)
+		return fmt.Sprintf("%d-%d", st.A, st.B), nil
+	})
+
+	defer func() {
+		serializersMu.Lock()
+		delete(serializers, reflect.TypeOf(net.IP{}))
+		delete(serializers, reflect.TypeOf(serializerTestType{}))
+		serializersMu.Unlock()
+	}()
+
+	type test struct {
+		IP     net.IP
+		Custom serializerTestType
+		Ptr    *serializerTestType
+	}
+
+	object := test{
+		IP:     net.ParseIP("127.0.0.1"),
+		Custom: serializerTestType{1, 2},
+		Ptr:    &serializerTestType{3, 4},
+	}
+
+	cases := []struct {
+		description   string
+		column        string
+		options       []Option
+		expectedValue interface{}
+		errContains   string
+	}{
+		{
+			description:   "global serializer",
+			column:        "ip",
+			expectedValue: "127.0.0.1",
+		},
+		{
+			description:   "global serializer for struct",
+			column:        "custom",
+			expectedValue: "1-2",
+		},
+		{
+			description:   "pointer uses serializer for element type",
+			column:        "ptr",
+			expectedValue: "3-4",
+		},
+		{
+			description: "per call serializer takes precedence",
+			column:      "custom",
+			options: []Option{
+				WithSerializer(serializerTestType{}, func(v interface{}) (interface{}, error) {
+					return "overridden", nil
+				}),
+			},
+			expectedValue: "overridden",
+		},
+		{
+			description: "errors are returned",
+			column:      "custom",
+			options: []Option{
+				WithSerializer(serializerTestType{}, func(v interface{}) (interface{}, error) {
+					return nil, errors.New("oops")
+				}),
+			},
+			errContains: "could not serialize column custom: oops",
+		},
+	}
+
+	fields, err := ObjectToMap(object)
+	require.NoError(t, err)
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			field, ok := fields[tc.column]
+			require.True(t, ok)
+
+			value, err := newOptions(tc.options...).convertValue(field, fieldValue(field))
+			if tc.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errContains)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedValue, value)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package gormbulk
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// foreignKeyChecks holds the statements to disable and enable foreign key
+// checks for the session for each dialect.
+var foreignKeyChecks = map[string][2]string{
+	"mysql":    {"SET foreign_key_checks = 0", "SET foreign_key_checks = 1"},
+	"postgres": {"SET session_replication_role = replica", "SET session_replication_role = DEFAULT"},
+	"sqlite3":  {"PRAGMA foreign_keys = OFF", "PRAGMA foreign_keys = ON"},
+}
+
+// WithSetupSQL will execute the statements before the bulk operation, i.e.
+// `SET unique_checks = 0`. The statements are executed on the same connection
+// as the bulk operation, see WithPinnedConnection, or in the transaction if
+// the db passed is a transaction.
+func WithSetupSQL(statements ...string) Option {
+	return func(o *options) {
+		o.setupSQL = append(o.setupSQL, statements...)
+	}
+}
+
+// WithTeardownSQL will execute the statements after the bulk operation, i.e.
+// `SET unique_checks = 1`, the same way as WithSetupSQL. The statements are
+// executed even if the setup or bulk operation failed.
+func WithTeardownSQL(statements ...string) Option {
+	return func(o *options) {
+		o.teardownSQL = append(o.teardownSQL, statements...)
+	}
+}
+
+// WithForeignKeyChecksDisabled will disable foreign key checks for the session
+// before the bulk operation and enable them again when done, even if the bulk
+// operation failed. The checks are disabled before any statements set with
+// WithSetupSQL and enabled after any statements set with WithTeardownSQL.
+// Since the connection is pinned the checks are also disabled in the
+// transactions used by WithAuditTable, BulkSync and SeedTable.
+// Postgres requires a superuser to disable the checks and SQLite doesn't
+// disable the checks within a transaction.
+func WithForeignKeyChecksDisabled() Option {
+	return func(o *options) {
+		o.foreignKeyChecksDisabled = true
+	}
+}
+
+// hasSession returns true if there are statements to run before or after the
+// bulk operation, including taking an advisory lock.
+func (o *options) hasSession() bool {
+	return len(o.setupSQL) > 0 || len(o.teardownSQL) > 0 || o.foreignKeyChecksDisabled || o.advisoryLock != ""
+}
+
+// sessionSQL returns the statements to run before and after the bulk
+// operation for the dialect.
+func (o *options) sessionSQL(dialect string) ([]string, []string, error) {
+	if !o.foreignKeyChecksDisabled {
+		return o.setupSQL, o.teardownSQL, nil
+	}
+
+	statements, ok := foreignKeyChecks[dialect]
+	if !ok {
+		return nil, nil, fmt.Errorf("foreign key checks can't be disabled for dialect %s", dialect)
+	}
+
+	setup := append([]string{statements[0]}, o.setupSQL...)
+	teardown := append(append([]string{}, o.teardownSQL...), statements[1])
+
+	return setup, teardown, nil
+}
+
+// startSession acquires the guard (if used), pins the connection (if used),
+// takes the advisory lock (if used) and executes the setup statements. The
+// returned function must be called when the bulk operation is done to execute
+// the teardown statements and release the lock, connection and guard. It
+// returns the first error from the teardown statements.
+func (o *options) startSession(db *gorm.DB) (func() error, error) {
+	setup, teardown, err := o.sessionSQL(db.Dialect().GetName())
+	if err != nil {
+		return nil, err
+	}
+
+	releaseGuard, err := o.acquireGuard()
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := o.pin(db)
+	if err != nil {
+		_ = releaseGuard()
+		return nil, err
+	}
+
+	unlock, err := o.lock(db)
+	if err != nil {
+		release()
+		_ = releaseGuard()
+
+		return nil, err
+	}
+
+	end := func() error {
+		var teardownErr error
+
+		for _, statement := range teardown {
+			if err := o.exec(db, statement); err != nil && teardownErr == nil {
+				teardownErr = err
+			}
+		}
+
+		if err := unlock(); err != nil && teardownErr == nil {
+			teardownErr = err
+		}
+
+		release()
+
+		if err := releaseGuard(); err != nil && teardownErr == nil {
+			teardownErr = err
+		}
+
+		return teardownErr
+	}
+
+	for _, statement := range setup {
+		if err := o.exec(db, statement); err != nil {
+			_ = end()
+			return nil, err
+		}
+	}
+
+	return end, nil
+}
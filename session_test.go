@@ -0,0 +1,271 @@
+package gormbulk
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithSetupAndTeardownSQL(t *testing.T) {
+	type item struct {
+		Name string
+	}
+
+	cases := []struct {
+		description string
+		setupErr    error
+		insertErr   error
+		expectedErr error
+	}{
+		{
+			description: "setup and teardown around the bulk operation",
+		},
+		{
+			description: "teardown after failed insert",
+			insertErr:   errors.New("insert failed"),
+			expectedErr: errors.New("insert failed"),
+		},
+		{
+			description: "teardown after failed setup",
+			setupErr:    errors.New("setup failed"),
+			expectedErr: errors.New("setup failed"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			mock.ExpectExec("SET unique_checks = 0").
+				WillReturnResult(sqlmock.NewResult(0, 0))
+
+			if tc.setupErr != nil {
+				mock.ExpectExec("ALTER TABLE `items` DISABLE KEYS").
+					WillReturnError(tc.setupErr)
+			} else {
+				mock.ExpectExec("ALTER TABLE `items` DISABLE KEYS").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+
+				insert := mock.ExpectExec("INSERT INTO `items`").WithArgs("a")
+				if tc.insertErr != nil {
+					insert.WillReturnError(tc.insertErr)
+				} else {
+					insert.WillReturnResult(sqlmock.NewResult(0, 1))
+				}
+			}
+
+			mock.ExpectExec("ALTER TABLE `items` ENABLE KEYS").
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec("SET unique_checks = 1").
+				WillReturnResult(sqlmock.NewResult(0, 0))
+
+			err = BulkInsert(
+				gdb, []interface{}{item{Name: "a"}},
+				WithSetupSQL("SET unique_checks = 0", "ALTER TABLE `items` DISABLE KEYS"),
+				WithTeardownSQL("ALTER TABLE `items` ENABLE KEYS", "SET unique_checks = 1"),
+			)
+			require.NoError(t, mock.ExpectationsWereMet())
+
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, 0, db.Stats().InUse)
+		})
+	}
+}
+
+func TestWithForeignKeyChecksDisabled(t *testing.T) {
+	type item struct {
+		Name string
+	}
+
+	cases := []struct {
+		dialect  string
+		disable  string
+		insert   string
+		enable   string
+		errorMsg string
+	}{
+		{
+			dialect: "mysql",
+			disable: "SET foreign_key_checks = 0",
+			insert:  "INSERT INTO `items` (`name`) VALUES (?)",
+			enable:  "SET foreign_key_checks = 1",
+		},
+		{
+			dialect: "postgres",
+			disable: "SET session_replication_role = replica",
+			insert:  `INSERT INTO "items" ("name") VALUES ($1)`,
+			enable:  "SET session_replication_role = DEFAULT",
+		},
+		{
+			dialect:  "common",
+			errorMsg: "foreign key checks can't be disabled for dialect common",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.dialect, func(t *testing.T) {
+			db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open(tc.dialect, db)
+			require.NoError(t, err)
+
+			objects := []interface{}{item{Name: "a"}}
+
+			if tc.errorMsg != "" {
+				err := BulkInsert(gdb, objects, WithForeignKeyChecksDisabled())
+				require.EqualError(t, err, tc.errorMsg)
+				require.NoError(t, mock.ExpectationsWereMet())
+
+				return
+			}
+
+			mock.ExpectExec(tc.disable).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec("SET unique_checks = 0").
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(tc.insert).
+				WillReturnError(errors.New("insert failed"))
+			mock.ExpectExec("SET unique_checks = 1").
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(tc.enable).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+
+			err = BulkInsert(
+				gdb, objects,
+				WithForeignKeyChecksDisabled(),
+				WithSetupSQL("SET unique_checks = 0"),
+				WithTeardownSQL("SET unique_checks = 1"),
+			)
+			require.EqualError(t, err, "insert failed")
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestForeignKeyChecksDisabledTransaction(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	connector := &sessionConnector{}
+
+	db := sql.OpenDB(connector)
+	db.SetMaxOpenConns(1)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, BulkInsert(
+		gdb, []interface{}{user{Name: "a"}},
+		WithContext(ctx),
+		WithForeignKeyChecksDisabled(),
+		WithAuditTable("users_audit"),
+	))
+
+	require.Len(t, connector.connections, 1)
+	assert.Equal(t, []string{
+		"SET foreign_key_checks = 0",
+		"BEGIN",
+		"INSERT INTO `users` (`name`) VALUES (?)",
+		"INSERT INTO `users_audit` (`audited_at`, `name`, `operation`) VALUES (?, ?, ?)",
+		"COMMIT",
+		"SET foreign_key_checks = 1",
+	}, connector.connections[0])
+}
+
+func TestSessionEmptyInput(t *testing.T) {
+	type item struct {
+		Name string `gorm:"unique"`
+	}
+
+	var (
+		guard = &fakeGuard{}
+		opts  = []Option{
+			WithSetupSQL("SET unique_checks = 0"),
+			WithTeardownSQL("SET unique_checks = 1"),
+			WithAdvisoryLock("import"),
+			WithGuard(guard),
+			WithSyncKeys("name"),
+		}
+	)
+
+	cases := []struct {
+		description string
+		fn          func(db *gorm.DB) error
+	}{
+		{
+			description: "BulkExec",
+			fn: func(db *gorm.DB) error {
+				return BulkExec(db, nil, InsertFunc, opts...)
+			},
+		},
+		{
+			description: "BulkExecChunk",
+			fn: func(db *gorm.DB) error {
+				if errs := BulkExecChunk(db, []interface{}{}, InsertFunc, 10, opts...); len(errs) > 0 {
+					return errs[0]
+				}
+
+				return nil
+			},
+		},
+		{
+			description: "BulkSync",
+			fn: func(db *gorm.DB) error {
+				return BulkSync(db, nil, append(opts, WithDeleteMissing())...)
+			},
+		},
+		{
+			description: "SeedTable",
+			fn: func(db *gorm.DB) error {
+				_, err := SeedTable(db, nil, opts...)
+				return err
+			},
+		},
+		{
+			description: "BulkPatch",
+			fn: func(db *gorm.DB) error {
+				return BulkPatch(db, nil, opts...)
+			},
+		},
+		{
+			description: "only nil objects",
+			fn: func(db *gorm.DB) error {
+				return BulkExec(db, []interface{}{nil, (*item)(nil)}, InsertFunc, append(opts, WithNilPolicy(SkipNil))...)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			guard.calls = nil
+
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			// No statements are expected.
+			require.NoError(t, tc.fn(gdb))
+			require.NoError(t, mock.ExpectationsWereMet())
+
+			assert.Empty(t, guard.calls)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package gormbulk
+
+// ShardFunc returns the name of the table the object should be written to.
+type ShardFunc func(object interface{}) string
+
+// WithShardFunc will route each object to the table returned by fn. One bulk
+// statement is executed for each table, in the order the tables are first
+// returned by fn.
+func WithShardFunc(fn ShardFunc) Option {
+	return func(o *options) {
+		o.shardFunc = fn
+	}
+}
+
+// shard holds the objects routed to a single table.
+type shard struct {
+	table   string
+	objects []interface{}
+	rows    []int
+}
+
+// shards groups the objects by the table returned by the shard func.
+func (o *options) shards(objects []interface{}, rows []int) []*shard {
+	var (
+		shards  []*shard
+		byTable = map[string]*shard{}
+	)
+
+	for i, object := range objects {
+		table := o.shardFunc(object)
+
+		s, ok := byTable[table]
+		if !ok {
+			s = &shard{table: table}
+			byTable[table] = s
+			shards = append(shards, s)
+		}
+
+		s.objects = append(s.objects, object)
+		s.rows = append(s.rows, rows[i])
+	}
+
+	return shards
+}
@@ -0,0 +1,51 @@
+package gormbulk
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardFunc(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	type event struct {
+		Month int
+		Name  string
+	}
+
+	objects := []interface{}{
+		event{Month: 5, Name: "a"},
+		event{Month: 4, Name: "b"},
+		&event{Month: 5, Name: "c"},
+	}
+
+	shardFunc := func(object interface{}) string {
+		switch e := object.(type) {
+		case event:
+			return fmt.Sprintf("events_2019_%02d", e.Month)
+		case *event:
+			return fmt.Sprintf("events_2019_%02d", e.Month)
+		}
+
+		return "events"
+	}
+
+	mock.ExpectExec("INSERT INTO `events_2019_05` \\(`month`, `name`\\)").
+		WithArgs(5, "a", 5, "c").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	mock.ExpectExec("INSERT INTO `events_2019_04` \\(`month`, `name`\\)").
+		WithArgs(4, "b").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, BulkInsert(gdb, objects, WithShardFunc(shardFunc)))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,42 @@
+package gormbulk
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ToInterfaceSlice converts any slice or array, or a pointer to one, to a
+// slice of interfaces which may be passed to the bulk functions, i.e.
+// []MyType or []*MyType. A nil value returns nil and any other kind will
+// panic.
+//
+//  err := gormbulk.BulkInsert(db, gormbulk.ToInterfaceSlice(myTypes))
+func ToInterfaceSlice(v interface{}) []interface{} {
+	if v == nil {
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		panic(fmt.Sprintf("gormbulk: ToInterfaceSlice called with non slice type %T", v))
+	}
+
+	if rv.Kind() == reflect.Slice && rv.IsNil() {
+		return nil
+	}
+
+	objects := make([]interface{}, rv.Len())
+	for i := range objects {
+		objects[i] = rv.Index(i).Interface()
+	}
+
+	return objects
+}
@@ -0,0 +1,21 @@
+//go:build go1.18
+// +build go1.18
+
+package gormbulk
+
+// ToInterfaceSliceOf works like ToInterfaceSlice but is type safe and doesn't
+// use reflection. It requires Go 1.18 or later.
+//
+//  err := gormbulk.BulkInsert(db, gormbulk.ToInterfaceSliceOf(myTypes))
+func ToInterfaceSliceOf[T any](s []T) []interface{} {
+	if s == nil {
+		return nil
+	}
+
+	objects := make([]interface{}, len(s))
+	for i := range s {
+		objects[i] = s[i]
+	}
+
+	return objects
+}
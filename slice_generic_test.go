@@ -0,0 +1,22 @@
+//go:build go1.18
+// +build go1.18
+
+package gormbulk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToInterfaceSliceOf(t *testing.T) {
+	type test struct {
+		Name string
+	}
+
+	var nilSlice []test
+
+	assert.Equal(t, []interface{}{test{"a"}, test{"b"}}, ToInterfaceSliceOf([]test{{"a"}, {"b"}}))
+	assert.Equal(t, []interface{}{}, ToInterfaceSliceOf([]test{}))
+	assert.Nil(t, ToInterfaceSliceOf(nilSlice))
+}
@@ -0,0 +1,69 @@
+package gormbulk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToInterfaceSlice(t *testing.T) {
+	type test struct {
+		Name string
+	}
+
+	var (
+		a        = test{Name: "a"}
+		b        = test{Name: "b"}
+		nilSlice []test
+	)
+
+	cases := []struct {
+		description string
+		value       interface{}
+		expected    []interface{}
+	}{
+		{
+			description: "slice of structs",
+			value:       []test{a, b},
+			expected:    []interface{}{a, b},
+		},
+		{
+			description: "slice of pointers",
+			value:       []*test{&a, &b},
+			expected:    []interface{}{&a, &b},
+		},
+		{
+			description: "pointer to slice",
+			value:       &[]test{a},
+			expected:    []interface{}{a},
+		},
+		{
+			description: "array",
+			value:       [2]test{a, b},
+			expected:    []interface{}{a, b},
+		},
+		{
+			description: "empty slice",
+			value:       []test{},
+			expected:    []interface{}{},
+		},
+		{
+			description: "nil slice",
+			value:       nilSlice,
+			expected:    nil,
+		},
+		{
+			description: "nil",
+			value:       nil,
+			expected:    nil,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ToInterfaceSlice(tc.value))
+		})
+	}
+
+	assert.Panics(t, func() { ToInterfaceSlice(a) })
+}
@@ -0,0 +1,179 @@
+package gormbulk
+
+import (
+	"container/list"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/jinzhu/gorm"
+)
+
+// DefaultStatementCacheSize is the number of statement shapes kept by the
+// cache used with WithStatementReuse.
+const DefaultStatementCacheSize = 16
+
+// WithStatementReuse will reuse the SQL built for a previous statement when
+// the next statement has the same shape, that is the same table, columns and
+// placeholders, and only bind the new vars. This avoids calling the ExecFunc
+// and building the SQL for every chunk, i.e. when executing thousands of small
+// chunks with BulkExecChunk. The SQL is only reused if the ExecFunc binds the
+// vars for all rows in order, which all bundled ExecFuncs do, and the ExecFunc
+// must not build the SQL from the values of the objects. The last
+// DefaultStatementCacheSize shapes are kept for the call. Statements are never
+// reused when using WithScopeModifier.
+func WithStatementReuse() Option {
+	return func(o *options) {
+		o.statementCache = NewStatementCache(DefaultStatementCacheSize)
+	}
+}
+
+// WithStatementCache works like WithStatementReuse but keeps the shapes in
+// the cache, which may be shared between calls, i.e. by a service inserting
+// the same shapes over and over again. The shape doesn't include the ExecFunc
+// or options such as WithComment, so the cache must only be shared between
+// calls using the same ExecFunc and options.
+func WithStatementCache(cache *StatementCache) Option {
+	return func(o *options) {
+		o.statementCache = cache
+	}
+}
+
+// StatementCache holds the SQL and statement level vars for the least
+// recently used statement shapes, keyed by the table, columns and
+// placeholders. It's safe to use concurrently.
+type StatementCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// cachedStatement is the SQL and statement level vars for a shape.
+type cachedStatement struct {
+	key        string
+	sql        string
+	varsBefore []interface{}
+	varsAfter  []interface{}
+}
+
+// NewStatementCache returns a cache keeping at most size statement shapes. A
+// size less than one keeps a single shape.
+func NewStatementCache(size int) *StatementCache {
+	if size < 1 {
+		size = 1
+	}
+
+	return &StatementCache{
+		size:    size,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+// Len returns the number of statement shapes in the cache.
+func (c *StatementCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// shapeSettings are the scope settings read by the bundled ExecFuncs and
+// decorateSQL which must be the same to reuse the SQL.
+var shapeSettings = []string{
+	nullColumnsKey,
+	versionColumnKey,
+	checksumColumnKey,
+	excludedColumnsKey,
+	insertOptionVarsKey,
+	"gorm:insert_option",
+	"gorm:query_option",
+}
+
+// shapeKey returns a key identifying the SQL built for the scope, columns and
+// placeholder groups.
+func shapeKey(scope *gorm.Scope, quotedColumnNames, groups []string) string {
+	parts := []string{
+		scope.QuotedTableName(),
+		strings.Join(quotedColumnNames, ", "),
+		strings.Join(groups, ", "),
+	}
+
+	for _, setting := range shapeSettings {
+		value, _ := scope.Get(setting)
+		parts = append(parts, fmt.Sprint(value))
+	}
+
+	return strings.Join(parts, "\x00")
+}
+
+// reuse sets the SQL from a previous statement on the scope if the shape is
+// cached and binds the statement level vars around the row vars already set
+// on the scope. The number of vars bound before the row vars is returned. A
+// nil cache never reuses any SQL.
+func (c *StatementCache) reuse(scope *gorm.Scope, key string) (int, bool) {
+	if c == nil {
+		return 0, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return 0, false
+	}
+
+	c.order.MoveToFront(element)
+
+	cached := element.Value.(*cachedStatement)
+
+	vars := make([]interface{}, 0, len(cached.varsBefore)+len(scope.SQLVars)+len(cached.varsAfter))
+	vars = append(vars, cached.varsBefore...)
+	vars = append(vars, scope.SQLVars...)
+	vars = append(vars, cached.varsAfter...)
+
+	scope.Raw(cached.sql)
+	scope.SQLVars = vars
+
+	return len(cached.varsBefore), true
+}
+
+// store saves the SQL built for the scope with the key and evicts the least
+// recently used shape if the cache is full. The rowVars are the vars for all
+// rows and before is the number of vars bound before them. The SQL is only
+// saved if the rows vars are bound in order.
+func (c *StatementCache) store(scope *gorm.Scope, key string, rowVars []interface{}, before int) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+
+	after := before + len(rowVars)
+	if after > len(scope.SQLVars) || !reflect.DeepEqual(scope.SQLVars[before:after], rowVars) {
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cachedStatement{
+		key:        key,
+		sql:        scope.SQL,
+		varsBefore: append([]interface{}{}, scope.SQLVars[:before]...),
+		varsAfter:  append([]interface{}{}, scope.SQLVars[after:]...),
+	})
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedStatement).key)
+	}
+}
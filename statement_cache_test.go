@@ -0,0 +1,257 @@
+package gormbulk
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithStatementReuse(t *testing.T) {
+	type item struct {
+		Name  string
+		Value int
+	}
+
+	objects := []interface{}{
+		item{Name: "a", Value: 1},
+		item{Name: "b", Value: 2},
+		item{Name: "c", Value: 3},
+		item{Name: "d", Value: 4},
+		item{Name: "e", Value: 5},
+	}
+
+	reversed := func(ctx *ExecContext) {
+		columnNames, groups, vars := ctx.SelectColumns(1, 0)
+
+		ctx.Scope.Raw(fmt.Sprintf(
+			"INSERT INTO %s (%s) VALUES %s",
+			ctx.Scope.QuotedTableName(),
+			strings.Join(columnNames, ", "),
+			strings.Join(groups, ", "),
+		))
+
+		ctx.Scope.SQLVars = vars
+	}
+
+	cases := []struct {
+		description   string
+		execFunc      ExecFuncV2
+		opts          []Option
+		expectedSQL   []string
+		expectedArgs  [][]driver.Value
+		expectedCalls int
+	}{
+		{
+			description: "same shape is reused",
+			execFunc:    ExecFunc(InsertFunc).toV2(),
+			opts:        []Option{WithStatementReuse()},
+			expectedSQL: []string{
+				"INSERT INTO `items` (`name`, `value`) VALUES (?, ?), (?, ?)",
+				"INSERT INTO `items` (`name`, `value`) VALUES (?, ?), (?, ?)",
+				"INSERT INTO `items` (`name`, `value`) VALUES (?, ?)",
+			},
+			expectedArgs: [][]driver.Value{
+				{"a", 1, "b", 2},
+				{"c", 3, "d", 4},
+				{"e", 5},
+			},
+			expectedCalls: 2,
+		},
+		{
+			description: "statement level vars are reused",
+			execFunc:    ExecFunc(InsertFunc).toV2(),
+			opts: []Option{
+				WithStatementReuse(),
+				WithInsertOption("ON DUPLICATE KEY UPDATE `value` = ?", 0),
+			},
+			expectedSQL: []string{
+				"INSERT INTO `items` (`name`, `value`) VALUES (?, ?), (?, ?) ON DUPLICATE KEY UPDATE `value` = ?",
+				"INSERT INTO `items` (`name`, `value`) VALUES (?, ?), (?, ?) ON DUPLICATE KEY UPDATE `value` = ?",
+				"INSERT INTO `items` (`name`, `value`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `value` = ?",
+			},
+			expectedArgs: [][]driver.Value{
+				{"a", 1, "b", 2, 0},
+				{"c", 3, "d", 4, 0},
+				{"e", 5, 0},
+			},
+			expectedCalls: 2,
+		},
+		{
+			description: "not reused if vars are reordered",
+			execFunc:    reversed,
+			opts:        []Option{WithStatementReuse()},
+			expectedSQL: []string{
+				"INSERT INTO `items` (`value`, `name`) VALUES (?, ?), (?, ?)",
+				"INSERT INTO `items` (`value`, `name`) VALUES (?, ?), (?, ?)",
+				"INSERT INTO `items` (`value`, `name`) VALUES (?, ?)",
+			},
+			expectedArgs: [][]driver.Value{
+				{1, "a", 2, "b"},
+				{3, "c", 4, "d"},
+				{5, "e"},
+			},
+			expectedCalls: 3,
+		},
+		{
+			description: "not reused without option",
+			execFunc:    ExecFunc(InsertFunc).toV2(),
+			expectedSQL: []string{
+				"INSERT INTO `items` (`name`, `value`) VALUES (?, ?), (?, ?)",
+				"INSERT INTO `items` (`name`, `value`) VALUES (?, ?), (?, ?)",
+				"INSERT INTO `items` (`name`, `value`) VALUES (?, ?)",
+			},
+			expectedArgs: [][]driver.Value{
+				{"a", 1, "b", 2},
+				{"c", 3, "d", 4},
+				{"e", 5},
+			},
+			expectedCalls: 3,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			for i, sql := range tc.expectedSQL {
+				mock.ExpectExec(regexp.QuoteMeta(sql)).
+					WithArgs(tc.expectedArgs[i]...).
+					WillReturnResult(sqlmock.NewResult(0, int64(len(tc.expectedArgs[i])/2)))
+			}
+
+			var calls int
+
+			execFunc := func(ctx *ExecContext) {
+				calls++
+				tc.execFunc(ctx)
+			}
+
+			errs := BulkExecChunkV2(gdb, objects, execFunc, 2, tc.opts...)
+			require.Empty(t, errs)
+			require.NoError(t, mock.ExpectationsWereMet())
+
+			assert.Equal(t, tc.expectedCalls, calls)
+		})
+	}
+}
+
+func TestWithStatementCache(t *testing.T) {
+	type item struct {
+		Name string
+	}
+
+	cases := []struct {
+		description   string
+		size          int
+		chunkSizes    []int
+		expectedCalls int
+		expectedLen   int
+	}{
+		{
+			description:   "shapes reused between calls",
+			size:          2,
+			chunkSizes:    []int{2, 1, 2, 1},
+			expectedCalls: 2,
+			expectedLen:   2,
+		},
+		{
+			description:   "least recently used shape evicted",
+			size:          1,
+			chunkSizes:    []int{2, 1, 2, 1},
+			expectedCalls: 4,
+			expectedLen:   1,
+		},
+		{
+			description:   "same shape reused with smallest cache",
+			size:          0,
+			chunkSizes:    []int{2, 2, 2},
+			expectedCalls: 1,
+			expectedLen:   1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			var (
+				cache = NewStatementCache(tc.size)
+				calls int
+			)
+
+			execFunc := func(ctx *ExecContext) {
+				calls++
+				ExecFunc(InsertFunc).toV2()(ctx)
+			}
+
+			for _, n := range tc.chunkSizes {
+				var (
+					objects = make([]interface{}, n)
+					args    = make([]driver.Value, n)
+				)
+
+				for i := range objects {
+					objects[i] = item{Name: fmt.Sprintf("item %d", i)}
+					args[i] = fmt.Sprintf("item %d", i)
+				}
+
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `items` (`name`) VALUES (?)")).
+					WithArgs(args...).
+					WillReturnResult(sqlmock.NewResult(0, int64(n)))
+
+				require.NoError(t, BulkExecV2(gdb, objects, execFunc, WithStatementCache(cache)))
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+
+			assert.Equal(t, tc.expectedCalls, calls)
+			assert.Equal(t, tc.expectedLen, cache.Len())
+		})
+	}
+}
+
+func TestWithStatementCacheInsertOption(t *testing.T) {
+	type item struct {
+		Name   string
+		Status string
+	}
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	cache := NewStatementCache(2)
+
+	for _, status := range []string{"archived", "deleted"} {
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `items` (`name`, `status`) VALUES (?, ?) ON DUPLICATE KEY UPDATE status = ?")).
+			WithArgs("a", "new", status).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, BulkInsert(
+			gdb,
+			[]interface{}{item{Name: "a", Status: "new"}},
+			WithStatementCache(cache),
+			WithInsertOption("ON DUPLICATE KEY UPDATE status = ?", status),
+		))
+	}
+
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, 2, cache.Len())
+}
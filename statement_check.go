@@ -0,0 +1,30 @@
+package gormbulk
+
+// StatementCheckFunc is called with the final SQL and vars before any
+// statement is executed. Returning an error vetoes the statement, which is
+// then not executed and the error is returned by the bulk function.
+type StatementCheckFunc func(sql string, vars []interface{}) error
+
+// WithStatementCheck will call fn before executing any statement, i.e. to
+// enforce an allow list of tables or clauses for dynamically built bulk
+// statements. This includes statements for syncing, deleting and the setup
+// and teardown of a session as well as queries, such as selecting existing
+// rows for WithSkipUnchanged and counting rows in SeedTable. Statements are
+// checked even when using WithExecutor.
+func WithStatementCheck(fn StatementCheckFunc) Option {
+	return func(o *options) {
+		o.statementChecks = append(o.statementChecks, fn)
+	}
+}
+
+// checkStatement calls all functions set with WithStatementCheck and returns
+// the first error.
+func (o *options) checkStatement(sql string, vars []interface{}) error {
+	for _, check := range o.statementChecks {
+		if err := check(sql, vars); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
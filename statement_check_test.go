@@ -0,0 +1,177 @@
+package gormbulk
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithStatementCheck(t *testing.T) {
+	type item struct {
+		Name string
+	}
+
+	errNotAllowed := errors.New("table not allowed")
+
+	allowTables := func(tables ...string) StatementCheckFunc {
+		return func(sql string, _ []interface{}) error {
+			for _, table := range tables {
+				if strings.HasPrefix(sql, "INSERT INTO "+table+" ") {
+					return nil
+				}
+			}
+
+			return errNotAllowed
+		}
+	}
+
+	cases := []struct {
+		description string
+		tables      []string
+		opts        []Option
+		executed    bool
+		expectedErr error
+	}{
+		{
+			description: "allowed statement is executed",
+			tables:      []string{"`items`"},
+			executed:    true,
+		},
+		{
+			description: "vetoed statement is not executed",
+			tables:      []string{"`users`"},
+			expectedErr: errNotAllowed,
+		},
+		{
+			description: "vetoed setup statement",
+			tables:      []string{"`items`"},
+			opts:        []Option{WithSetupSQL("SET unique_checks = 0")},
+			expectedErr: errNotAllowed,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			if tc.executed {
+				mock.ExpectExec("INSERT INTO `items`").
+					WithArgs("a").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			var checked [][]interface{}
+
+			opts := append([]Option{
+				WithStatementCheck(func(_ string, vars []interface{}) error {
+					checked = append(checked, vars)
+					return nil
+				}),
+				WithStatementCheck(allowTables(tc.tables...)),
+			}, tc.opts...)
+
+			err = BulkInsert(gdb, []interface{}{item{Name: "a"}}, opts...)
+			require.NoError(t, mock.ExpectationsWereMet())
+
+			assert.Equal(t, tc.expectedErr, err)
+
+			if tc.executed {
+				assert.Equal(t, [][]interface{}{{"a"}}, checked)
+			}
+		})
+	}
+}
+
+func TestWithStatementCheckQueries(t *testing.T) {
+	type item struct {
+		Name string `gorm:"unique"`
+	}
+
+	errNotAllowed := errors.New("select not allowed")
+
+	noSelect := func(sql string, _ []interface{}) error {
+		if strings.HasPrefix(sql, "SELECT") {
+			return errNotAllowed
+		}
+
+		return nil
+	}
+
+	cases := []struct {
+		description string
+		fn          func(db *gorm.DB, opts ...Option) error
+		expectedSQL string
+	}{
+		{
+			description: "existing rows selected by WithSkipUnchanged",
+			fn: func(db *gorm.DB, opts ...Option) error {
+				return BulkInsert(db, []interface{}{item{Name: "a"}}, append(opts, WithSkipUnchanged())...)
+			},
+			expectedSQL: "SELECT * FROM `items` WHERE (`name`) IN ((?))",
+		},
+		{
+			description: "present rows counted by SeedTable",
+			fn: func(db *gorm.DB, opts ...Option) error {
+				_, err := SeedTable(db, []interface{}{item{Name: "a"}}, opts...)
+				return err
+			},
+			expectedSQL: "SELECT COUNT(*) FROM `items` WHERE (`name`) IN ((?))",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			// SeedTable runs in a transaction which is first rolled back and
+			// then committed when using the executor.
+			mock.ExpectBegin()
+			mock.ExpectRollback()
+			mock.ExpectBegin()
+			mock.ExpectCommit()
+
+			var logged []string
+
+			err = tc.fn(
+				gdb,
+				WithStatementCheck(noSelect),
+				WithStatementLog(func(sql string, _ []interface{}) {
+					logged = append(logged, sql)
+				}),
+			)
+			assert.Equal(t, errNotAllowed, err)
+
+			// The vetoed query is never logged or executed.
+			assert.Empty(t, logged)
+
+			var executed []string
+
+			executor := ExecutorFunc(func(_ *gorm.DB, sql string, _ ...interface{}) error {
+				executed = append(executed, sql)
+				return nil
+			})
+
+			logStatement := WithStatementLog(func(sql string, _ []interface{}) {
+				logged = append(logged, sql)
+			})
+
+			require.NoError(t, tc.fn(gdb, WithExecutor(executor), logStatement))
+			require.NotEmpty(t, executed)
+			assert.Equal(t, tc.expectedSQL, executed[0])
+			assert.Equal(t, executed, logged)
+		})
+	}
+}
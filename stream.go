@@ -0,0 +1,141 @@
+package gormbulk
+
+import (
+	"errors"
+	"io"
+	"reflect"
+
+	"github.com/jinzhu/gorm"
+)
+
+// DefaultChunkSize is the number of objects in each statement when reading
+// objects from a reader, unless set with WithChunkSize.
+const DefaultChunkSize = 1000
+
+// WithChunkSize sets the number of objects in each statement when reading
+// objects from a reader, i.e. with BulkInsertCSV or BulkInsertJSONLines.
+func WithChunkSize(size int) Option {
+	return func(o *options) {
+		o.chunkSize = size
+	}
+}
+
+// streamObjects will call next until it returns io.EOF and execute the SQL for
+// each chunk of objects. Rows are counted from the first object returned by
+// next so errors identify the row in the whole stream and not only in the
+// chunk. Rows are only deleted (see SyncDeleteMissing) after all chunks are
+// written.
+func streamObjects(db *gorm.DB, next func() (interface{}, error), execFunc ExecFuncV2, o *options) (err error) {
+	var (
+		chunk      []interface{}
+		allObjects []interface{}
+		chunkSize  = o.streamChunkSize()
+	)
+
+	if err := o.probeHealth(db); err != nil {
+		return err
+	}
+
+	end, err := o.startSession(db)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if endErr := end(); err == nil {
+			err = endErr
+		}
+	}()
+
+	flush := func() error {
+		objects, err := o.execChunk(db, chunk, execFunc)
+		if err != nil {
+			return err
+		}
+
+		if o.deleteMissing {
+			allObjects = append(allObjects, objects...)
+		}
+
+		chunk = nil
+
+		return nil
+	}
+
+	for {
+		object, err := next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		chunk = append(chunk, object)
+
+		if len(chunk) >= chunkSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(chunk) > 0 {
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+
+	if o.deleteMissing {
+		return deleteMissing(db, allObjects, o)
+	}
+
+	return nil
+}
+
+// streamChunkSize returns the chunk size set with WithChunkSize or
+// DefaultChunkSize if not set.
+func (o *options) streamChunkSize() int {
+	if o.chunkSize < 1 {
+		return DefaultChunkSize
+	}
+
+	return o.chunkSize
+}
+
+// execChunk validates and executes the SQL for one chunk of streamed objects
+// and returns the valid objects. The row offset is moved past the chunk so
+// rows are counted from the first object streamed and the chunk is counted.
+func (o *options) execChunk(db *gorm.DB, chunk []interface{}, execFunc ExecFuncV2) ([]interface{}, error) {
+	objects, rows, err := o.validate(chunk)
+	if err != nil {
+		return nil, err
+	}
+
+	o.rowOffset += len(chunk)
+
+	err = o.execPadded(db, objects, rows, execFunc, o.streamChunkSize())
+	o.chunk++
+
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// structType returns the struct type of the model, which may be a struct or a
+// pointer to a struct.
+func structType(model interface{}) (reflect.Type, error) {
+	t := reflect.TypeOf(model)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, errors.New("model must be kind of Struct")
+	}
+
+	return t, nil
+}
@@ -0,0 +1,320 @@
+package gormbulk
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ErrMissingSyncKeys is returned when the sync keys are required but not set.
+var ErrMissingSyncKeys = errors.New("sync keys must be set with WithSyncKeys")
+
+// WithSyncKeys sets the key columns identifying a row when syncing with
+// BulkSync. The keys are required for the postgres dialect (used as conflict
+// target) and to delete missing rows.
+func WithSyncKeys(keyColumns ...string) Option {
+	return func(o *options) {
+		o.syncKeys = keyColumns
+	}
+}
+
+// WithStagingTable sets the name of the staging table used by BulkSync. The
+// default is the target table name with the suffix `_staging`.
+func WithStagingTable(table string) Option {
+	return func(o *options) {
+		o.stagingTable = table
+	}
+}
+
+// WithDeleteMissing will make BulkSync delete all rows in the target table
+// with keys (see WithSyncKeys) not present in the staging table.
+func WithDeleteMissing() Option {
+	return func(o *options) {
+		o.deleteMissing = true
+	}
+}
+
+// SyncDeleteMissing will, after all objects are written, delete all rows in
+// the target table with keys not present in the objects. This is the same as
+// setting both WithSyncKeys and WithDeleteMissing and may be used with any
+// bulk function, i.e. BulkInsertOnDuplicateKeyUpdate, to reconcile a full
+// table. Nothing is deleted if any statement fails.
+//
+//  DELETE FROM `tbl` WHERE (`key1`, `key2`) NOT IN ((?, ?), (?, ?))
+func SyncDeleteMissing(keyColumns ...string) Option {
+	return func(o *options) {
+		o.syncKeys = keyColumns
+		o.deleteMissing = true
+	}
+}
+
+// WithDeleteMissingWhere will limit which rows are deleted when deleting
+// missing rows, i.e. WithDeleteMissingWhere("tenant_id = ?", 42).
+func WithDeleteMissingWhere(where string, args ...interface{}) Option {
+	return func(o *options) {
+		o.deleteMissingWhere = where
+		o.deleteMissingArgs = args
+	}
+}
+
+// BulkSync will sync the objects to the table in a single transaction using a
+// staging table. The staging table is created as a temporary table (or emptied
+// if it already exists), all objects are inserted to the staging table and
+// the target table is updated with one single set based upsert. Rows missing
+// in the objects may optionally be deleted with WithDeleteMissing.
+//
+//  CREATE TEMPORARY TABLE IF NOT EXISTS `tbl_staging` LIKE `tbl`
+//  DELETE FROM `tbl_staging`
+//  INSERT INTO `tbl_staging` (col1, col2) VALUES (?, ?), (?, ?)
+//  INSERT INTO `tbl` (col1, col2)
+//    SELECT col1, col2 FROM `tbl_staging`
+//  ON DUPLICATE KEY UPDATE
+//    col1 = VALUES(col1),
+//    col2 = VALUES(col2)
+func BulkSync(db *gorm.DB, objects []interface{}, opts ...Option) (err error) {
+	o := newModelOptions(firstObject(objects), opts...)
+
+	objects, rows, err := o.validate(objects)
+	if err != nil {
+		return err
+	}
+
+	// Never sync an empty batch since that would delete all rows when
+	// deleting missing rows.
+	if len(objects) < 1 {
+		return nil
+	}
+
+	if o.deleteMissing && len(o.syncKeys) == 0 {
+		return ErrMissingSyncKeys
+	}
+
+	end, err := o.startSession(db)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if endErr := end(); err == nil {
+			err = endErr
+		}
+	}()
+
+	return o.transaction(db, func(tx *gorm.DB) error {
+		return syncObjects(tx, objects, rows, o)
+	})
+}
+
+func syncObjects(tx *gorm.DB, objects []interface{}, rows []int, o *options) error {
+	var (
+		scope        = tx.NewScope(objects[0])
+		dialect      = scope.Dialect().GetName()
+		target       = o.scopeTableName(scope)
+		staging      = o.stagingTable
+		stagingScope *gorm.Scope
+		columnNames  []string
+	)
+
+	if staging == "" {
+		staging = fmt.Sprintf("%s_staging", target)
+	}
+
+	if dialect == "postgres" && len(o.syncKeys) == 0 {
+		return ErrMissingSyncKeys
+	}
+
+	var (
+		quotedTarget  = scope.Quote(target)
+		quotedStaging = scope.Quote(staging)
+	)
+
+	createSQL := fmt.Sprintf("CREATE TEMPORARY TABLE IF NOT EXISTS %s LIKE %s", quotedStaging, quotedTarget)
+	if dialect == "postgres" {
+		createSQL = fmt.Sprintf("CREATE TEMPORARY TABLE IF NOT EXISTS %s (LIKE %s INCLUDING DEFAULTS)", quotedStaging, quotedTarget)
+	}
+
+	if err := o.exec(tx, createSQL); err != nil {
+		return err
+	}
+
+	if err := o.exec(tx, fmt.Sprintf("DELETE FROM %s", quotedStaging)); err != nil {
+		return err
+	}
+
+	// The staging table name is already prefixed and the objects should all
+	// be loaded to the same table.
+	stagingOptions := *o
+	stagingOptions.tablePrefix = ""
+	stagingOptions.tableSuffix = ""
+	stagingOptions.shardFunc = nil
+	stagingOptions.auditTable = ""
+
+	loadFunc := func(ctx *ExecContext) {
+		stagingScope = ctx.Scope
+		columnNames = ctx.QuotedColumnNames
+
+		plainInsertFunc(ctx)
+	}
+
+	// Trailing query options can't be used when loading the staging table.
+	stagingDB := tx.Table(staging).Set("gorm:query_option", "")
+
+	if err := execStatement(stagingDB, objects, rows, loadFunc, &stagingOptions); err != nil {
+		return err
+	}
+
+	columns := strings.Join(columnNames, ", ")
+	mergeSQL := fmt.Sprintf(
+		"INSERT INTO %s (%s) SELECT %s FROM %s ON DUPLICATE KEY UPDATE %s",
+		quotedTarget, columns, columns, quotedStaging,
+		strings.Join(duplicateKeyUpdates(stagingScope, columnNames), ", "),
+	)
+
+	if dialect == "postgres" {
+		mergeSQL = fmt.Sprintf(
+			"INSERT INTO %s (%s) SELECT %s FROM %s ON CONFLICT (%s) DO UPDATE SET %s",
+			quotedTarget, columns, columns, quotedStaging,
+			strings.Join(quoteColumns(scope, o.syncKeys), ", "),
+			strings.Join(excludedUpdates(stagingScope, columnNames), ", "),
+		)
+	}
+
+	if err := o.exec(tx, mergeSQL); err != nil {
+		return err
+	}
+
+	if !o.deleteMissing {
+		return nil
+	}
+
+	var keyConditions []string
+
+	for _, key := range quoteColumns(scope, o.syncKeys) {
+		keyConditions = append(keyConditions, fmt.Sprintf(
+			"%s.%s = %s.%s",
+			quotedStaging, key, quotedTarget, key,
+		))
+	}
+
+	where := fmt.Sprintf(
+		"NOT EXISTS (SELECT 1 FROM %s WHERE %s)",
+		quotedStaging, strings.Join(keyConditions, " AND "),
+	)
+
+	return o.exec(
+		tx,
+		fmt.Sprintf("DELETE FROM %s WHERE %s", quotedTarget, o.deleteMissingCondition(where)),
+		o.deleteMissingArgs...,
+	)
+}
+
+// deleteMissing deletes all rows in the target table with keys not present in
+// the objects.
+func deleteMissing(db *gorm.DB, objects []interface{}, o *options) error {
+	if len(objects) < 1 {
+		return nil
+	}
+
+	if len(o.syncKeys) == 0 {
+		return ErrMissingSyncKeys
+	}
+
+	scope := db.NewScope(objects[0])
+
+	notIn, vars, err := o.keysCondition(scope, objects, o.syncKeys, "NOT IN")
+	if err != nil {
+		return err
+	}
+
+	return o.exec(
+		db,
+		fmt.Sprintf(
+			"DELETE FROM %s WHERE %s",
+			scope.Quote(o.scopeTableName(scope)),
+			o.deleteMissingCondition(notIn),
+		),
+		append(append([]interface{}{}, o.deleteMissingArgs...), vars...)...,
+	)
+}
+
+// keysCondition returns a condition comparing the key columns to the keys of
+// all the objects with the operator (IN or NOT IN) and the vars to bind, i.e.
+//
+//  (`key1`, `key2`) IN ((?, ?), (?, ?))
+func (o *options) keysCondition(scope *gorm.Scope, objects []interface{}, keys []string, operator string) (string, []interface{}, error) {
+	var (
+		groups []string
+		vars   []interface{}
+	)
+
+	for _, object := range objects {
+		fields, err := o.objectToMap(object)
+		if err != nil {
+			return "", nil, err
+		}
+
+		var placeholders []string
+
+		for _, key := range keys {
+			field, ok := fields[key]
+			if !ok {
+				return "", nil, fmt.Errorf("key %s not found in object", key)
+			}
+
+			vars = append(vars, fieldValue(field))
+			placeholders = append(placeholders, "?")
+		}
+
+		groups = append(groups, fmt.Sprintf("(%s)", strings.Join(placeholders, ", ")))
+	}
+
+	condition := fmt.Sprintf(
+		"(%s) %s (%s)",
+		strings.Join(quoteColumns(scope, keys), ", "),
+		operator,
+		strings.Join(groups, ", "),
+	)
+
+	return condition, vars, nil
+}
+
+// deleteMissingCondition adds the condition set with WithDeleteMissingWhere to
+// the where clause.
+func (o *options) deleteMissingCondition(where string) string {
+	if o.deleteMissingWhere == "" {
+		return where
+	}
+
+	return fmt.Sprintf("(%s) AND %s", o.deleteMissingWhere, where)
+}
+
+// quoteColumns returns all the columns quoted.
+func quoteColumns(scope *gorm.Scope, columns []string) []string {
+	quoted := make([]string, len(columns))
+	for i := range columns {
+		quoted[i] = scope.Quote(columns[i])
+	}
+
+	return quoted
+}
+
+// excludedUpdates returns the assignments for a postgres ON CONFLICT DO UPDATE
+// clause for the columns except created at and excluded columns.
+func excludedUpdates(scope *gorm.Scope, columnNames []string) []string {
+	var (
+		updates  []string
+		excluded = excludedColumns(scope)
+	)
+
+	for _, column := range columnNames {
+		if _, ok := excluded[column]; ok {
+			continue
+		}
+
+		updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", column, column))
+	}
+
+	return updates
+}
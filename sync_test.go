@@ -0,0 +1,241 @@
+package gormbulk
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkSync(t *testing.T) {
+	type user struct {
+		Email string
+		Name  string
+	}
+
+	objects := []interface{}{
+		user{Email: "a@example.com", Name: "a"},
+		user{Email: "b@example.com", Name: "b"},
+	}
+
+	cases := []struct {
+		description      string
+		dialect          string
+		options          []Option
+		expectedMockFunc func(mock sqlmock.Sqlmock)
+		expectedErr      error
+	}{
+		{
+			description: "sync with staging table",
+			dialect:     "mysql",
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("CREATE TEMPORARY TABLE IF NOT EXISTS `users_staging` LIKE `users`")).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `users_staging`")).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users_staging` (`email`, `name`) VALUES (?, ?), (?, ?)")).
+					WithArgs("a@example.com", "a", "b@example.com", "b").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users` (`email`, `name`) SELECT `email`, `name` FROM `users_staging` ON DUPLICATE KEY UPDATE `email` = VALUES(`email`), `name` = VALUES(`name`)")).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			description: "sync with delete missing and custom staging table",
+			dialect:     "mysql",
+			options: []Option{
+				WithSyncKeys("email"),
+				WithDeleteMissing(),
+				WithStagingTable("tmp_users"),
+			},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("CREATE TEMPORARY TABLE IF NOT EXISTS `tmp_users` LIKE `users`")).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `tmp_users`")).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `tmp_users`")).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users`")).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `users` WHERE NOT EXISTS (SELECT 1 FROM `tmp_users` WHERE `tmp_users`.`email` = `users`.`email`)")).
+					WillReturnResult(sqlmock.NewResult(0, 5))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			description: "sync with advisory lock",
+			dialect:     "mysql",
+			options:     []Option{WithAdvisoryLock("users_sync")},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT GET_LOCK(?, -1)")).
+					WithArgs("users_sync").
+					WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("CREATE TEMPORARY TABLE IF NOT EXISTS `users_staging` LIKE `users`")).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `users_staging`")).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users_staging`")).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users`")).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+				mock.ExpectExec(regexp.QuoteMeta("SELECT RELEASE_LOCK(?)")).
+					WithArgs("users_sync").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			description: "sync for postgres",
+			dialect:     "postgres",
+			options:     []Option{WithSyncKeys("email")},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`CREATE TEMPORARY TABLE IF NOT EXISTS "users_staging" (LIKE "users" INCLUDING DEFAULTS)`)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "users_staging"`)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "users_staging" ("email", "name") VALUES ($1, $2), ($3, $4)`)).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "users" ("email", "name") SELECT "email", "name" FROM "users_staging" ON CONFLICT ("email") DO UPDATE SET "email" = EXCLUDED."email", "name" = EXCLUDED."name"`)).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			description:      "delete missing requires keys",
+			dialect:          "mysql",
+			options:          []Option{WithDeleteMissing()},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {},
+			expectedErr:      ErrMissingSyncKeys,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open(tc.dialect, db)
+			require.NoError(t, err)
+
+			tc.expectedMockFunc(mock)
+
+			err = BulkSync(gdb, objects, tc.options...)
+
+			if tc.expectedErr != nil {
+				assert.Equal(t, tc.expectedErr, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSyncDeleteMissing(t *testing.T) {
+	type user struct {
+		Email    string
+		TenantID int
+	}
+
+	objects := []interface{}{
+		user{Email: "a@example.com", TenantID: 1},
+		user{Email: "b@example.com", TenantID: 1},
+	}
+
+	cases := []struct {
+		description      string
+		chunkSize        int
+		options          []Option
+		expectedMockFunc func(mock sqlmock.Sqlmock)
+		expectedErr      bool
+	}{
+		{
+			description: "delete missing after upsert",
+			options:     []Option{SyncDeleteMissing("email")},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users`")).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `users` WHERE (`email`) NOT IN ((?), (?))")).
+					WithArgs("a@example.com", "b@example.com").
+					WillReturnResult(sqlmock.NewResult(0, 3))
+			},
+		},
+		{
+			description: "delete missing scoped by where",
+			options: []Option{
+				SyncDeleteMissing("email", "tenant_id"),
+				WithDeleteMissingWhere("tenant_id = ?", 1),
+			},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users`")).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `users` WHERE (tenant_id = ?) AND (`email`, `tenant_id`) NOT IN ((?, ?), (?, ?))")).
+					WithArgs(1, "a@example.com", 1, "b@example.com", 1).
+					WillReturnResult(sqlmock.NewResult(0, 3))
+			},
+		},
+		{
+			description: "delete missing once after all chunks",
+			chunkSize:   1,
+			options:     []Option{SyncDeleteMissing("email")},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users`")).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users`")).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `users` WHERE (`email`) NOT IN ((?), (?))")).
+					WithArgs("a@example.com", "b@example.com").
+					WillReturnResult(sqlmock.NewResult(0, 3))
+			},
+		},
+		{
+			description: "nothing deleted if upsert fails",
+			options:     []Option{SyncDeleteMissing("email")},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users`")).
+					WillReturnError(assert.AnError)
+			},
+			expectedErr: true,
+		},
+		{
+			description: "unknown key returns error",
+			options:     []Option{SyncDeleteMissing("missing")},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users`")).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			tc.expectedMockFunc(mock)
+
+			if tc.chunkSize > 0 {
+				errs := BulkExecChunk(gdb, objects, InsertOnDuplicateKeyUpdateFunc, tc.chunkSize, tc.options...)
+				assert.Equal(t, tc.expectedErr, len(errs) > 0)
+			} else {
+				err = BulkInsertOnDuplicateKeyUpdate(gdb, objects, tc.options...)
+				assert.Equal(t, tc.expectedErr, err != nil)
+			}
+
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package gormbulk
+
+import (
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// bulkTag is the struct tag used to configure bulk specific behavior, i.e.
+// `bulk:"generated"`. Settings are separated by semicolon and may have a value
+// separated by colon, the same way as the gorm tag.
+const bulkTag = "bulk"
+
+// bulkTagSettings parses the bulk tag for the field. All keys are upper cased.
+// A nil field, such as for columns injected by options, has no settings.
+func bulkTagSettings(field *gorm.StructField) map[string]string {
+	settings := map[string]string{}
+
+	if field == nil {
+		return settings
+	}
+
+	tag, ok := field.Struct.Tag.Lookup(bulkTag)
+	if !ok {
+		return settings
+	}
+
+	for _, setting := range strings.Split(tag, ";") {
+		if strings.TrimSpace(setting) == "" {
+			continue
+		}
+
+		kv := strings.SplitN(setting, ":", 2)
+		key := strings.ToUpper(strings.TrimSpace(kv[0]))
+
+		if len(kv) > 1 {
+			settings[key] = strings.TrimSpace(kv[1])
+		} else {
+			settings[key] = key
+		}
+	}
+
+	return settings
+}
+
+// isGeneratedField returns true if the field is a generated (or virtual)
+// column which the database doesn't accept values for. This is the case for
+// fields tagged with `gorm:"->"` (read only) or `bulk:"generated"`.
+func isGeneratedField(field *gorm.StructField) bool {
+	if _, ok := field.TagSettingsGet("->"); ok {
+		return true
+	}
+
+	_, ok := bulkTagSettings(field)["GENERATED"]
+
+	return ok
+}
+
+// isVersionField returns true if the field is tagged with `bulk:"version"` and
+// should be used for optimistic locking.
+func isVersionField(field *gorm.StructField) bool {
+	_, ok := bulkTagSettings(field)["VERSION"]
+
+	return ok
+}
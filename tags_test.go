@@ -0,0 +1,66 @@
+package gormbulk
+
+import (
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_bulkTagSettings(t *testing.T) {
+	type test struct {
+		None      string
+		Generated string `bulk:"generated"`
+		Multiple  string `bulk:"generated; chunk:500 ;upsert:keep-newest"`
+		ReadOnly  string `gorm:"->"`
+	}
+
+	fields := (&gorm.Scope{Value: &test{}}).Fields()
+
+	cases := []struct {
+		field             string
+		expectedSettings  map[string]string
+		expectedGenerated bool
+	}{
+		{
+			field:            "None",
+			expectedSettings: map[string]string{},
+		},
+		{
+			field:             "Generated",
+			expectedSettings:  map[string]string{"GENERATED": "GENERATED"},
+			expectedGenerated: true,
+		},
+		{
+			field: "Multiple",
+			expectedSettings: map[string]string{
+				"GENERATED": "GENERATED",
+				"CHUNK":     "500",
+				"UPSERT":    "keep-newest",
+			},
+			expectedGenerated: true,
+		},
+		{
+			field:             "ReadOnly",
+			expectedSettings:  map[string]string{},
+			expectedGenerated: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.field, func(t *testing.T) {
+			for _, field := range fields {
+				if field.Name != tc.field {
+					continue
+				}
+
+				assert.Equal(t, tc.expectedSettings, bulkTagSettings(field.StructField))
+				assert.Equal(t, tc.expectedGenerated, isGeneratedField(field.StructField))
+
+				return
+			}
+
+			t.Fatalf("field %s not found", tc.field)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package gormbulk
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jinzhu/gorm"
+)
+
+// WithTxOptions sets the isolation level and read-only flag for the
+// transactions started by the bulk functions, i.e. READ COMMITTED to reduce
+// deadlocks for large upserts. This applies to the transaction for each chunk
+// when using WithAuditTable and to the transactions used by BulkSync and
+// SeedTable. The options are not used if the db passed already is a
+// transaction.
+func WithTxOptions(txOptions sql.TxOptions) Option {
+	return func(o *options) {
+		o.txOptions = &txOptions
+	}
+}
+
+// transaction runs fn in a transaction which is committed if fn returns nil
+// and rolled back otherwise. If db already is a transaction it will be used as
+// is and it's up to the caller to commit or roll back. The transaction is
+// started with the options set with WithTxOptions, if any.
+func (o *options) transaction(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	if _, ok := db.CommonDB().(*sql.Tx); ok {
+		return fn(db)
+	}
+
+	tx, err := o.beginTx(db)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// beginTx starts a transaction with the context set with WithContext. If the
+// connection is pinned the transaction is started on the pinned connection, so
+// the statements in it see the session settings (see WithSetupSQL) and no
+// other connection is needed from the pool. The transaction on the pinned
+// connection keeps the table and the statement settings of the db but not its
+// logger.
+func (o *options) beginTx(db *gorm.DB) (*gorm.DB, error) {
+	ctx := o.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if !o.pinned(db) {
+		tx := db.BeginTx(ctx, o.txOptions)
+		return tx, tx.Error
+	}
+
+	sqlTx, err := o.conn.BeginTx(ctx, o.txOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := gorm.Open(db.Dialect().GetName(), sqlTx)
+	if err != nil {
+		_ = sqlTx.Rollback()
+		return nil, err
+	}
+
+	if table := db.NewScope(nil).TableName(); table != gorm.DefaultTableNameHandler(db, "") {
+		tx = tx.Table(table)
+	}
+
+	for _, setting := range statementSettings {
+		if value, ok := db.Get(setting); ok {
+			tx = tx.Set(setting, value)
+		}
+	}
+
+	return tx, nil
+}
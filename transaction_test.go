@@ -0,0 +1,79 @@
+package gormbulk
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// txOptionsConnector is a driver recording the options for each transaction
+// started. All statements succeed unless the context is done.
+type txOptionsConnector struct {
+	txOptions []driver.TxOptions
+}
+
+func (c *txOptionsConnector) Connect(context.Context) (driver.Conn, error) { return c, nil }
+func (c *txOptionsConnector) Driver() driver.Driver                        { return nil }
+func (c *txOptionsConnector) Prepare(string) (driver.Stmt, error)          { return nil, driver.ErrSkip }
+func (c *txOptionsConnector) Close() error                                 { return nil }
+func (c *txOptionsConnector) Begin() (driver.Tx, error)                    { return c, nil }
+func (c *txOptionsConnector) Commit() error                                { return nil }
+func (c *txOptionsConnector) Rollback() error                              { return nil }
+
+func (c *txOptionsConnector) BeginTx(_ context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.txOptions = append(c.txOptions, opts)
+	return c, nil
+}
+
+func (c *txOptionsConnector) ExecContext(ctx context.Context, _ string, _ []driver.NamedValue) (driver.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return driver.RowsAffected(1), nil
+}
+
+func TestWithTxOptions(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	cases := []struct {
+		description string
+		opts        []Option
+		expected    []driver.TxOptions
+	}{
+		{
+			description: "default options",
+			expected:    []driver.TxOptions{{}},
+		},
+		{
+			description: "isolation level and read only",
+			opts: []Option{
+				WithTxOptions(sql.TxOptions{Isolation: sql.LevelReadCommitted, ReadOnly: true}),
+			},
+			expected: []driver.TxOptions{
+				{Isolation: driver.IsolationLevel(sql.LevelReadCommitted), ReadOnly: true},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			connector := &txOptionsConnector{}
+
+			gdb, err := gorm.Open("mysql", sql.OpenDB(connector))
+			require.NoError(t, err)
+
+			opts := append([]Option{WithAuditTable("users_history")}, tc.opts...)
+
+			require.NoError(t, BulkInsert(gdb, []interface{}{user{Name: "a"}}, opts...))
+			assert.Equal(t, tc.expected, connector.txOptions)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package gormbulk
+
+import "fmt"
+
+// RowTransformerFunc transforms a single object before any fields are
+// extracted, i.e. to normalize, enrich or redact the object. The returned
+// object is used instead of the passed one.
+type RowTransformerFunc func(object interface{}) (interface{}, error)
+
+// WithRowTransformer will run the passed RowTransformerFunc for every object
+// before validating it and building the SQL. Multiple transformers may be
+// added and will run in the order they were added. An error is handled the
+// same way as a validation error and a nil object returned is handled
+// according to the NilPolicy.
+func WithRowTransformer(fn RowTransformerFunc) Option {
+	return func(o *options) {
+		o.rowTransformers = append(o.rowTransformers, fn)
+	}
+}
+
+// transformRow runs all row transformers for the object.
+func (o *options) transformRow(object interface{}) (interface{}, error) {
+	for _, fn := range o.rowTransformers {
+		var err error
+
+		if object, err = fn(object); err != nil {
+			return nil, err
+		}
+	}
+
+	return object, nil
+}
+
+// ColumnTransformerFunc transforms the value bound for a column, i.e. to hash
+// or encrypt sensitive data.
+type ColumnTransformerFunc func(value interface{}) (interface{}, error)
+
+// WithColumnTransformer will run the passed ColumnTransformerFunc for every
+// value bound for the column. The value passed is the value after all other
+// conversions, i.e. serialized or marshalled as JSON. The transformer isn't
+// called for NULL values or columns set with WithColumnValue. Multiple
+// transformers for the same column will run in the order they were added.
+func WithColumnTransformer(column string, fn ColumnTransformerFunc) Option {
+	return func(o *options) {
+		if o.columnTransformers == nil {
+			o.columnTransformers = map[string][]ColumnTransformerFunc{}
+		}
+
+		o.columnTransformers[column] = append(o.columnTransformers[column], fn)
+	}
+}
+
+// transformColumn runs all column transformers for the column on the value.
+func (o *options) transformColumn(column string, value interface{}) (interface{}, error) {
+	if isNull(value) {
+		return value, nil
+	}
+
+	for _, fn := range o.columnTransformers[column] {
+		var err error
+
+		if value, err = fn(value); err != nil {
+			return nil, fmt.Errorf("could not transform column %s: %v", column, err)
+		}
+	}
+
+	return value, nil
+}
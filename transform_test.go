@@ -0,0 +1,162 @@
+package gormbulk
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRowTransformer(t *testing.T) {
+	type user struct {
+		TenantID int
+		Email    string
+	}
+
+	var (
+		withTenant = func(object interface{}) (interface{}, error) {
+			u := object.(user)
+			u.TenantID = 42
+
+			return u, nil
+		}
+
+		lowerEmail = func(object interface{}) (interface{}, error) {
+			u := object.(user)
+			if u.Email == "" {
+				return nil, errors.New("email must be set")
+			}
+
+			u.Email = strings.ToLower(u.Email)
+
+			return u, nil
+		}
+
+		dropEmpty = func(object interface{}) (interface{}, error) {
+			if object.(user).Email == "" {
+				return nil, nil
+			}
+
+			return object, nil
+		}
+	)
+
+	cases := []struct {
+		description      string
+		slice            []interface{}
+		options          []Option
+		expectedMockFunc func(mock sqlmock.Sqlmock)
+		expectedErr      error
+	}{
+		{
+			description: "transformers run in order",
+			slice:       []interface{}{user{Email: "A@EXAMPLE.COM"}, user{Email: "b@example.com"}},
+			options:     []Option{WithRowTransformer(withTenant), WithRowTransformer(lowerEmail)},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `users`").
+					WithArgs("a@example.com", 42, "b@example.com", 42).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+		},
+		{
+			description:      "errors handled as validation errors",
+			slice:            []interface{}{user{Email: "a@example.com"}, user{}},
+			options:          []Option{WithRowTransformer(lowerEmail)},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {},
+			expectedErr: ValidationErrors{
+				{Row: 1, Err: errors.New("email must be set")},
+			},
+		},
+		{
+			description: "nil objects handled by nil policy",
+			slice:       []interface{}{user{Email: "a@example.com"}, user{}},
+			options:     []Option{WithRowTransformer(dropEmpty), WithNilPolicy(SkipNil)},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `users`").
+					WithArgs("a@example.com", 0).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			tc.expectedMockFunc(mock)
+
+			err = BulkInsert(gdb, tc.slice, tc.options...)
+			assert.Equal(t, tc.expectedErr, err)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWithColumnTransformer(t *testing.T) {
+	type user struct {
+		Email string
+		Name  *string
+	}
+
+	var (
+		hash = func(value interface{}) (interface{}, error) {
+			return fmt.Sprintf("hash(%s)", value), nil
+		}
+
+		fail = func(_ interface{}) (interface{}, error) {
+			return nil, errors.New("no key")
+		}
+	)
+
+	cases := []struct {
+		description      string
+		options          []Option
+		expectedMockFunc func(mock sqlmock.Sqlmock)
+		expectedErr      error
+	}{
+		{
+			description: "transformers run for the column",
+			options: []Option{
+				WithColumnTransformer("email", hash),
+				WithColumnTransformer("email", hash),
+				WithColumnTransformer("name", fail),
+			},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `users`").
+					WithArgs("hash(hash(a@example.com))", nil).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			description:      "errors returned with column",
+			options:          []Option{WithColumnTransformer("email", fail)},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {},
+			expectedErr:      errors.New("could not transform column email: no key"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			tc.expectedMockFunc(mock)
+
+			err = BulkInsert(gdb, []interface{}{user{Email: "a@example.com"}}, tc.options...)
+			assert.Equal(t, tc.expectedErr, err)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package gormbulk
+
+import (
+	"errors"
+	"reflect"
+)
+
+// ValidatorFunc validates a single object before any SQL is built. The index
+// is the position of the object in the slice passed to the bulk function.
+type ValidatorFunc func(i int, object interface{}) error
+
+// InvalidPolicy decides what to do with objects failing validation.
+type InvalidPolicy int
+
+// Available policies for invalid objects.
+const (
+	// AbortOnInvalid will return a ValidationErrors holding all errors without
+	// executing any SQL. This is the default policy.
+	AbortOnInvalid InvalidPolicy = iota
+
+	// SkipInvalid will leave out all invalid objects and execute the SQL with
+	// the remaining objects. Objects which aren't structs are also considered
+	// invalid. Use WithResult to get a report of the skipped objects.
+	SkipInvalid
+)
+
+// ErrNilObject is the error for nil objects, or nil pointers, in the slice
+// passed to the bulk function.
+var ErrNilObject = errors.New("object is nil")
+
+// NilPolicy decides what to do with nil objects.
+type NilPolicy int
+
+// Available policies for nil objects.
+const (
+	// NilAsInvalid will treat nil objects as invalid objects with the error
+	// ErrNilObject, handled according to the InvalidPolicy. This is the
+	// default policy.
+	NilAsInvalid NilPolicy = iota
+
+	// FailOnNil will return a *RowError with ErrNilObject for the first nil
+	// object without executing any SQL, regardless of the InvalidPolicy.
+	FailOnNil
+
+	// SkipNil will leave out all nil objects. Use WithResult to get the
+	// number of skipped nil objects.
+	SkipNil
+)
+
+// WithValidator will run the passed ValidatorFunc for every object before
+// building the SQL. Multiple validators may be added and will run in the order
+// they were added.
+func WithValidator(fn ValidatorFunc) Option {
+	return func(o *options) {
+		o.validators = append(o.validators, fn)
+	}
+}
+
+// WithInvalidPolicy sets the policy for objects failing validation.
+func WithInvalidPolicy(policy InvalidPolicy) Option {
+	return func(o *options) {
+		o.invalidPolicy = policy
+	}
+}
+
+// WithNilPolicy sets the policy for nil objects.
+func WithNilPolicy(policy NilPolicy) Option {
+	return func(o *options) {
+		o.nilPolicy = policy
+	}
+}
+
+// validate will run all row transformers and validators for each object,
+// handle nil objects according to the nil policy and leave out objects older
+// than the watermark. Depending on the invalid policy an error is returned or
+// the invalid objects are left out from the returned slice. The index of each
+// returned object in the passed slice (plus the row offset when streaming) is
+// also returned.
+func (o *options) validate(objects []interface{}) ([]interface{}, []int, error) {
+	var (
+		validationErrors ValidationErrors
+		validObjects     = make([]interface{}, 0, len(objects))
+		rows             = make([]int, 0, len(objects))
+		skippedNil       int
+		skippedStale     int
+	)
+
+	for i, object := range objects {
+		row := o.rowOffset + i
+
+		if !isNil(object) {
+			transformed, err := o.transformRow(object)
+			if err != nil {
+				validationErrors = append(validationErrors, &RowError{Row: row, Err: err})
+				continue
+			}
+
+			object = transformed
+		}
+
+		if isNil(object) {
+			switch o.nilPolicy {
+			case FailOnNil:
+				return nil, nil, &RowError{Row: row, Err: ErrNilObject}
+			case SkipNil:
+				skippedNil++
+			default:
+				validationErrors = append(validationErrors, &RowError{Row: row, Err: ErrNilObject})
+			}
+
+			continue
+		}
+
+		if err := o.validateObject(row, object); err != nil {
+			validationErrors = append(validationErrors, &RowError{Row: row, Err: err})
+			continue
+		}
+
+		if o.isStale(object) {
+			skippedStale++
+			continue
+		}
+
+		validObjects = append(validObjects, object)
+		rows = append(rows, row)
+	}
+
+	if len(validationErrors) > 0 && o.invalidPolicy == AbortOnInvalid {
+		return nil, nil, validationErrors
+	}
+
+	if o.result != nil {
+		o.result.Skipped = append(o.result.Skipped, validationErrors...)
+		o.result.SkippedNil += skippedNil
+		o.result.SkippedStale += skippedStale
+		o.result.BatchID = o.batchID
+	}
+
+	return validObjects, rows, nil
+}
+
+func (o *options) validateObject(i int, object interface{}) error {
+	if o.invalidPolicy == SkipInvalid && !isStruct(object) {
+		return errors.New("value must be kind of Struct")
+	}
+
+	for _, fn := range o.validators {
+		if err := fn(i, object); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isNil returns true if the object is nil or a nil pointer.
+func isNil(object interface{}) bool {
+	if object == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(object)
+
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// isStruct returns true if the object is a struct or a non nil pointer to a
+// struct.
+func isStruct(object interface{}) bool {
+	rv := reflect.ValueOf(object)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+
+	return rv.Kind() == reflect.Struct
+}
@@ -0,0 +1,153 @@
+package gormbulk
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidator(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	type test struct {
+		Foo string
+	}
+
+	notEmpty := func(_ int, obj interface{}) error {
+		if obj.(test).Foo == "" {
+			return errors.New("foo must be set")
+		}
+
+		return nil
+	}
+
+	cases := []struct {
+		description        string
+		slice              []interface{}
+		options            []Option
+		expectedMockFunc   func(mock sqlmock.Sqlmock)
+		expectedErrors     ValidationErrors
+		expectedErr        error
+		expectedSkipped    []*RowError
+		expectedSkippedNil int
+	}{
+		{
+			description: "all objects valid",
+			slice:       []interface{}{test{"a"}, test{"b"}},
+			options:     []Option{WithValidator(notEmpty)},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `tests`").
+					WithArgs("a", "b").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+		},
+		{
+			description:      "all errors returned by default",
+			slice:            []interface{}{test{""}, test{"b"}, test{""}},
+			options:          []Option{WithValidator(notEmpty)},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {},
+			expectedErrors: ValidationErrors{
+				{Row: 0, Err: errors.New("foo must be set")},
+				{Row: 2, Err: errors.New("foo must be set")},
+			},
+		},
+		{
+			description: "invalid objects skipped",
+			slice:       []interface{}{test{""}, test{"b"}, test{"c"}},
+			options: []Option{
+				WithValidator(notEmpty),
+				WithInvalidPolicy(SkipInvalid),
+			},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `tests`").
+					WithArgs("b", "c").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+			expectedSkipped: []*RowError{
+				{Row: 0, Err: errors.New("foo must be set")},
+			},
+		},
+		{
+			description: "non structs skipped with skip policy",
+			slice:       []interface{}{"string", test{"b"}, 1, nil},
+			options:     []Option{WithInvalidPolicy(SkipInvalid)},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `tests`").
+					WithArgs("b").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedSkipped: []*RowError{
+				{Row: 0, Err: errors.New("value must be kind of Struct")},
+				{Row: 2, Err: errors.New("value must be kind of Struct")},
+				{Row: 3, Err: ErrNilObject},
+			},
+		},
+		{
+			description:      "nil objects invalid by default",
+			slice:            []interface{}{test{"a"}, nil, (*test)(nil)},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {},
+			expectedErrors: ValidationErrors{
+				{Row: 1, Err: ErrNilObject},
+				{Row: 2, Err: ErrNilObject},
+			},
+		},
+		{
+			description: "nil objects skipped and counted",
+			slice:       []interface{}{test{"a"}, nil, (*test)(nil)},
+			options:     []Option{WithNilPolicy(SkipNil)},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `tests`").
+					WithArgs("a").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedSkippedNil: 2,
+		},
+		{
+			description: "nil objects fail with skip policy",
+			slice:       []interface{}{test{"a"}, nil},
+			options: []Option{
+				WithNilPolicy(FailOnNil),
+				WithInvalidPolicy(SkipInvalid),
+			},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {},
+			expectedErr:      &RowError{Row: 1, Err: ErrNilObject},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			tc.expectedMockFunc(mock)
+
+			result := &Result{}
+			tc.options = append(tc.options, WithResult(result))
+
+			err := BulkInsert(gdb, tc.slice, tc.options...)
+
+			if tc.expectedErrors != nil {
+				require.Error(t, err)
+				assert.Equal(t, tc.expectedErrors, err)
+
+				return
+			}
+
+			if tc.expectedErr != nil {
+				assert.Equal(t, tc.expectedErr, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, mock.ExpectationsWereMet())
+
+			assert.Equal(t, tc.expectedSkipped, result.Skipped)
+			assert.Equal(t, tc.expectedSkippedNil, result.SkippedNil)
+		})
+	}
+}
@@ -0,0 +1,500 @@
+package gormbulk
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+)
+
+// NilPointerPolicy decides how nil pointer fields are written.
+type NilPointerPolicy int
+
+// Available policies for nil pointer fields.
+const (
+	// NilPointerAsNull will bind nil pointer fields as NULL. This is the
+	// default policy.
+	NilPointerAsNull NilPointerPolicy = iota
+
+	// NilPointerAsDefault will bind nil pointer fields as DEFAULT to let the
+	// database use the column default. Columns where the field is a nil
+	// pointer for all objects are left out from the statement. DEFAULT is not
+	// supported as a value by SQLite.
+	NilPointerAsDefault
+)
+
+// WithNilPointerPolicy sets the policy for nil pointer fields. Blank CreatedAt
+// and UpdatedAt fields are always set to the current time.
+func WithNilPointerPolicy(policy NilPointerPolicy) Option {
+	return func(o *options) {
+		o.nilPointerPolicy = policy
+	}
+}
+
+// bindDefault returns true if DEFAULT should be bound for the column since
+// the field is a nil pointer and the policy is NilPointerAsDefault.
+func (o *options) bindDefault(column string, field *gorm.Field) bool {
+	if o.nilPointerPolicy != NilPointerAsDefault {
+		return false
+	}
+
+	if _, ok := o.columnValues[column]; ok || column == o.checksumColumn || column == o.expiryColumn {
+		return false
+	}
+
+	return isNilPointer(field)
+}
+
+// isNilPointer returns true if the field is a nil pointer which should be
+// written according to the NilPointerPolicy.
+func isNilPointer(field *gorm.Field) bool {
+	if field == nil || !field.Field.IsValid() {
+		return false
+	}
+
+	switch field.Struct.Name {
+	case "CreatedAt", "UpdatedAt":
+		return false
+	}
+
+	return field.Field.Kind() == reflect.Ptr && field.Field.IsNil()
+}
+
+// omitNilPointerColumns returns the column names without the columns where
+// the field is a nil pointer for all the objects, if using NilPointerAsDefault.
+func (o *options) omitNilPointerColumns(columnNames []string, objects []interface{}) []string {
+	if o.nilPointerPolicy != NilPointerAsDefault {
+		return columnNames
+	}
+
+	nilCount := map[string]int{}
+
+	for _, object := range objects {
+		fields, err := o.objectToMap(object)
+		if err != nil {
+			return columnNames
+		}
+
+		for _, column := range columnNames {
+			if o.bindDefault(column, fields[column]) {
+				nilCount[column]++
+			}
+		}
+	}
+
+	var columns []string
+
+	for _, column := range columnNames {
+		if nilCount[column] == len(objects) {
+			continue
+		}
+
+		columns = append(columns, column)
+	}
+
+	return columns
+}
+
+// derefValue returns the value pointed to for non nil pointers. Pointers
+// implementing driver.Valuer or json.Marshaler are kept as is since the
+// implementation might have a pointer receiver.
+func derefValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *time.Time:
+		if v != nil {
+			return *v
+		}
+
+		return value
+	case nil, driver.Valuer, json.Marshaler:
+		return value
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return value
+	}
+
+	return rv.Elem().Interface()
+}
+
+// fieldValuer returns the field as a driver.Valuer if the field type or a
+// pointer to the field type implements the interface. Nil pointers are never
+// returned since calling Value() on them might panic.
+func fieldValuer(field *gorm.Field) (driver.Valuer, bool) {
+	fv := field.Field
+	if !fv.IsValid() {
+		return nil, false
+	}
+
+	if fv.Kind() == reflect.Ptr && fv.IsNil() {
+		return nil, false
+	}
+
+	if valuer, ok := fv.Interface().(driver.Valuer); ok {
+		return valuer, true
+	}
+
+	if fv.CanAddr() {
+		if valuer, ok := fv.Addr().Interface().(driver.Valuer); ok {
+			return valuer, true
+		}
+	}
+
+	return nil, false
+}
+
+// valuerIsBlank will consider a driver.Valuer blank if it returns a nil
+// value. Errors are not treated as blank, they will be returned by the driver
+// when the statement is executed.
+func valuerIsBlank(valuer driver.Valuer) bool {
+	value, err := valuer.Value()
+
+	return err == nil && value == nil
+}
+
+// fieldValue returns the value that should be bound for the field. Types
+// implementing driver.Valuer will be passed as is and nil pointers will be
+// bound as NULL.
+func fieldValue(field *gorm.Field) interface{} {
+	if valuer, ok := fieldValuer(field); ok {
+		return valuer
+	}
+
+	if field.Field.Kind() == reflect.Ptr && field.Field.IsNil() {
+		return nil
+	}
+
+	return field.Field.Interface()
+}
+
+// isNull returns true if the value will be bound as NULL. This is true for nil
+// and for any driver.Valuer returning nil, such as sql.NullString with Valid
+// set to false.
+func isNull(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+
+	if valuer, ok := value.(driver.Valuer); ok {
+		return valuerIsBlank(valuer)
+	}
+
+	return false
+}
+
+// columnValue returns the value to bind for the column in the row. The field
+// is nil for columns injected by the options. The row is the index of the
+// object in the slice passed to the bulk function and now is the time used for
+// blank CreatedAt and UpdatedAt fields.
+func (o *options) columnValue(column string, field *gorm.Field, row int, object interface{}, now time.Time) (interface{}, error) {
+	if fn, ok := o.columnValues[column]; ok {
+		return fn(row, object), nil
+	}
+
+	if column == o.expiryColumn && (field == nil || field.IsBlank) {
+		return o.expiry(now), nil
+	}
+
+	value := fieldValue(field)
+
+	switch field.Struct.Name {
+	// Column CreatedAt and UpdatedAt with zero value will be set to same time
+	case "CreatedAt", "UpdatedAt":
+		if field.IsBlank {
+			value = now
+		}
+	}
+
+	value, err := o.convertValue(field, value)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err = o.transformColumn(column, value)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err = o.coerce(row, field, value)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err = o.compress(column, field, value)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err = o.encrypt(column, field, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.checkSize(row, field, value)
+}
+
+// convertValue applies all value conversions configured in the options to the
+// value that will be bound for the field.
+func (o *options) convertValue(field *gorm.Field, value interface{}) (interface{}, error) {
+	if o.location != nil {
+		switch v := value.(type) {
+		case time.Time:
+			value = v.In(o.location)
+		case *time.Time:
+			value = v.In(o.location)
+		}
+	}
+
+	serialized, ok, err := o.serialize(value)
+	if err != nil {
+		return nil, fmt.Errorf("could not serialize column %s: %v", field.DBName, err)
+	}
+
+	if !ok {
+		// Bind the value pointed to, unless serialized, so pointer fields
+		// are handled the same way as the type they point to.
+		value = derefValue(value)
+
+		if t, isTime := value.(time.Time); isTime && isDateField(field) {
+			value = t.Format(o.dateLayout())
+		}
+	}
+
+	switch {
+	case ok:
+		value = serialized
+	case isJSONField(field, value):
+		jsonValue, err := marshalJSON(value)
+		if err != nil {
+			return nil, fmt.Errorf("could not marshal column %s to JSON: %v", field.DBName, err)
+		}
+
+		value = jsonValue
+	case o.isArrayField(field, value):
+		value = pq.Array(value)
+	}
+
+	if o.emptyStringAsNull && isEmptyString(value) {
+		if o.emptyStringAsNullColumns == nil {
+			value = nil
+		}
+
+		if _, ok := o.emptyStringAsNullColumns[o.columnName(field.StructField)]; ok {
+			value = nil
+		}
+	}
+
+	return value, nil
+}
+
+// DefaultDateLayout is the layout used to format time values for columns with
+// the date type.
+const DefaultDateLayout = "2006-01-02"
+
+// WithDateLayout sets the layout used to format time values for columns
+// tagged with `gorm:"type:date"`. If any dialects are passed the layout is
+// only used for those dialects. The default layout is DefaultDateLayout.
+func WithDateLayout(layout string, dialects ...string) Option {
+	return func(o *options) {
+		if o.dateLayouts == nil {
+			o.dateLayouts = map[string]string{}
+		}
+
+		if len(dialects) == 0 {
+			o.dateLayouts[""] = layout
+		}
+
+		for _, dialect := range dialects {
+			o.dateLayouts[dialect] = layout
+		}
+	}
+}
+
+// dateLayout returns the layout for date columns for the current dialect.
+func (o *options) dateLayout() string {
+	if layout, ok := o.dateLayouts[o.dialect]; ok {
+		return layout
+	}
+
+	if layout, ok := o.dateLayouts[""]; ok {
+		return layout
+	}
+
+	return DefaultDateLayout
+}
+
+// isDateField returns true if the field is tagged with the date type.
+func isDateField(field *gorm.Field) bool {
+	columnType, ok := field.TagSettingsGet("TYPE")
+
+	return ok && strings.EqualFold(strings.TrimSpace(columnType), "date")
+}
+
+// isEmptyString returns true if the value is a string, or a pointer to a
+// string, with zero length.
+func isEmptyString(value interface{}) bool {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	return rv.Kind() == reflect.String && rv.Len() == 0
+}
+
+// isJSONField returns true if the value should be bound as JSON. This is true
+// if the field is tagged with a JSON type or if the value implements
+// json.Marshaler. Values implementing driver.Valuer and time.Time is never
+// considered JSON since they're handled by the driver.
+func isJSONField(field *gorm.Field, value interface{}) bool {
+	if value == nil {
+		return false
+	}
+
+	switch value.(type) {
+	case driver.Valuer, time.Time, *time.Time:
+		return false
+	case json.Marshaler:
+		return true
+	}
+
+	columnType, ok := field.TagSettingsGet("TYPE")
+	if !ok {
+		return false
+	}
+
+	switch strings.ToLower(strings.TrimSpace(columnType)) {
+	case "json", "jsonb":
+		return true
+	}
+
+	return false
+}
+
+// marshalJSON returns the JSON representation of the value as a string. Nil
+// maps, slices and pointers will be bound as NULL.
+func marshalJSON(value interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(value)
+
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+// isArrayField returns true if the value is a slice (other than []byte) that
+// should be bound as an array. This is true if the field is tagged with an
+// array type such as `gorm:"type:text[]"` or if the dialect is postgres.
+func (o *options) isArrayField(field *gorm.Field, value interface{}) bool {
+	if value == nil {
+		return false
+	}
+
+	if _, ok := value.(driver.Valuer); ok {
+		return false
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return false
+	}
+
+	if rv.Type().Elem().Kind() == reflect.Uint8 {
+		return false
+	}
+
+	if columnType, ok := field.TagSettingsGet("TYPE"); ok {
+		if strings.HasSuffix(strings.TrimSpace(columnType), "[]") {
+			return true
+		}
+	}
+
+	return o.dialect == "postgres"
+}
+
+// columnSizeRe matches the size of string column types such as varchar(10).
+var columnSizeRe = regexp.MustCompile(`(?i)^\s*n?(?:var)?char\s*\(\s*(\d+)\s*\)`)
+
+// columnSize returns the max number of characters allowed for the field as
+// configured with the SIZE or TYPE tag.
+func columnSize(field *gorm.Field) (int, bool) {
+	if size, ok := field.TagSettingsGet("SIZE"); ok {
+		if n, err := strconv.Atoi(strings.TrimSpace(size)); err == nil {
+			return n, true
+		}
+	}
+
+	if columnType, ok := field.TagSettingsGet("TYPE"); ok {
+		if match := columnSizeRe.FindStringSubmatch(columnType); match != nil {
+			if n, err := strconv.Atoi(match[1]); err == nil {
+				return n, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// checkSize will validate or truncate string values exceeding the column size
+// according to the options. The row is the index of the object the value
+// belongs to.
+func (o *options) checkSize(row int, field *gorm.Field, value interface{}) (interface{}, error) {
+	if !o.validateSize && !o.truncateStrings {
+		return value, nil
+	}
+
+	var str string
+
+	switch v := value.(type) {
+	case string:
+		str = v
+	case *string:
+		str = *v
+	default:
+		return value, nil
+	}
+
+	size, ok := columnSize(field)
+	if !ok {
+		return value, nil
+	}
+
+	length := utf8.RuneCountInString(str)
+	if length <= size {
+		return value, nil
+	}
+
+	sizeErr := &ColumnSizeError{
+		Row:    row,
+		Column: o.columnName(field.StructField),
+		Size:   size,
+		Length: length,
+	}
+
+	if !o.truncateStrings {
+		return nil, sizeErr
+	}
+
+	if o.onTruncate != nil {
+		o.onTruncate(sizeErr)
+	}
+
+	return string([]rune(str)[:size]), nil
+}
@@ -0,0 +1,603 @@
+package gormbulk
+
+import (
+	"database/sql/driver"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type valueValuer struct {
+	v string
+}
+
+func (v valueValuer) Value() (driver.Value, error) {
+	if v.v == "" {
+		return nil, nil
+	}
+
+	return v.v, nil
+}
+
+type ptrValuer struct {
+	v string
+}
+
+func (v *ptrValuer) Value() (driver.Value, error) {
+	if v.v == "" {
+		return nil, nil
+	}
+
+	return v.v, nil
+}
+
+func Test_fieldValue(t *testing.T) {
+	type test struct {
+		ValueValuer    valueValuer
+		PtrValuer      ptrValuer
+		NilValuer      *valueValuer
+		ValuerWithZero valueValuer `gorm:"default:'x'"`
+	}
+
+	cases := []struct {
+		description   string
+		object        interface{}
+		column        string
+		expectedValue interface{}
+		expectedBlank bool
+	}{
+		{
+			description:   "value receiver is passed as valuer",
+			object:        test{ValueValuer: valueValuer{"foo"}},
+			column:        "value_valuer",
+			expectedValue: "foo",
+		},
+		{
+			description:   "pointer receiver is passed as valuer",
+			object:        test{PtrValuer: ptrValuer{"foo"}},
+			column:        "ptr_valuer",
+			expectedValue: "foo",
+		},
+		{
+			description:   "pointer receiver from pointer object is passed as valuer",
+			object:        &test{PtrValuer: ptrValuer{"foo"}},
+			column:        "ptr_valuer",
+			expectedValue: "foo",
+		},
+		{
+			description:   "valuer returning nil is blank",
+			object:        test{},
+			column:        "value_valuer",
+			expectedValue: nil,
+			expectedBlank: true,
+		},
+		{
+			description:   "nil pointer is bound as NULL",
+			object:        test{},
+			column:        "nil_valuer",
+			expectedValue: nil,
+			expectedBlank: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			fields, err := ObjectToMap(tc.object)
+			require.NoError(t, err)
+
+			field, ok := fields[tc.column]
+			require.True(t, ok)
+
+			assert.Equal(t, tc.expectedBlank, field.IsBlank)
+
+			value := fieldValue(field)
+			if valuer, ok := value.(driver.Valuer); ok {
+				value, err = valuer.Value()
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tc.expectedValue, value)
+		})
+	}
+
+	t.Run("blank valuer with default is skipped", func(t *testing.T) {
+		fields, err := ObjectToMap(test{ValueValuer: valueValuer{"foo"}})
+		require.NoError(t, err)
+
+		_, ok := fields["valuer_with_zero"]
+		assert.False(t, ok)
+	})
+}
+
+func Test_convertValue(t *testing.T) {
+	var (
+		empty = ""
+		foo   = "foo"
+	)
+
+	type test struct {
+		Foo    string
+		Bar    string
+		PtrFoo *string
+	}
+
+	cases := []struct {
+		description   string
+		object        interface{}
+		column        string
+		options       []Option
+		expectedValue interface{}
+	}{
+		{
+			description:   "empty string kept without options",
+			object:        test{},
+			column:        "foo",
+			expectedValue: "",
+		},
+		{
+			description:   "empty string as NULL for all columns",
+			object:        test{},
+			column:        "foo",
+			options:       []Option{WithEmptyStringAsNull()},
+			expectedValue: nil,
+		},
+		{
+			description:   "empty string pointer as NULL",
+			object:        test{PtrFoo: &empty},
+			column:        "ptr_foo",
+			options:       []Option{WithEmptyStringAsNull()},
+			expectedValue: nil,
+		},
+		{
+			description:   "empty string as NULL for selected column",
+			object:        test{},
+			column:        "foo",
+			options:       []Option{WithEmptyStringAsNull("foo")},
+			expectedValue: nil,
+		},
+		{
+			description:   "empty string kept for column not selected",
+			object:        test{},
+			column:        "bar",
+			options:       []Option{WithEmptyStringAsNull("foo")},
+			expectedValue: "",
+		},
+		{
+			description:   "non empty string kept",
+			object:        test{Foo: "foo"},
+			column:        "foo",
+			options:       []Option{WithEmptyStringAsNull()},
+			expectedValue: "foo",
+		},
+		{
+			description:   "non nil pointer dereferenced",
+			object:        test{PtrFoo: &foo},
+			column:        "ptr_foo",
+			expectedValue: "foo",
+		},
+		{
+			description:   "nil pointer kept as NULL",
+			object:        test{},
+			column:        "ptr_foo",
+			expectedValue: nil,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			fields, err := ObjectToMap(tc.object)
+			require.NoError(t, err)
+
+			field, ok := fields[tc.column]
+			require.True(t, ok)
+
+			value, err := newOptions(tc.options...).convertValue(field, fieldValue(field))
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedValue, value)
+		})
+	}
+}
+
+type jsonMarshaler struct {
+	Foo string
+}
+
+func (j jsonMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(`{"custom":"` + j.Foo + `"}`), nil
+}
+
+func Test_convertValueJSON(t *testing.T) {
+	type inner struct {
+		A string `json:"a"`
+		B int    `json:"b"`
+	}
+
+	type test struct {
+		Struct    inner          `gorm:"type:json"`
+		Map       map[string]int `gorm:"type:JSON"`
+		Slice     []string       `gorm:"type:jsonb"`
+		NilMap    map[string]int `gorm:"type:json"`
+		Ptr       *inner         `gorm:"type:json"`
+		Marshaler jsonMarshaler
+		Time      time.Time
+	}
+
+	pastDate := time.Date(1985, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	object := test{
+		Struct:    inner{A: "a", B: 1},
+		Map:       map[string]int{"x": 1},
+		Slice:     []string{"a", "b"},
+		Marshaler: jsonMarshaler{Foo: "foo"},
+		Time:      pastDate,
+	}
+
+	cases := []struct {
+		column        string
+		expectedValue interface{}
+	}{
+		{column: "struct", expectedValue: `{"a":"a","b":1}`},
+		{column: "map", expectedValue: `{"x":1}`},
+		{column: "slice", expectedValue: `["a","b"]`},
+		{column: "nil_map", expectedValue: nil},
+		{column: "ptr", expectedValue: nil},
+		{column: "marshaler", expectedValue: `{"custom":"foo"}`},
+		{column: "time", expectedValue: pastDate},
+	}
+
+	fields, err := ObjectToMap(object)
+	require.NoError(t, err)
+
+	for _, tc := range cases {
+		t.Run(tc.column, func(t *testing.T) {
+			field, ok := fields[tc.column]
+			require.True(t, ok)
+
+			value, err := newOptions().convertValue(field, fieldValue(field))
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedValue, value)
+		})
+	}
+}
+
+func Test_convertValueArray(t *testing.T) {
+	type test struct {
+		Tags   []string
+		Ints   []int64 `gorm:"type:integer[]"`
+		Bytes  []byte
+		Valuer pq.StringArray
+	}
+
+	object := test{
+		Tags:   []string{"a", "b"},
+		Ints:   []int64{1, 2},
+		Bytes:  []byte("foo"),
+		Valuer: pq.StringArray{"c"},
+	}
+
+	cases := []struct {
+		description   string
+		dialect       string
+		column        string
+		expectedValue interface{}
+	}{
+		{
+			description:   "slice bound as array for postgres",
+			dialect:       "postgres",
+			column:        "tags",
+			expectedValue: "{\"a\",\"b\"}",
+		},
+		{
+			description:   "slice tagged as array bound as array for any dialect",
+			dialect:       "mysql",
+			column:        "ints",
+			expectedValue: "{1,2}",
+		},
+		{
+			description:   "byte slices are not arrays",
+			dialect:       "postgres",
+			column:        "bytes",
+			expectedValue: []byte("foo"),
+		},
+		{
+			description:   "valuers are not wrapped",
+			dialect:       "postgres",
+			column:        "valuer",
+			expectedValue: "{\"c\"}",
+		},
+	}
+
+	fields, err := ObjectToMap(object)
+	require.NoError(t, err)
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			field, ok := fields[tc.column]
+			require.True(t, ok)
+
+			o := newOptions()
+			o.dialect = tc.dialect
+
+			value, err := o.convertValue(field, fieldValue(field))
+			require.NoError(t, err)
+
+			if valuer, ok := value.(driver.Valuer); ok {
+				value, err = valuer.Value()
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tc.expectedValue, value)
+		})
+	}
+}
+
+func Test_convertValueLocation(t *testing.T) {
+	stockholm := time.FixedZone("Europe/Stockholm", 3600)
+	localDate := time.Date(1985, 1, 1, 1, 0, 0, 0, stockholm)
+
+	type test struct {
+		Time    time.Time
+		PtrTime *time.Time
+	}
+
+	cases := []struct {
+		description      string
+		column           string
+		options          []Option
+		expectedLocation *time.Location
+	}{
+		{
+			description:      "location kept without option",
+			column:           "time",
+			expectedLocation: stockholm,
+		},
+		{
+			description:      "time converted to UTC",
+			column:           "time",
+			options:          []Option{WithUTC()},
+			expectedLocation: time.UTC,
+		},
+		{
+			description:      "time pointer converted to UTC",
+			column:           "ptr_time",
+			options:          []Option{WithUTC()},
+			expectedLocation: time.UTC,
+		},
+	}
+
+	fields, err := ObjectToMap(test{Time: localDate, PtrTime: &localDate})
+	require.NoError(t, err)
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			field, ok := fields[tc.column]
+			require.True(t, ok)
+
+			value, err := newOptions(tc.options...).convertValue(field, fieldValue(field))
+			require.NoError(t, err)
+
+			timeValue, ok := value.(time.Time)
+			if !ok {
+				timeValue = *value.(*time.Time)
+			}
+
+			assert.True(t, localDate.Equal(timeValue))
+			assert.Equal(t, tc.expectedLocation, timeValue.Location())
+		})
+	}
+}
+
+func Test_convertValueDate(t *testing.T) {
+	stockholm := time.FixedZone("Europe/Stockholm", 3600)
+	localDate := time.Date(1985, 1, 1, 0, 30, 0, 0, stockholm)
+
+	type test struct {
+		Day     time.Time  `gorm:"type:date"`
+		PtrDay  *time.Time `gorm:"type:DATE"`
+		Created time.Time
+	}
+
+	cases := []struct {
+		description   string
+		column        string
+		dialect       string
+		options       []Option
+		expectedValue interface{}
+	}{
+		{
+			description:   "date column formatted",
+			column:        "day",
+			expectedValue: "1985-01-01",
+		},
+		{
+			description:   "date pointer formatted",
+			column:        "ptr_day",
+			expectedValue: "1985-01-01",
+		},
+		{
+			description:   "date formatted in location",
+			column:        "day",
+			options:       []Option{WithUTC()},
+			expectedValue: "1984-12-31",
+		},
+		{
+			description:   "custom layout for dialect",
+			column:        "day",
+			dialect:       "mysql",
+			options:       []Option{WithDateLayout("20060102", "mysql")},
+			expectedValue: "19850101",
+		},
+		{
+			description:   "custom layout not used for other dialects",
+			column:        "day",
+			dialect:       "postgres",
+			options:       []Option{WithDateLayout("20060102", "mysql")},
+			expectedValue: "1985-01-01",
+		},
+		{
+			description:   "timestamp kept",
+			column:        "created",
+			expectedValue: localDate,
+		},
+	}
+
+	fields, err := ObjectToMap(test{Day: localDate, PtrDay: &localDate, Created: localDate})
+	require.NoError(t, err)
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			field, ok := fields[tc.column]
+			require.True(t, ok)
+
+			o := newOptions(tc.options...)
+			o.dialect = tc.dialect
+
+			value, err := o.convertValue(field, fieldValue(field))
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedValue, value)
+		})
+	}
+}
+
+func Test_checkSize(t *testing.T) {
+	type test struct {
+		Varchar string `gorm:"type:varchar(5)"`
+		Size    string `gorm:"size:3"`
+		NoSize  string
+	}
+
+	object := test{
+		Varchar: "abcdefgh",
+		Size:    "åäö",
+		NoSize:  "abcdefgh",
+	}
+
+	var truncated []*ColumnSizeError
+
+	cases := []struct {
+		description   string
+		column        string
+		options       []Option
+		expectedValue interface{}
+		expectedErr   *ColumnSizeError
+	}{
+		{
+			description:   "no validation without option",
+			column:        "varchar",
+			expectedValue: "abcdefgh",
+		},
+		{
+			description: "too long value returns error",
+			column:      "varchar",
+			options:     []Option{WithValidateStringSize()},
+			expectedErr: &ColumnSizeError{Row: 2, Column: "varchar", Size: 5, Length: 8},
+		},
+		{
+			description:   "size counts characters",
+			column:        "size",
+			options:       []Option{WithValidateStringSize()},
+			expectedValue: "åäö",
+		},
+		{
+			description:   "columns without size are ignored",
+			column:        "no_size",
+			options:       []Option{WithValidateStringSize()},
+			expectedValue: "abcdefgh",
+		},
+		{
+			description: "too long value is truncated",
+			column:      "varchar",
+			options: []Option{
+				WithTruncateStrings(func(err *ColumnSizeError) {
+					truncated = append(truncated, err)
+				}),
+			},
+			expectedValue: "abcde",
+		},
+	}
+
+	fields, err := ObjectToMap(object)
+	require.NoError(t, err)
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			field, ok := fields[tc.column]
+			require.True(t, ok)
+
+			value, err := newOptions(tc.options...).checkSize(2, field, fieldValue(field))
+			if tc.expectedErr != nil {
+				require.Error(t, err)
+				assert.Equal(t, tc.expectedErr, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedValue, value)
+		})
+	}
+
+	require.Len(t, truncated, 1)
+	assert.Equal(t, "varchar", truncated[0].Column)
+}
+
+func TestWithNilPointerPolicy(t *testing.T) {
+	type test struct {
+		Foo    string
+		PtrBar *string
+		PtrBaz *int
+	}
+
+	bar := "bar"
+
+	cases := []struct {
+		description  string
+		policy       NilPointerPolicy
+		expectedSQL  string
+		expectedArgs []driver.Value
+	}{
+		{
+			description:  "nil pointers bound as NULL by default",
+			policy:       NilPointerAsNull,
+			expectedSQL:  "INSERT INTO `tests` (`foo`, `ptr_bar`, `ptr_baz`) VALUES (?, ?, ?), (?, ?, ?)",
+			expectedArgs: []driver.Value{"a", "bar", nil, "b", nil, nil},
+		},
+		{
+			description:  "nil pointers bound as DEFAULT and nil columns omitted",
+			policy:       NilPointerAsDefault,
+			expectedSQL:  "INSERT INTO `tests` (`foo`, `ptr_bar`) VALUES (?, ?), (?, DEFAULT)",
+			expectedArgs: []driver.Value{"a", "bar", "b"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			mock.ExpectExec(regexp.QuoteMeta(tc.expectedSQL)).
+				WithArgs(tc.expectedArgs...).
+				WillReturnResult(sqlmock.NewResult(0, 2))
+
+			objects := []interface{}{
+				test{Foo: "a", PtrBar: &bar},
+				test{Foo: "b"},
+			}
+
+			require.NoError(t, BulkInsert(gdb, objects, WithNilPointerPolicy(tc.policy)))
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package gormbulk
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jinzhu/gorm"
+)
+
+// sqlExcerptLength is the max number of bytes of the SQL added to an
+// *ExecError when using ErrorsWithSQL.
+const sqlExcerptLength = 256
+
+// ErrorVerbosity decides how much context is added to errors from executing
+// the bulk statements.
+type ErrorVerbosity int
+
+// Available error verbosities.
+const (
+	// PlainErrors will return the error from the driver as is. This is the
+	// default verbosity.
+	PlainErrors ErrorVerbosity = iota
+
+	// ErrorsWithContext will return an *ExecError holding the table, chunk
+	// and rows of the failed statement.
+	ErrorsWithContext
+
+	// ErrorsWithSQL will also add a truncated excerpt of the SQL to the
+	// *ExecError. The vars are never part of the excerpt.
+	ErrorsWithSQL
+)
+
+// WithErrorVerbosity sets how much context is added to errors from executing
+// the bulk statements, i.e. to make logged errors actionable without logging
+// all SQL.
+func WithErrorVerbosity(verbosity ErrorVerbosity) Option {
+	return func(o *options) {
+		o.errorVerbosity = verbosity
+	}
+}
+
+// execError wraps the error from executing the statement in the scope in an
+// *ExecError according to the error verbosity. The rows holds the index of
+// each object in the statement.
+func (o *options) execError(scope *gorm.Scope, rows []int, err error) error {
+	if err == nil || o.errorVerbosity == PlainErrors {
+		return err
+	}
+
+	execErr := &ExecError{
+		Table: scope.TableName(),
+		Chunk: o.chunk,
+		Rows:  rows,
+		Err:   err,
+	}
+
+	if o.errorVerbosity == ErrorsWithSQL {
+		execErr.SQL = sqlExcerpt(scope.SQL)
+		execErr.Vars = len(scope.SQLVars)
+	}
+
+	return execErr
+}
+
+// sqlExcerpt returns the SQL with whitespace collapsed, truncated to
+// sqlExcerptLength bytes.
+func sqlExcerpt(sql string) string {
+	sql = strings.Join(strings.Fields(sql), " ")
+	if len(sql) <= sqlExcerptLength {
+		return sql
+	}
+
+	end := sqlExcerptLength
+	for end > 0 && !utf8.RuneStart(sql[end]) {
+		end--
+	}
+
+	return sql[:end] + "..."
+}
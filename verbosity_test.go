@@ -0,0 +1,131 @@
+package gormbulk
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithErrorVerbosity(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	driverErr := errors.New("duplicate entry")
+
+	cases := []struct {
+		description string
+		opts        []Option
+		expectedErr error
+	}{
+		{
+			description: "plain errors are returned as is",
+			expectedErr: driverErr,
+		},
+		{
+			description: "errors with context",
+			opts:        []Option{WithErrorVerbosity(ErrorsWithContext)},
+			expectedErr: &ExecError{
+				Table: "users",
+				Chunk: 1,
+				Rows:  []int{2, 3},
+				Err:   driverErr,
+			},
+		},
+		{
+			description: "errors with SQL",
+			opts:        []Option{WithErrorVerbosity(ErrorsWithSQL)},
+			expectedErr: &ExecError{
+				Table: "users",
+				Chunk: 1,
+				Rows:  []int{2, 3},
+				SQL:   "INSERT INTO `users` (`name`) VALUES (?), (?)",
+				Vars:  2,
+				Err:   driverErr,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users`")).
+				WithArgs("a", "b").
+				WillReturnResult(sqlmock.NewResult(0, 2))
+
+			mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `users`")).
+				WithArgs("c", "d").
+				WillReturnError(driverErr)
+
+			errs := BulkExecChunk(
+				gdb,
+				[]interface{}{user{"a"}, user{"b"}, user{"c"}, user{"d"}},
+				InsertFunc,
+				2,
+				tc.opts...,
+			)
+
+			require.Len(t, errs, 1)
+			assert.Equal(t, tc.expectedErr, errs[0])
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestExecError_Error(t *testing.T) {
+	err := &ExecError{
+		Table: "users",
+		Chunk: 3,
+		Rows:  []int{10, 11, 12},
+		SQL:   "INSERT INTO `users` (`name`) VALUES (?), (?), (?)",
+		Vars:  3,
+		Err:   errors.New("duplicate entry"),
+	}
+
+	assert.Equal(
+		t,
+		"table users chunk 3 rows 10-12: duplicate entry (SQL: INSERT INTO `users` (`name`) VALUES (?), (?), (?), 3 vars)",
+		err.Error(),
+	)
+}
+
+func Test_sqlExcerpt(t *testing.T) {
+	cases := []struct {
+		description string
+		sql         string
+		expected    string
+	}{
+		{
+			description: "whitespace is collapsed",
+			sql:         "INSERT INTO `users`\n\t(`name`)  VALUES (?)",
+			expected:    "INSERT INTO `users` (`name`) VALUES (?)",
+		},
+		{
+			description: "long SQL is truncated",
+			sql:         strings.Repeat("a", sqlExcerptLength+10),
+			expected:    strings.Repeat("a", sqlExcerptLength) + "...",
+		},
+		{
+			description: "truncated at rune boundary",
+			sql:         strings.Repeat("a", sqlExcerptLength-1) + "åäö",
+			expected:    strings.Repeat("a", sqlExcerptLength-1) + "...",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			assert.Equal(t, tc.expected, sqlExcerpt(tc.sql))
+		})
+	}
+}
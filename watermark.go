@@ -0,0 +1,51 @@
+package gormbulk
+
+import (
+	"reflect"
+	"time"
+)
+
+// WithWatermark will leave out all objects with an UpdatedAt field older than
+// the watermark before building the SQL, i.e. for incremental syncs. Objects
+// without an UpdatedAt field or with a blank UpdatedAt are kept. Use WithResult
+// to get the number of objects left out.
+func WithWatermark(watermark time.Time) Option {
+	return func(o *options) {
+		o.watermark = watermark
+	}
+}
+
+// isStale returns true if the object has an UpdatedAt field older than the
+// watermark.
+func (o *options) isStale(object interface{}) bool {
+	if o.watermark.IsZero() {
+		return false
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(object))
+	if rv.Kind() != reflect.Struct {
+		return false
+	}
+
+	field := rv.FieldByName("UpdatedAt")
+	if !field.IsValid() {
+		return false
+	}
+
+	var updatedAt time.Time
+
+	switch v := field.Interface().(type) {
+	case time.Time:
+		updatedAt = v
+	case *time.Time:
+		if v == nil {
+			return false
+		}
+
+		updatedAt = *v
+	default:
+		return false
+	}
+
+	return !updatedAt.IsZero() && updatedAt.Before(o.watermark)
+}
@@ -0,0 +1,48 @@
+package gormbulk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithWatermark(t *testing.T) {
+	type event struct {
+		Name      string
+		UpdatedAt time.Time
+	}
+
+	var (
+		watermark = time.Date(2019, 11, 1, 0, 0, 0, 0, time.UTC)
+		before    = watermark.Add(-time.Second)
+		after     = watermark.Add(time.Second)
+	)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	mock.ExpectExec("INSERT INTO `events`").
+		WithArgs("b", watermark, "c", after).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	var (
+		result  = &Result{}
+		objects = []interface{}{
+			event{Name: "a", UpdatedAt: before},
+			event{Name: "b", UpdatedAt: watermark},
+			&event{Name: "c", UpdatedAt: after},
+		}
+	)
+
+	require.NoError(t, BulkInsert(gdb, objects, WithWatermark(watermark), WithResult(result)))
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, 1, result.SkippedStale)
+}
@@ -0,0 +1,181 @@
+package gormbulk
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ErrWriterClosed is returned when writing to a closed BulkWriter.
+var ErrWriterClosed = errors.New("bulk writer is closed")
+
+// BulkWriter buffers objects and executes the SQL for each full chunk of
+// DefaultChunkSize objects (see WithChunkSize). Rows are counted from the
+// first object written. The session (see WithSetupSQL) is started for each
+// call to Write, Flush or Close executing any chunks. A BulkWriter is safe for
+// concurrent use.
+type BulkWriter struct {
+	db        *gorm.DB
+	execFunc  ExecFuncV2
+	o         *options
+	chunkSize int
+
+	mu      sync.Mutex
+	pending []interface{}
+	closed  bool
+	elapsed time.Duration
+	done    int
+}
+
+// NewBulkWriter returns a BulkWriter executing the SQL with the ExecFunc.
+func NewBulkWriter(db *gorm.DB, execFunc ExecFunc, opts ...Option) *BulkWriter {
+	o := newOptions(opts...)
+
+	return &BulkWriter{
+		db:        db,
+		execFunc:  execFunc.toV2(),
+		o:         o,
+		chunkSize: o.streamChunkSize(),
+	}
+}
+
+// Write adds the objects to the buffer and executes the SQL for all full
+// chunks. If a chunk fails, the error is returned and the objects in the chunk
+// are dropped.
+func (w *BulkWriter) Write(objects ...interface{}) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ErrWriterClosed
+	}
+
+	w.pending = append(w.pending, objects...)
+
+	if len(w.pending) < w.chunkSize {
+		return nil
+	}
+
+	return w.session(func() error {
+		for len(w.pending) >= w.chunkSize {
+			if err := w.flushChunk(); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Flush executes the SQL for all buffered objects, except a partial chunk
+// when using CarryOverPartialChunk.
+func (w *BulkWriter) Flush() error {
+	return w.close(context.Background(), false)
+}
+
+// Close flushes all buffered objects and closes the writer. The flush is
+// bounded by the context: no chunk is started when the context is done or
+// when it's estimated to not finish before the deadline, based on the time
+// per object for the chunks already executed. If any objects are left they
+// are returned in an *UnflushedError so they can be handled elsewhere, i.e.
+// when draining on shutdown.
+//
+//  ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//  defer cancel()
+//
+//  if err := w.Close(ctx); err != nil {
+//      ...
+//  }
+func (w *BulkWriter) Close(ctx context.Context) error {
+	return w.close(ctx, true)
+}
+
+func (w *BulkWriter) close(ctx context.Context, closeWriter bool) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ErrWriterClosed
+	}
+
+	w.closed = closeWriter
+
+	if len(w.pending) == 0 || w.carryOver(closeWriter) {
+		return nil
+	}
+
+	o := *w.o
+	o.ctx = ctx
+
+	return w.session(func() error {
+		for len(w.pending) > 0 && !w.carryOver(closeWriter) {
+			size := w.chunkSize
+			if len(w.pending) < size {
+				size = len(w.pending)
+			}
+
+			if err := o.canStartChunk(w.elapsed, w.done, size); err != nil {
+				unflushed := w.pending
+				w.pending = nil
+
+				return &UnflushedError{Objects: unflushed, Err: err}
+			}
+
+			if err := w.flushChunk(); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// carryOver returns true if the pending objects are a partial chunk kept for
+// the next flush, see CarryOverPartialChunk.
+func (w *BulkWriter) carryOver(closeWriter bool) bool {
+	return !closeWriter && w.o.partialChunkPolicy == CarryOverPartialChunk && len(w.pending) < w.chunkSize
+}
+
+// session runs fn in a session started with the options of the writer, see
+// WithSetupSQL. The first error from fn or ending the session is returned.
+func (w *BulkWriter) session(fn func() error) (err error) {
+	if err := w.o.probeHealth(w.db); err != nil {
+		return err
+	}
+
+	end, err := w.o.startSession(w.db)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if endErr := end(); err == nil {
+			err = endErr
+		}
+	}()
+
+	return fn()
+}
+
+// flushChunk executes the SQL for the first chunk of pending objects.
+func (w *BulkWriter) flushChunk() error {
+	size := w.chunkSize
+	if len(w.pending) < size {
+		size = len(w.pending)
+	}
+
+	chunk := w.pending[:size]
+	w.pending = w.pending[size:]
+
+	started := time.Now()
+
+	_, err := w.o.execChunk(w.db, chunk, w.execFunc)
+
+	w.elapsed += time.Since(started)
+	w.done += size
+
+	return err
+}
@@ -0,0 +1,156 @@
+package gormbulk
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkWriter(t *testing.T) {
+	type test struct {
+		Foo string
+	}
+
+	cases := []struct {
+		description      string
+		objects          []interface{}
+		ctx              func() (context.Context, context.CancelFunc)
+		expectedMockFunc func(mock sqlmock.Sqlmock)
+		expectedErr      error
+	}{
+		{
+			description: "full chunks written and rest flushed on close",
+			objects:     []interface{}{test{"a"}, test{"b"}, test{"c"}},
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `tests`").
+					WithArgs("a", "b").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec("INSERT INTO `tests`").
+					WithArgs("c").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			description: "pending objects reported when context is done",
+			objects:     []interface{}{test{"a"}, test{"b"}, test{"c"}},
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				return ctx, cancel
+			},
+			expectedMockFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `tests`").
+					WithArgs("a", "b").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+			expectedErr: &UnflushedError{
+				Objects: []interface{}{test{"c"}},
+				Err:     context.Canceled,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			gdb, err := gorm.Open("mysql", db)
+			require.NoError(t, err)
+
+			tc.expectedMockFunc(mock)
+
+			w := NewBulkWriter(gdb, InsertFunc, WithChunkSize(2))
+			require.NoError(t, w.Write(tc.objects...))
+
+			ctx, cancel := tc.ctx()
+			defer cancel()
+
+			err = w.Close(ctx)
+			assert.Equal(t, tc.expectedErr, err)
+			require.NoError(t, mock.ExpectationsWereMet())
+
+			assert.Equal(t, ErrWriterClosed, w.Write(test{"d"}))
+		})
+	}
+}
+
+func TestBulkWriterFlush(t *testing.T) {
+	type test struct {
+		Foo string
+	}
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	mock.ExpectExec("INSERT INTO `tests`").
+		WithArgs("a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `tests`").
+		WithArgs("b").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	w := NewBulkWriter(gdb, InsertFunc)
+
+	require.NoError(t, w.Write(test{"a"}))
+	require.NoError(t, w.Flush())
+	require.NoError(t, w.Write(test{"b"}))
+	require.NoError(t, w.Close(context.Background()))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBulkWriterSession(t *testing.T) {
+	type test struct {
+		Foo string
+	}
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gdb, err := gorm.Open("mysql", db)
+	require.NoError(t, err)
+
+	w := NewBulkWriter(
+		gdb, InsertFunc,
+		WithChunkSize(2),
+		WithSetupSQL("SET unique_checks = 0"),
+		WithTeardownSQL("SET unique_checks = 1"),
+	)
+
+	// No session is started until a chunk is executed.
+	require.NoError(t, w.Write(test{"a"}))
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	mock.ExpectExec("SET unique_checks = 0").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO `tests`").
+		WithArgs("a", "b").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("SET unique_checks = 1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, w.Write(test{"b"}, test{"c"}))
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	mock.ExpectExec("SET unique_checks = 0").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO `tests`").
+		WithArgs("c").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("SET unique_checks = 1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, w.Close(context.Background()))
+	require.NoError(t, mock.ExpectationsWereMet())
+}